@@ -14,14 +14,17 @@ interface SessionResponse {
   session_id: string;
   websocket_url: string;
   status: string;
+  ticket?: string; // Present when the server requires authentication
 }
 
 interface AudioStreamerProps {
+  accessToken?: string; // Bearer token, for servers with authentication enabled
   onStatusChange?: (status: string) => void;
   onTranscript?: (text: string, type: string) => void;
 }
 
 const AudioStreamer: React.FC<AudioStreamerProps> = ({
+  accessToken,
   onStatusChange,
   onTranscript,
 }) => {
@@ -35,6 +38,12 @@ const AudioStreamer: React.FC<AudioStreamerProps> = ({
   const statusIntervalRef = useRef<NodeJS.Timeout | null>(null);
   const isConnectingRef = useRef<boolean>(false);
 
+  const authHeaders = useCallback(
+    (): Record<string, string> =>
+      accessToken ? { Authorization: `Bearer ${accessToken}` } : {},
+    [accessToken]
+  );
+
   const updateStatus = useCallback(
     (newStatus: string) => {
       setStatus(newStatus);
@@ -67,6 +76,7 @@ const AudioStreamer: React.FC<AudioStreamerProps> = ({
           `${API_BASE_URL}/api/interview/close?session_id=${sessionId}`,
           {
             method: "DELETE",
+            headers: authHeaders(),
           }
         );
         setSessionId(null);
@@ -74,7 +84,7 @@ const AudioStreamer: React.FC<AudioStreamerProps> = ({
         console.error("Error closing session:", err);
       }
     }
-  }, [sessionId]);
+  }, [sessionId, authHeaders]);
 
   const initializeSession = async (): Promise<SessionResponse | null> => {
     if (isConnectingRef.current) {
@@ -88,6 +98,7 @@ const AudioStreamer: React.FC<AudioStreamerProps> = ({
         method: "POST",
         headers: {
           "Content-Type": "application/json",
+          ...authHeaders(),
         },
         body: JSON.stringify({
           sample_rate: SAMPLE_RATE,
@@ -177,7 +188,10 @@ const AudioStreamer: React.FC<AudioStreamerProps> = ({
       const sessionData = await initializeSession();
       if (!sessionData) return;
 
-      const websocket = new WebSocket(sessionData.websocket_url);
+      const websocketUrl = sessionData.ticket
+        ? `${sessionData.websocket_url}?ticket=${encodeURIComponent(sessionData.ticket)}`
+        : sessionData.websocket_url;
+      const websocket = new WebSocket(websocketUrl);
       websocketRef.current = websocket;
 
       websocket.onopen = async () => {
@@ -212,7 +226,8 @@ const AudioStreamer: React.FC<AudioStreamerProps> = ({
 
         try {
           const response = await fetch(
-            `${API_BASE_URL}/api/interview/status?session_id=${sessionData.session_id}`
+            `${API_BASE_URL}/api/interview/status?session_id=${sessionData.session_id}`,
+            { headers: authHeaders() }
           );
           if (!response.ok) {
             throw new Error(`Status check failed: ${response.statusText}`);
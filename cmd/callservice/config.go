@@ -1,12 +1,777 @@
 package main
 
-// Config holds the application configuration
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/sashabaranov/go-openai"
+	"github.com/torteous44/callservice/internal/audio/stt"
+	"github.com/torteous44/callservice/internal/audio/tts"
+	"github.com/torteous44/callservice/internal/audio/vad"
+	"github.com/torteous44/callservice/internal/auth"
+	"github.com/torteous44/callservice/internal/contextbrain"
+	"github.com/torteous44/callservice/internal/health"
+	"github.com/torteous44/callservice/internal/limits"
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/orchestrator"
+	"github.com/torteous44/callservice/internal/sessionstate"
+	"github.com/torteous44/callservice/internal/tlsconfig"
+	"github.com/torteous44/callservice/internal/tracing"
+	"github.com/torteous44/callservice/pkg/transport"
+	"gopkg.in/yaml.v3"
+)
+
+// Default locations of the config layers, relative to the working directory
+const (
+	defaultConfigFile = "configs/default.yaml"
+	defaultEnvFile    = ".env"
+)
+
+// redactedValue replaces secrets in the effective config dump
+const redactedValue = "[redacted]"
+
+// minJWTSecretLength is the shortest HS256 secret accepted, the size of the hash
+const minJWTSecretLength = 32
+
+// Config holds the application configuration. Values are layered, each layer
+// overriding the one before: built-in defaults, the YAML config file, the env
+// file, environment variables and command line flags. Every setting can be
+// set with the environment variable in its env tag or with a flag named after
+// its YAML path, e.g. -server.port. Fields tagged secret are redacted from
+// the config dump
 type Config struct {
-	// TODO: Add configuration fields
+	Server  ServerConfig  `yaml:"server"`
+	Auth    AuthConfig    `yaml:"auth"`
+	Limits  LimitsConfig  `yaml:"limits"`
+	Audio   AudioConfig   `yaml:"audio"`
+	VAD     VADConfig     `yaml:"vad"`
+	STT     STTConfig     `yaml:"stt"`
+	TTS     TTSConfig     `yaml:"tts"`
+	LLM     LLMConfig     `yaml:"llm"`
+	Storage StorageConfig `yaml:"storage"`
+	Logging LoggingConfig `yaml:"logging"`
+	Tracing TracingConfig `yaml:"tracing"`
+	Health  HealthConfig  `yaml:"health"`
+}
+
+// ServerConfig configures the listeners and client connections
+type ServerConfig struct {
+	Host                string          `yaml:"host" env:"HOST"` // Empty listens on all interfaces
+	Port                int             `yaml:"port" env:"PORT"`
+	GRPCPort            int             `yaml:"grpc_port" env:"GRPC_PORT"` // 0 disables the gRPC API
+	ResumeTokenSecret   string          `yaml:"resume_token_secret" env:"RESUME_TOKEN_SECRET" secret:"true"`
+	ObserverAPIKey      string          `yaml:"observer_api_key" env:"OBSERVER_API_KEY" secret:"true"`
+	AdminAPIKey         string          `yaml:"admin_api_key" env:"ADMIN_API_KEY" secret:"true"`
+	PublicURL           string          `yaml:"public_url" env:"PUBLIC_URL"`                     // Base URL clients reach the service at, empty derives it from requests
+	TrustedProxies      []string        `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`           // IPs or CIDRs allowed to set X-Forwarded-Proto/Host
+	AdvertiseTransports bool            `yaml:"advertise_transports" env:"ADVERTISE_TRANSPORTS"` // Return SSE and WebRTC URLs with new sessions
+	DrainTimeout        time.Duration   `yaml:"drain_timeout" env:"DRAIN_TIMEOUT"`               // How long interviews may finish on shutdown
+	WebSocket           WebSocketConfig `yaml:"websocket"`
+	WebRTC              WebRTCConfig    `yaml:"webrtc"`
+	TLS                 TLSConfig       `yaml:"tls"`
+	CORS                CORSConfig      `yaml:"cors"`
+}
+
+// WebSocketConfig configures client connection keepalive
+type WebSocketConfig struct {
+	PingInterval     time.Duration `yaml:"ping_interval" env:"WS_PING_INTERVAL"`
+	ReadTimeout      time.Duration `yaml:"read_timeout" env:"WS_READ_TIMEOUT"`
+	WriteTimeout     time.Duration `yaml:"write_timeout" env:"WS_WRITE_TIMEOUT"`
+	HeartbeatTimeout time.Duration `yaml:"heartbeat_timeout" env:"WS_HEARTBEAT_TIMEOUT"`
+}
+
+// TLSConfig enables HTTPS, WSS and gRPC over TLS when a certificate is set
+type TLSConfig struct {
+	CertFile       string        `yaml:"cert_file" env:"TLS_CERT_FILE"`
+	KeyFile        string        `yaml:"key_file" env:"TLS_KEY_FILE"`
+	CertDir        string        `yaml:"cert_dir" env:"TLS_CERT_DIR"` // Holds tls.crt and tls.key, instead of the files
+	MinVersion     string        `yaml:"min_version" env:"TLS_MIN_VERSION"`
+	CipherSuites   []string      `yaml:"cipher_suites" env:"TLS_CIPHER_SUITES"`   // TLS 1.2 suites, empty uses Go's defaults
+	ClientCAFile   string        `yaml:"client_ca_file" env:"TLS_CLIENT_CA_FILE"` // Requires client certificates for admin and gRPC APIs
+	ReloadInterval time.Duration `yaml:"reload_interval" env:"TLS_RELOAD_INTERVAL"`
+}
+
+// Options returns the TLS options
+func (c TLSConfig) Options() tlsconfig.Options {
+	return tlsconfig.Options{
+		CertFile:       c.CertFile,
+		KeyFile:        c.KeyFile,
+		CertDir:        c.CertDir,
+		MinVersion:     c.MinVersion,
+		CipherSuites:   c.CipherSuites,
+		ClientCAFile:   c.ClientCAFile,
+		ReloadInterval: c.ReloadInterval,
+	}
+}
+
+// CORSConfig lists the browser origins allowed to call the API and open
+// WebSockets, besides the service's own
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"` // e.g. https://app.example.com or https://*.example.com
+	AllowCredentials bool          `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `yaml:"max_age" env:"CORS_MAX_AGE"` // How long browsers cache preflights
+}
+
+// Options returns the CORS options
+func (c CORSConfig) Options() auth.CORSOptions {
+	return auth.CORSOptions{
+		AllowedOrigins:   c.AllowedOrigins,
+		AllowCredentials: c.AllowCredentials,
+		MaxAge:           c.MaxAge,
+	}
+}
+
+// AuthConfig configures JWT bearer authentication of API callers. Setting a
+// secret or JWKS file enables it; sessions are then owned by the token subject
+type AuthConfig struct {
+	JWTSecret string        `yaml:"jwt_secret" env:"AUTH_JWT_SECRET" secret:"true"` // Verifies HS256 tokens
+	JWKSFile  string        `yaml:"jwks_file" env:"AUTH_JWKS_FILE"`                 // Verifies RS256 tokens, reloaded when it changes
+	Issuer    string        `yaml:"issuer" env:"AUTH_ISSUER"`                       // Required iss claim, if set
+	Audience  string        `yaml:"audience" env:"AUTH_AUDIENCE"`                   // Required aud claim, if set
+	TicketTTL time.Duration `yaml:"ticket_ttl" env:"AUTH_TICKET_TTL"`               // How long WebSocket tickets are valid
+}
+
+// Options returns the bearer token options
+func (c AuthConfig) Options() auth.Options {
+	return auth.Options{
+		Secret:   []byte(c.JWTSecret),
+		JWKSFile: c.JWKSFile,
+		Issuer:   c.Issuer,
+		Audience: c.Audience,
+	}
 }
 
-// LoadConfig loads the application configuration
-func LoadConfig() (*Config, error) {
-	// TODO: Implement configuration loading
-	return &Config{}, nil
+// LimitsConfig caps what clients may spend: session creation rates, concurrent
+// sessions and a budget per session. Zero values disable a limit
+type LimitsConfig struct {
+	PerUser                RateConfig   `yaml:"per_user"`
+	PerIP                  RateConfig   `yaml:"per_ip"`
+	Global                 RateConfig   `yaml:"global"`
+	MaxSessionsPerUser     int          `yaml:"max_sessions_per_user" env:"LIMITS_MAX_SESSIONS_PER_USER"` // Concurrent active sessions of one authenticated user
+	MaxSessionsPerInstance int          `yaml:"max_sessions_per_instance" env:"LIMITS_MAX_SESSIONS_PER_INSTANCE"`
+	Budget                 BudgetConfig `yaml:"budget"`
+}
+
+// RateConfig is a token bucket limit on new sessions
+type RateConfig struct {
+	SessionsPerMinute float64 `yaml:"sessions_per_minute"`
+	Burst             int     `yaml:"burst"` // Sessions created at once, defaults to sessions_per_minute
+}
+
+// Options returns the rate
+func (c RateConfig) Options() limits.Rate {
+	return limits.Rate{PerMinute: c.SessionsPerMinute, Burst: c.Burst}
+}
+
+// BudgetConfig is what a session may spend on paid services
+type BudgetConfig struct {
+	AudioMinutes  float64 `yaml:"audio_minutes" env:"LIMITS_BUDGET_AUDIO_MINUTES"` // Candidate audio streamed to speech recognition
+	LLMTokens     int     `yaml:"llm_tokens" env:"LIMITS_BUDGET_LLM_TOKENS"`
+	TTSCharacters int     `yaml:"tts_characters" env:"LIMITS_BUDGET_TTS_CHARACTERS"`
+}
+
+// Options returns the session limits
+func (c LimitsConfig) Options() limits.Options {
+	return limits.Options{
+		PerUser:            c.PerUser.Options(),
+		PerIP:              c.PerIP.Options(),
+		Global:             c.Global.Options(),
+		MaxSessionsPerUser: c.MaxSessionsPerUser,
+		MaxSessions:        c.MaxSessionsPerInstance,
+		Budget: limits.Spend{
+			Audio:         time.Duration(c.Budget.AudioMinutes * float64(time.Minute)),
+			LLMTokens:     c.Budget.LLMTokens,
+			TTSCharacters: c.Budget.TTSCharacters,
+		},
+	}
+}
+
+// WebRTCConfig configures WebRTC peers
+type WebRTCConfig struct {
+	ICEServers []string `yaml:"ice_servers" env:"RTC_ICE_SERVERS"` // STUN/TURN URLs, comma-separated in env
+}
+
+// AudioConfig is the audio format of sessions that do not ask for one
+type AudioConfig struct {
+	SampleRate int    `yaml:"sample_rate" env:"AUDIO_SAMPLE_RATE"`
+	Encoding   string `yaml:"encoding" env:"AUDIO_ENCODING"`
+}
+
+// VADConfig tunes voice activity detection
+type VADConfig struct {
+	EnergyThreshold  float64 `yaml:"energy_threshold" env:"VAD_ENERGY_THRESHOLD"`
+	MinVoiceFrames   int     `yaml:"min_voice_frames" env:"VAD_MIN_VOICE_FRAMES"`
+	MinSilenceFrames int     `yaml:"min_silence_frames" env:"VAD_MIN_SILENCE_FRAMES"`
+	SmoothingWindow  int     `yaml:"smoothing_window" env:"VAD_SMOOTHING_WINDOW"`
+}
+
+// STTConfig configures AssemblyAI streaming and its turn detection
+type STTConfig struct {
+	APIKey                           string  `yaml:"api_key" env:"ASSEMBLYAI_API_KEY" secret:"true"`
+	FormatTurns                      bool    `yaml:"format_turns" env:"STT_FORMAT_TURNS"`
+	EndOfTurnConfidenceThreshold     float64 `yaml:"end_of_turn_confidence_threshold" env:"STT_END_OF_TURN_CONFIDENCE_THRESHOLD"`
+	MinEndOfTurnSilenceWhenConfident int     `yaml:"min_end_of_turn_silence_when_confident" env:"STT_MIN_END_OF_TURN_SILENCE_WHEN_CONFIDENT"` // Milliseconds
+	MaxTurnSilence                   int     `yaml:"max_turn_silence" env:"STT_MAX_TURN_SILENCE"`                                             // Milliseconds
+}
+
+// TTSConfig configures OpenAI speech synthesis
+type TTSConfig struct {
+	APIKey string  `yaml:"api_key" env:"OPENAI_API_KEY" secret:"true"`
+	Model  string  `yaml:"model" env:"TTS_MODEL"`
+	Voice  string  `yaml:"voice" env:"TTS_VOICE"`
+	Format string  `yaml:"format" env:"TTS_FORMAT"`
+	Speed  float64 `yaml:"speed" env:"TTS_SPEED"`
+}
+
+// Options returns the synthesis options of the interviewer's speech
+func (c TTSConfig) Options() tts.SynthesizeOptions {
+	return tts.SynthesizeOptions{
+		Model:  openai.SpeechModel(c.Model),
+		Voice:  openai.SpeechVoice(c.Voice),
+		Format: openai.SpeechResponseFormat(c.Format),
+		Speed:  c.Speed,
+	}
+}
+
+// LLMConfig configures the OpenAI model behind grading and follow-ups
+type LLMConfig struct {
+	APIKey      string        `yaml:"api_key" env:"OPENAI_API_KEY" secret:"true"`
+	Model       string        `yaml:"model" env:"LLM_MODEL"`
+	Temperature float64       `yaml:"temperature" env:"LLM_TEMPERATURE"`
+	MaxTokens   int           `yaml:"max_tokens" env:"LLM_MAX_TOKENS"`
+	Timeout     time.Duration `yaml:"timeout" env:"LLM_TIMEOUT"`
+
+	// Interviewer grades every final transcript and speaks a follow-up,
+	// which costs two LLM calls and one TTS call per answer
+	Interviewer bool `yaml:"interviewer" env:"LLM_INTERVIEWER"`
+}
+
+// Options returns the context brain client options
+func (c LLMConfig) Options() contextbrain.Options {
+	return contextbrain.Options{
+		APIKey:      c.APIKey,
+		Model:       c.Model,
+		Temperature: float32(c.Temperature),
+		MaxTokens:   c.MaxTokens,
+		Timeout:     c.Timeout,
+	}
+}
+
+// StorageConfig configures session state and the analytics database
+type StorageConfig struct {
+	RedisURL   string          `yaml:"redis_url" env:"REDIS_URL" secret:"true"` // Empty keeps sessions in memory
+	SessionTTL time.Duration   `yaml:"session_ttl" env:"SESSION_TTL"`
+	Analytics  AnalyticsConfig `yaml:"analytics"`
+}
+
+// AnalyticsConfig is the database completed sessions are flushed to
+type AnalyticsConfig struct {
+	Driver string `yaml:"driver" env:"ANALYTICS_DRIVER"`
+	DSN    string `yaml:"dsn" env:"ANALYTICS_DSN" secret:"true"` // Empty disables the analytics flush
+}
+
+// LoggingConfig configures the service logs
+type LoggingConfig struct {
+	Level       string `yaml:"level" env:"LOG_LEVEL"`
+	Format      string `yaml:"format" env:"LOG_FORMAT"`
+	Transcripts string `yaml:"transcripts" env:"LOG_TRANSCRIPTS"` // How candidate speech is logged: hash, redact or plain
+}
+
+// Options returns the logger options
+func (c LoggingConfig) Options() logging.Options {
+	return logging.Options{
+		Level:       c.Level,
+		Format:      c.Format,
+		Transcripts: c.Transcripts,
+	}
+}
+
+// TracingConfig configures the export of pipeline spans
+type TracingConfig struct {
+	Endpoint    string  `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"` // OTLP/gRPC collector URL, empty disables tracing
+	ServiceName string  `yaml:"service_name" env:"OTEL_SERVICE_NAME"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"` // Share of sessions traced
+}
+
+// Options returns the span export options
+func (c TracingConfig) Options() tracing.Options {
+	return tracing.Options{
+		Endpoint:    c.Endpoint,
+		ServiceName: c.ServiceName,
+		SampleRatio: c.SampleRatio,
+	}
+}
+
+// HealthConfig configures the readiness checks
+type HealthConfig struct {
+	Timeout        time.Duration `yaml:"timeout" env:"HEALTH_TIMEOUT"`                 // Of each check
+	ProbeProviders bool          `yaml:"probe_providers" env:"HEALTH_PROBE_PROVIDERS"` // Also check AssemblyAI and OpenAI
+	ProbeInterval  time.Duration `yaml:"probe_interval" env:"HEALTH_PROBE_INTERVAL"`   // How long a provider check is reused
+}
+
+// DefaultConfig returns the built-in defaults, used for anything the config
+// file leaves out
+func DefaultConfig() *Config {
+	ws := transport.DefaultWSOptions()
+	streaming := stt.GetDefaultStreamingConfig()
+	detector := vad.DefaultConfig()
+	speech := tts.GetDefaultOptions()
+
+	return &Config{
+		Server: ServerConfig{
+			Port:         8080,
+			DrainTimeout: 25 * time.Second,
+			TLS: TLSConfig{
+				MinVersion:     "1.2",
+				ReloadInterval: tlsconfig.DefaultReloadInterval,
+			},
+			CORS: CORSConfig{
+				MaxAge: 10 * time.Minute,
+			},
+			WebSocket: WebSocketConfig{
+				PingInterval:     ws.PingInterval,
+				ReadTimeout:      ws.ReadTimeout,
+				WriteTimeout:     ws.WriteTimeout,
+				HeartbeatTimeout: ws.HeartbeatTimeout,
+			},
+		},
+		Auth: AuthConfig{
+			TicketTTL: orchestrator.DefaultTicketTTL,
+		},
+		Limits: LimitsConfig{
+			PerUser:                RateConfig{SessionsPerMinute: 5, Burst: 10},
+			PerIP:                  RateConfig{SessionsPerMinute: 10, Burst: 20},
+			Global:                 RateConfig{SessionsPerMinute: 300, Burst: 100},
+			MaxSessionsPerUser:     3,
+			MaxSessionsPerInstance: 200,
+			Budget: BudgetConfig{
+				AudioMinutes:  120,
+				LLMTokens:     200000,
+				TTSCharacters: 100000,
+			},
+		},
+		Audio: AudioConfig{
+			SampleRate: streaming.SampleRate,
+			Encoding:   streaming.Encoding,
+		},
+		VAD: VADConfig{
+			EnergyThreshold:  detector.EnergyThreshold,
+			MinVoiceFrames:   detector.MinVoiceFrames,
+			MinSilenceFrames: detector.MinSilenceFrames,
+			SmoothingWindow:  detector.SmoothingWindow,
+		},
+		STT: STTConfig{
+			FormatTurns:                      streaming.FormatTurns,
+			EndOfTurnConfidenceThreshold:     streaming.EndOfTurnConfidenceThreshold,
+			MinEndOfTurnSilenceWhenConfident: streaming.MinEndOfTurnSilenceWhenConfident,
+			MaxTurnSilence:                   streaming.MaxTurnSilence,
+		},
+		TTS: TTSConfig{
+			Model:  string(speech.Model),
+			Voice:  string(speech.Voice),
+			Format: string(speech.Format),
+			Speed:  speech.Speed,
+		},
+		LLM: LLMConfig{
+			Model:       "gpt-4o-mini",
+			Temperature: 0.7,
+			MaxTokens:   512,
+			Timeout:     30 * time.Second,
+		},
+		Storage: StorageConfig{
+			SessionTTL: sessionstate.DefaultSessionTTL,
+			Analytics:  AnalyticsConfig{Driver: "sqlite"},
+		},
+		Logging: LoggingConfig{
+			Level:       "info",
+			Format:      "text",
+			Transcripts: logging.TranscriptsHash,
+		},
+		Tracing: TracingConfig{
+			ServiceName: "callservice",
+			SampleRatio: 1,
+		},
+		Health: HealthConfig{
+			Timeout:       health.DefaultTimeout,
+			ProbeInterval: time.Minute,
+		},
+	}
+}
+
+// LoadConfig loads the application configuration from its layers and
+// validates it. args are the command line arguments without the program name
+func LoadConfig(args []string) (*Config, error) {
+	config := DefaultConfig()
+
+	flags := flag.NewFlagSet("callservice", flag.ContinueOnError)
+	configFile := flags.String("config", defaultConfigFile, "YAML config file")
+	envFile := flags.String("env-file", defaultEnvFile, "env file loaded into the environment")
+
+	// Flags are applied last, in the order given, once the other layers are in
+	type override struct{ path, value string }
+	var overrides []override
+	walkConfig(config, func(path string, field reflect.StructField, value reflect.Value) {
+		usage := fmt.Sprintf("overrides %s", path)
+		if env := field.Tag.Get("env"); env != "" {
+			usage = fmt.Sprintf("overrides %s and $%s", path, env)
+		}
+		flags.Func(path, usage, func(raw string) error {
+			// Only check the value for now
+			if err := setField(reflect.New(value.Type()).Elem(), raw); err != nil {
+				return err
+			}
+			overrides = append(overrides, override{path, raw})
+			return nil
+		})
+	})
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	explicit := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	if err := loadConfigFile(config, *configFile, explicit["config"]); err != nil {
+		return nil, err
+	}
+
+	// The env file does not override variables already in the environment
+	if err := godotenv.Load(*envFile); err != nil && (explicit["env-file"] || !errors.Is(err, fs.ErrNotExist)) {
+		return nil, fmt.Errorf("failed to load env file %s: %w", *envFile, err)
+	}
+	if err := applyEnv(config); err != nil {
+		return nil, err
+	}
+
+	fields := configFields(config)
+	for _, o := range overrides {
+		if err := setField(fields[o.path], o.value); err != nil {
+			return nil, fmt.Errorf("invalid -%s: %w", o.path, err)
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// loadConfigFile decodes a YAML config file over config. A missing file is
+// only an error if it was asked for explicitly
+func loadConfigFile(config *Config, path string, required bool) error {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv sets every field whose environment variable is set
+func applyEnv(config *Config) error {
+	var errs []error
+	walkConfig(config, func(path string, field reflect.StructField, value reflect.Value) {
+		env := field.Tag.Get("env")
+		raw, ok := os.LookupEnv(env)
+		if env == "" || !ok || raw == "" {
+			return
+		}
+		if err := setField(value, raw); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", env, err))
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// walkConfig calls visit with every setting of config, named by its dotted
+// YAML path
+func walkConfig(config *Config, visit func(path string, field reflect.StructField, value reflect.Value)) {
+	var walk func(prefix string, v reflect.Value)
+	walk = func(prefix string, v reflect.Value) {
+		for i := range v.NumField() {
+			field := v.Type().Field(i)
+			path := prefix + field.Tag.Get("yaml")
+			if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+				walk(path+".", v.Field(i))
+				continue
+			}
+			visit(path, field, v.Field(i))
+		}
+	}
+	walk("", reflect.ValueOf(config).Elem())
+}
+
+// configFields indexes the settings of config by path
+func configFields(config *Config) map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+	walkConfig(config, func(path string, _ reflect.StructField, value reflect.Value) {
+		fields[path] = value
+	})
+	return fields
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setField parses raw into a setting. Lists are comma-separated
+func setField(value reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+	if value.Type() == durationType {
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(duration))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		value.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		value.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		value.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", value.Type())
+	}
+	return nil
+}
+
+// Validate checks the configuration, reporting every invalid setting
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, path, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port", "must be between 1 and 65535, got %d", c.Server.Port)
+	check(c.Server.GRPCPort >= 0 && c.Server.GRPCPort <= 65535, "server.grpc_port", "must be between 0 and 65535, got %d", c.Server.GRPCPort)
+	check(c.Server.GRPCPort != c.Server.Port, "server.grpc_port", "must differ from server.port")
+	check(c.Server.DrainTimeout >= 0, "server.drain_timeout", "must not be negative")
+	ws := c.Server.WebSocket
+	check(ws.PingInterval > 0, "server.websocket.ping_interval", "must be positive")
+	check(ws.WriteTimeout > 0, "server.websocket.write_timeout", "must be positive")
+	check(ws.HeartbeatTimeout > 0, "server.websocket.heartbeat_timeout", "must be positive")
+	check(ws.ReadTimeout > ws.PingInterval, "server.websocket.read_timeout", "must be longer than ping_interval (%s), got %s", ws.PingInterval, ws.ReadTimeout)
+	if c.Server.PublicURL != "" {
+		_, err := orchestrator.ParsePublicURL(c.Server.PublicURL)
+		check(err == nil, "server.public_url", "%v", err)
+	}
+	_, err := orchestrator.ParseTrustedProxies(c.Server.TrustedProxies)
+	check(err == nil, "server.trusted_proxies", "%v", err)
+	err = c.Server.TLS.Options().Validate()
+	check(err == nil, "server.tls", "%v", err)
+	_, err = auth.NewOriginPolicy(c.Server.CORS.Options())
+	check(err == nil, "server.cors.allowed_origins", "%v", err)
+	check(!c.Server.CORS.AllowCredentials || !slices.Contains(c.Server.CORS.AllowedOrigins, "*"),
+		"server.cors.allow_credentials", "cannot be combined with allowing any origin")
+	check(c.Server.CORS.MaxAge >= 0, "server.cors.max_age", "must not be negative")
+	for _, server := range c.Server.WebRTC.ICEServers {
+		check(strings.HasPrefix(server, "stun:") || strings.HasPrefix(server, "turn:") || strings.HasPrefix(server, "turns:"),
+			"server.webrtc.ice_servers", "%q is not a stun:, turn: or turns: URL", server)
+	}
+
+	check(c.Auth.JWTSecret == "" || len(c.Auth.JWTSecret) >= minJWTSecretLength, "auth.jwt_secret",
+		"must be at least %d bytes", minJWTSecretLength)
+	check(c.Auth.TicketTTL > 0, "auth.ticket_ttl", "must be positive")
+
+	checkRate := func(path string, rate RateConfig) {
+		check(rate.SessionsPerMinute >= 0, path+".sessions_per_minute", "must not be negative")
+		check(rate.Burst >= 0, path+".burst", "must not be negative")
+	}
+	checkRate("limits.per_user", c.Limits.PerUser)
+	checkRate("limits.per_ip", c.Limits.PerIP)
+	checkRate("limits.global", c.Limits.Global)
+	check(c.Limits.MaxSessionsPerUser >= 0, "limits.max_sessions_per_user", "must not be negative")
+	check(c.Limits.MaxSessionsPerInstance >= 0, "limits.max_sessions_per_instance", "must not be negative")
+	check(c.Limits.Budget.AudioMinutes >= 0, "limits.budget.audio_minutes", "must not be negative")
+	check(c.Limits.Budget.LLMTokens >= 0, "limits.budget.llm_tokens", "must not be negative")
+	check(c.Limits.Budget.TTSCharacters >= 0, "limits.budget.tts_characters", "must not be negative")
+
+	check(c.Audio.SampleRate >= 8000 && c.Audio.SampleRate <= 48000, "audio.sample_rate", "must be between 8000 and 48000 Hz, got %d", c.Audio.SampleRate)
+	check(c.Audio.Encoding == "pcm_s16le" || c.Audio.Encoding == "pcm_mulaw", "audio.encoding", "must be pcm_s16le or pcm_mulaw, got %q", c.Audio.Encoding)
+
+	check(c.VAD.EnergyThreshold > 0, "vad.energy_threshold", "must be positive")
+	check(c.VAD.MinVoiceFrames > 0, "vad.min_voice_frames", "must be at least 1")
+	check(c.VAD.MinSilenceFrames > 0, "vad.min_silence_frames", "must be at least 1")
+	check(c.VAD.SmoothingWindow > 0, "vad.smoothing_window", "must be at least 1")
+
+	check(c.STT.APIKey != "", "stt.api_key", "is required, set ASSEMBLYAI_API_KEY")
+	check(c.STT.EndOfTurnConfidenceThreshold > 0 && c.STT.EndOfTurnConfidenceThreshold <= 1,
+		"stt.end_of_turn_confidence_threshold", "must be in (0, 1], got %g", c.STT.EndOfTurnConfidenceThreshold)
+	check(c.STT.MinEndOfTurnSilenceWhenConfident >= 0, "stt.min_end_of_turn_silence_when_confident", "must not be negative")
+	check(c.STT.MaxTurnSilence >= c.STT.MinEndOfTurnSilenceWhenConfident, "stt.max_turn_silence",
+		"must be at least min_end_of_turn_silence_when_confident (%d), got %d", c.STT.MinEndOfTurnSilenceWhenConfident, c.STT.MaxTurnSilence)
+
+	check(c.TTS.Model != "", "tts.model", "is required")
+	check(slices.Contains(ttsVoices, c.TTS.Voice), "tts.voice", "must be one of %s, got %q", strings.Join(ttsVoices, ", "), c.TTS.Voice)
+	check(slices.Contains(ttsFormats, c.TTS.Format), "tts.format", "must be one of %s, got %q", strings.Join(ttsFormats, ", "), c.TTS.Format)
+	check(c.TTS.Speed >= 0.25 && c.TTS.Speed <= 4, "tts.speed", "must be between 0.25 and 4, got %g", c.TTS.Speed)
+
+	check(c.LLM.Model != "", "llm.model", "is required")
+	check(c.LLM.Temperature >= 0 && c.LLM.Temperature <= 2, "llm.temperature", "must be between 0 and 2, got %g", c.LLM.Temperature)
+	check(c.LLM.MaxTokens > 0, "llm.max_tokens", "must be positive")
+	check(c.LLM.Timeout > 0, "llm.timeout", "must be positive")
+
+	if c.Storage.RedisURL != "" {
+		u, err := url.Parse(c.Storage.RedisURL)
+		check(err == nil && (u.Scheme == "redis" || u.Scheme == "rediss"), "storage.redis_url", "must be a redis:// or rediss:// URL")
+	}
+	check(c.Storage.SessionTTL > 0, "storage.session_ttl", "must be positive")
+	check(c.Storage.Analytics.Driver == "sqlite" || c.Storage.Analytics.Driver == "postgres",
+		"storage.analytics.driver", "must be sqlite or postgres, got %q", c.Storage.Analytics.Driver)
+
+	check(slices.Contains(logLevels, c.Logging.Level), "logging.level", "must be one of %s, got %q", strings.Join(logLevels, ", "), c.Logging.Level)
+	check(c.Logging.Format == "text" || c.Logging.Format == "json", "logging.format", "must be text or json, got %q", c.Logging.Format)
+	check(slices.Contains(logTranscripts, c.Logging.Transcripts), "logging.transcripts", "must be one of %s, got %q", strings.Join(logTranscripts, ", "), c.Logging.Transcripts)
+
+	if c.Tracing.Endpoint != "" {
+		u, err := url.Parse(c.Tracing.Endpoint)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "tracing.endpoint", "must be an http:// or https:// URL")
+	}
+	check(c.Tracing.ServiceName != "", "tracing.service_name", "is required")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+
+	check(c.Health.Timeout > 0, "health.timeout", "must be positive")
+	check(c.Health.ProbeInterval >= 0, "health.probe_interval", "must not be negative")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Serviceable checks that the configuration lets the service hold
+// interviews. Beyond Validate, it requires the settings a session needs that
+// the service starts without, such as the OpenAI API key, so they fail
+// readiness rather than the first session
+func (c *Config) Serviceable() error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	var errs []error
+	if c.TTS.APIKey == "" {
+		errs = append(errs, errors.New("tts.api_key: is required, set OPENAI_API_KEY"))
+	}
+	if c.LLM.APIKey == "" {
+		errs = append(errs, errors.New("llm.api_key: is required, set OPENAI_API_KEY"))
+	}
+	return errors.Join(errs...)
+}
+
+// Accepted values of enumerated settings
+var (
+	ttsVoices = []string{string(tts.VoiceAlloy), string(tts.VoiceEcho), string(tts.VoiceFable),
+		string(tts.VoiceOnyx), string(tts.VoiceNova), string(tts.VoiceShimmer)}
+	ttsFormats     = []string{"mp3", "opus", "aac", "flac", "wav", "pcm"}
+	logLevels      = []string{"debug", "info", "warn", "error"}
+	logTranscripts = []string{logging.TranscriptsHash, logging.TranscriptsRedact, logging.TranscriptsPlain}
+)
+
+// Effective returns the configuration keyed by YAML path sections, with
+// secrets redacted, for the config dump
+func (c *Config) Effective() map[string]interface{} {
+	effective := make(map[string]interface{})
+	walkConfig(c, func(path string, field reflect.StructField, value reflect.Value) {
+		section := effective
+		keys := strings.Split(path, ".")
+		for _, key := range keys[:len(keys)-1] {
+			next, ok := section[key].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				section[key] = next
+			}
+			section = next
+		}
+
+		var v interface{} = value.Interface()
+		switch {
+		case field.Tag.Get("secret") == "true":
+			if !value.IsZero() {
+				v = redactedValue
+			}
+		case value.Type() == durationType:
+			v = value.Interface().(time.Duration).String()
+		}
+		section[keys[len(keys)-1]] = v
+	})
+	return effective
+}
+
+// configHandler serves the effective configuration with secrets redacted. With
+// mutual TLS, requireAdminClientCert has already verified the caller; otherwise
+// it requires the admin key as a bearer token, and is disabled without one
+func configHandler(config *Config, clientCerts bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if !clientCerts {
+			adminKey := config.Server.AdminAPIKey
+			if adminKey == "" {
+				http.Error(w, "Admin access is not configured", http.StatusForbidden)
+				return
+			}
+			key, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !found || subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) != 1 {
+				http.Error(w, "Invalid admin key", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(config.Effective())
+	}
 }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/torteous44/callservice/internal/audio/stt"
+	"github.com/torteous44/callservice/internal/audio/tts"
+	"github.com/torteous44/callservice/internal/contextbrain"
+	"github.com/torteous44/callservice/internal/health"
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/sessionstate"
+)
+
+// readinessChecks returns the dependencies an instance needs to take new
+// sessions: a serviceable configuration, the session backend and, when
+// enabled, the speech and language model providers. Provider checks call
+// AssemblyAI and OpenAI, so their results are reused for the probe interval
+func readinessChecks(config *Config, backend sessionstate.Backend) []health.Check {
+	configErr := config.Serviceable()
+	if configErr != nil {
+		slog.Warn("Configuration is incomplete, the instance will not be ready", logging.Err(configErr))
+	}
+	checks := []health.Check{
+		{Name: "config", Probe: func(context.Context) error { return configErr }},
+		{Name: "session_backend", Probe: backend.Ping},
+	}
+	if !config.Health.ProbeProviders {
+		return checks
+	}
+
+	interval := config.Health.ProbeInterval
+	return append(checks,
+		health.Check{Name: "stt", Interval: interval, Probe: func(ctx context.Context) error {
+			return stt.Ping(ctx, config.STT.APIKey)
+		}},
+		health.Check{Name: "tts", Interval: interval, Probe: func(ctx context.Context) error {
+			return tts.Ping(ctx, config.TTS.APIKey, config.TTS.Model)
+		}},
+		health.Check{Name: "llm", Interval: interval, Probe: func(ctx context.Context) error {
+			return contextbrain.Ping(ctx, config.LLM.APIKey, config.LLM.Model)
+		}},
+	)
+}
@@ -1,44 +1,297 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"flag"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/sashabaranov/go-openai"
+	"github.com/torteous44/callservice/internal/analytics"
+	"github.com/torteous44/callservice/internal/audio/stt"
+	"github.com/torteous44/callservice/internal/audio/tts"
+	"github.com/torteous44/callservice/internal/audio/vad"
+	"github.com/torteous44/callservice/internal/auth"
+	"github.com/torteous44/callservice/internal/contextbrain"
+	"github.com/torteous44/callservice/internal/health"
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/metrics"
 	"github.com/torteous44/callservice/internal/orchestrator"
+	"github.com/torteous44/callservice/internal/sessionstate"
+	"github.com/torteous44/callservice/internal/tlsconfig"
+	"github.com/torteous44/callservice/internal/tracing"
+	"github.com/torteous44/callservice/pkg/interviewpb"
+	"github.com/torteous44/callservice/pkg/transport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Shutdown
+const (
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 10 * time.Second // For HTTP and gRPC calls once interviews are drained
 )
 
 func main() {
-	// Load environment variables from .env file
-	err := godotenv.Load()
+	// Layer configs/default.yaml, .env, the environment and flags
+	config, err := LoadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+
+	// Every component logs through the configured logger, the standard log
+	// package included
+	logger, err := logging.New(os.Stderr, config.Logging.Options())
 	if err != nil {
-		log.Printf("Warning: Error loading .env file: %v", err)
+		log.Fatal("❌ ", err)
 	}
+	slog.SetDefault(logger)
+	slog.Info("Call Service initialized")
 
-	fmt.Println("🚀 Call Service starting...")
-	log.Println("Call Service initialized")
+	// Export spans of each session and candidate turn when a collector is set
+	shutdownTracing := func(context.Context) error { return nil }
+	if tracingOptions := config.Tracing.Options(); tracingOptions.Enabled() {
+		shutdownTracing, err = tracing.Setup(context.Background(), tracingOptions)
+		if err != nil {
+			fatal("Invalid tracing configuration", err)
+		}
+		slog.Info("Tracing enabled", "endpoint", tracingOptions.Endpoint, "sample_ratio", tracingOptions.SampleRatio)
+	}
+
+	// Use Redis for session state when configured so sessions survive restarts
+	// and can be resumed on any instance
+	var backend sessionstate.Backend = sessionstate.NewMemoryBackend()
+	if config.Storage.RedisURL != "" {
+		redisBackend, err := sessionstate.NewRedisBackend(config.Storage.RedisURL)
+		if err != nil {
+			fatal("Invalid REDIS_URL", err)
+		}
+		backend = redisBackend
+		slog.Info("Session state backend", "backend", "redis")
+	} else {
+		slog.Info("Session state backend", "backend", "memory")
+	}
+	defer backend.Close()
+
+	// Flush completed sessions to the analytics database when configured
+	var analyticsSink analytics.Sink
+	if dsn := config.Storage.Analytics.DSN; dsn != "" {
+		driver := config.Storage.Analytics.Driver
+		sqlSink, err := analytics.OpenSQLSink(context.Background(), driver, dsn)
+		if err != nil {
+			fatal("Failed to open analytics database", err)
+		}
+		defer sqlSink.Close()
+		analyticsSink = sqlSink
+		slog.Info("Analytics sink", "driver", driver)
+	}
+
+	// Detect dead client connections
+	wsOptions := transport.WSOptions{
+		PingInterval:     config.Server.WebSocket.PingInterval,
+		ReadTimeout:      config.Server.WebSocket.ReadTimeout,
+		WriteTimeout:     config.Server.WebSocket.WriteTimeout,
+		HeartbeatTimeout: config.Server.WebSocket.HeartbeatTimeout,
+	}
+
+	// Browsers may only call the API and open WebSockets from allowed origins
+	origins, _ := auth.NewOriginPolicy(config.Server.CORS.Options())
+	wsOptions.CheckOrigin = origins.CheckOrigin
+
+	// Authenticate API callers with the identity provider's bearer tokens
+	var verifier *auth.Verifier
+	if authOptions := config.Auth.Options(); authOptions.Enabled() {
+		verifier, err = auth.NewVerifier(authOptions)
+		if err != nil {
+			fatal("Invalid authentication configuration", err)
+		}
+		slog.Info("Authentication: JWT bearer tokens")
+	} else {
+		slog.Warn("Authentication is disabled, anyone who knows a session ID can access it")
+	}
+
+	// Resume tokens must verify on every instance
+	if config.Server.ResumeTokenSecret == "" {
+		slog.Warn("RESUME_TOKEN_SECRET is not set, resume tokens only work on this instance until restart")
+	}
+
+	// WebRTC clients need STUN, or TURN behind restrictive NATs, to reach the server
+	rtcOptions := transport.DefaultRTCOptions()
+	rtcOptions.ICEServers = config.Server.WebRTC.ICEServers
+
+	// URLs handed to clients follow the public URL, or the host and scheme
+	// the client used as forwarded by a trusted proxy
+	urlOptions := orchestrator.URLOptions{AdvertiseTransports: config.Server.AdvertiseTransports}
+	if config.Server.PublicURL != "" {
+		urlOptions.PublicURL, _ = orchestrator.ParsePublicURL(config.Server.PublicURL)
+	}
+	urlOptions.TrustedProxies, _ = orchestrator.ParseTrustedProxies(config.Server.TrustedProxies)
+
+	// The interviewer grades answers and speaks its follow-ups through OpenAI.
+	// Every answer is paid for, so it only answers when enabled
+	var brain *contextbrain.Client
+	var speech *tts.TTS
+	if config.LLM.Interviewer {
+		brain = contextbrain.NewClient(config.LLM.Options())
+		speech = tts.NewTTSWithConfig(openai.DefaultConfig(config.TTS.APIKey))
+		speech.SetLogger(logger)
+	}
 
 	// Create interview manager
-	interviewManager := orchestrator.NewInterviewManager()
+	interviewManager := orchestrator.NewInterviewManager(orchestrator.ManagerOptions{
+		Backend:      backend,
+		SessionTTL:   config.Storage.SessionTTL,
+		Analytics:    analyticsSink,
+		WebSocket:    wsOptions,
+		ResumeSecret: []byte(config.Server.ResumeTokenSecret),
+		ObserverKey:  config.Server.ObserverAPIKey,
+		WebRTC:       rtcOptions,
+		STT: stt.StreamingConfig{
+			SampleRate:                       config.Audio.SampleRate,
+			Encoding:                         config.Audio.Encoding,
+			FormatTurns:                      config.STT.FormatTurns,
+			EndOfTurnConfidenceThreshold:     config.STT.EndOfTurnConfidenceThreshold,
+			MinEndOfTurnSilenceWhenConfident: config.STT.MinEndOfTurnSilenceWhenConfident,
+			MaxTurnSilence:                   config.STT.MaxTurnSilence,
+			APIKey:                           config.STT.APIKey,
+		},
+		VAD: vad.Config{
+			EnergyThreshold:  config.VAD.EnergyThreshold,
+			MinVoiceFrames:   config.VAD.MinVoiceFrames,
+			MinSilenceFrames: config.VAD.MinSilenceFrames,
+			SmoothingWindow:  config.VAD.SmoothingWindow,
+		},
+		URLs:      urlOptions,
+		Auth:      verifier,
+		TicketTTL: config.Auth.TicketTTL,
+		Limits:    config.Limits.Options(),
+
+		Brain:         brain,
+		Speech:        speech,
+		SpeechOptions: config.TTS.Options(),
+
+		Logger: logger,
+	})
+
+	// Background tasks run until shutdown
+	background, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// Garbage collect abandoned sessions
+	go interviewManager.RunReaper(background, orchestrator.DefaultReaperConfig())
+
+	// Pick up rotated identity provider keys
+	if verifier != nil {
+		go verifier.Run(background)
+	}
+
+	// Serve TLS when a certificate is configured, picking up rotated
+	// certificates without dropping live connections
+	var certs *tlsconfig.Reloader
+	if tlsOptions := config.Server.TLS.Options(); tlsOptions.Enabled() {
+		certs, err = tlsconfig.NewReloader(tlsOptions)
+		if err != nil {
+			fatal("Invalid TLS configuration", err)
+		}
+		go certs.Run(background)
+		slog.Info("TLS enabled", "min_version", config.Server.TLS.MinVersion, "mutual_tls", certs.MutualTLS())
+	}
+
+	// gRPC API for backend services, sharing sessions with the HTTP API
+	var grpcServer *grpc.Server
+	if config.Server.GRPCPort != 0 {
+		grpcAddr := net.JoinHostPort(config.Server.Host, strconv.Itoa(config.Server.GRPCPort))
+		listener, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			fatal("gRPC server failed to listen", err)
+		}
+		// Streams are kept alive like WebSocket clients, so a peer that went
+		// away is dropped instead of leaving a Converse send blocked
+		grpcOptions := []grpc.ServerOption{grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    config.Server.WebSocket.PingInterval,
+			Timeout: config.Server.WebSocket.WriteTimeout,
+		})}
+		// The gRPC API is internal: with mutual TLS every call needs a client certificate
+		if certs != nil {
+			grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(certs.ServerConfig(certs.MutualTLS()))))
+		}
+		grpcServer = grpc.NewServer(grpcOptions...)
+		interviewpb.RegisterInterviewServiceServer(grpcServer, orchestrator.NewGRPCServer(interviewManager))
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				fatal("gRPC server failed", err)
+			}
+		}()
+		slog.Info("gRPC InterviewService listening", "addr", grpcAddr)
+	}
 
 	// Set up HTTP routes
 	http.HandleFunc("/api/interview/init", interviewManager.InitializeSession)
 	http.HandleFunc("/api/interview/init-with-lesson", interviewManager.InitializeSessionWithLesson)
 	http.HandleFunc("/api/interview/status", interviewManager.GetSessionStatus)
 	http.HandleFunc("/api/interview/close", interviewManager.CloseSession)
+	http.HandleFunc("/api/interview/state", interviewManager.GetSessionState)
+	http.HandleFunc("/api/interview/state/schema", interviewManager.GetSessionStateSchema)
+	http.HandleFunc("/api/interview/timeline", interviewManager.GetTimeline)
+	http.HandleFunc("/api/interview/timeline/state", interviewManager.GetTimelineState)
+	http.HandleFunc("/api/interview/observer-token", interviewManager.IssueObserverToken)
+
+	// Short-lived tickets authorizing the owner's WebSocket upgrade
+	http.HandleFunc("/api/interview/{id}/ticket", interviewManager.IssueTicket)
+
+	// HTTP fallback for clients that cannot open a WebSocket
+	http.HandleFunc("/api/interview/{id}/events", interviewManager.StreamEvents)
+	http.HandleFunc("/api/interview/{id}/audio", interviewManager.UploadAudio)
+
+	// WebRTC signaling: the client posts an SDP offer and gets the answer back
+	http.HandleFunc("/api/interview/{id}/rtc", interviewManager.HandleRTCOffer)
 
 	// WebSocket endpoint for audio streaming
 	http.HandleFunc("/ws/interview/", interviewManager.HandleWebSocket)
 
-	// Health check endpoint
+	// Phone calls bridged by a telephony provider's media stream
+	http.HandleFunc("/ws/telephony", interviewManager.HandleTelephony)
+
+	// Read-only view of a live session for observers and coaches
+	http.HandleFunc("/ws/interview/{id}/observe", interviewManager.HandleObserve)
+
+	// Liveness and readiness probes. Load balancers route new interviews to
+	// ready instances: dependencies usable, not draining and below capacity
+	checker := health.NewChecker(health.Options{
+		Checks:   readinessChecks(config, backend),
+		Timeout:  config.Health.Timeout,
+		Load:     interviewManager.Load,
+		Draining: interviewManager.Draining,
+	})
+	http.HandleFunc("/livez", checker.ServeLive)
+	http.HandleFunc("/readyz", checker.ServeReady)
+	// Kept with its original body for existing monitors
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(`{"status": "healthy", "service": "call-service"}`))
 	})
 
+	// Effective configuration, secrets redacted, for admin clients
+	http.HandleFunc("/debug/config", configHandler(config, certs != nil && certs.MutualTLS()))
+
+	// Prometheus metrics
+	metrics.MustRegister(interviewManager.MetricsCollector())
+	http.Handle("/metrics", metrics.Handler())
+
 	// Serve static files (optional, for serving a simple test page)
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/" {
@@ -56,18 +309,32 @@ func main() {
         <li><strong>POST /api/interview/init</strong> - Initialize a new interview session</li>
         <li><strong>GET /api/interview/status?session_id=xxx</strong> - Get session status</li>
         <li><strong>DELETE /api/interview/close?session_id=xxx</strong> - Close session</li>
-        <li><strong>WebSocket /ws/interview/{session_id}</strong> - Audio streaming</li>
-        <li><strong>GET /health</strong> - Health check</li>
+        <li><strong>GET /api/interview/state?session_id=xxx</strong> - Get typed interview state</li>
+        <li><strong>GET /api/interview/state/schema</strong> - JSON schema of the interview state</li>
+        <li><strong>GET /api/interview/timeline?session_id=xxx&amp;after=seq</strong> - Session event timeline</li>
+        <li><strong>GET /api/interview/timeline/state?session_id=xxx&amp;at=RFC3339</strong> - Interview state replayed at a point in time</li>
+        <li><strong>POST /api/interview/observer-token</strong> - Issue an observer or coach token (requires the observer key)</li>
+        <li><strong>POST /api/interview/{session_id}/ticket</strong> - Issue a short-lived WebSocket ticket to the session owner</li>
+        <li><strong>GET /api/interview/{session_id}/events?resume_token=xxx</strong> - Transcript and status stream (Server-Sent Events)</li>
+        <li><strong>POST /api/interview/{session_id}/audio</strong> - Audio upload as a chunked request body</li>
+        <li><strong>POST /api/interview/{session_id}/rtc</strong> - WebRTC offer, answered with the server's SDP</li>
+        <li><strong>WebSocket /ws/interview/{session_id}?ticket=xxx</strong> - Audio streaming</li>
+        <li><strong>WebSocket /ws/interview/{session_id}/observe?token=xxx</strong> - Observe or coach a live session</li>
+        <li><strong>WebSocket /ws/telephony</strong> - Telephony media stream (8kHz μ-law phone calls)</li>
+        <li><strong>GET /livez</strong> - Liveness probe</li>
+        <li><strong>GET /readyz</strong> - Readiness probe with dependency checks and session load</li>
+        <li><strong>GET /debug/config</strong> - Effective configuration (secrets redacted, admin key or client certificate required)</li>
+        <li><strong>GET /metrics</strong> - Prometheus metrics</li>
     </ul>
     
     <h2>Example Usage:</h2>
     <pre>
-// Initialize session
-fetch('/api/interview/init', { method: 'POST' })
+// Initialize session (the bearer token is only needed when authentication is enabled)
+fetch('/api/interview/init', { method: 'POST', headers: { Authorization: 'Bearer ' + jwt } })
   .then(r => r.json())
   .then(data => {
     console.log('Session ID:', data.session_id);
-    console.log('WebSocket URL:', data.websocket_url);
+    console.log('WebSocket URL:', data.websocket_url + '?ticket=' + data.ticket);
   });
     </pre>
 </body>
@@ -78,20 +345,104 @@ fetch('/api/interview/init', { method: 'POST' })
 		}
 	})
 
-	port := "8080"
-	log.Printf("🌐 Server starting on http://localhost:%s", port)
-	log.Printf("WebSocket endpoint: ws://localhost:%s/ws/interview/{session_id}", port)
-	log.Printf("Interview API: http://localhost:%s/api/interview/", port)
-
-	fmt.Println("\nLegend:")
-	fmt.Println("   Voice detected - when VAD detects speech")
-	fmt.Println("   Silence detected - when VAD detects silence")
-	fmt.Println("   🔄 Partial transcripts - real-time speech recognition")
-	fmt.Println("   ✅ Final transcripts - completed phrases")
-	fmt.Println("   🗣️  End of utterance - when speaker stops talking")
-	fmt.Println()
-
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
-		log.Fatal("❌ Server failed to start:", err)
+	addr := net.JoinHostPort(config.Server.Host, strconv.Itoa(config.Server.Port))
+	host := config.Server.Host
+	if host == "" {
+		host = "localhost"
+	}
+	base := net.JoinHostPort(host, strconv.Itoa(config.Server.Port))
+	scheme := "http"
+	if certs != nil {
+		scheme = "https"
+	}
+	slog.Info("Server starting", "url", scheme+"://"+base,
+		"websocket", strings.Replace(scheme, "http", "ws", 1)+"://"+base+"/ws/interview/{session_id}",
+		"api", scheme+"://"+base+"/api/interview/")
+	if urlOptions.PublicURL != nil {
+		slog.Info("Public URL", "url", urlOptions.PublicURL.String())
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           origins.Handler(http.DefaultServeMux),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	if certs != nil {
+		server.TLSConfig = certs.ServerConfig(false)
+		if certs.MutualTLS() {
+			server.Handler = requireAdminClientCert(server.Handler)
+		}
+	}
+	go func() {
+		var err error
+		if server.TLSConfig != nil {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			fatal("Server failed to start", err)
+		}
+	}()
+
+	// Drain live interviews on SIGTERM or Ctrl-C; a second signal kills the process
+	signals, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	<-signals.Done()
+	stopSignals()
+	slog.Info("Shutting down, draining interviews", "timeout", config.Server.DrainTimeout.String())
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), config.Server.DrainTimeout)
+	if err := interviewManager.Drain(drainCtx); err != nil {
+		slog.Warn("Drain incomplete", logging.Err(err))
+	}
+	cancelDrain()
+	stopBackground()
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	if grpcServer != nil {
+		stopGRPC(shutdownCtx, grpcServer)
+	}
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("HTTP server did not shut down cleanly", logging.Err(err))
+	}
+	// Sessions handed off by the drain have ended their spans
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Warn("Failed to flush spans", logging.Err(err))
+	}
+	slog.Info("Call Service stopped")
+}
+
+// fatal logs an error the service cannot start or run with, and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, logging.Err(err))
+	os.Exit(1)
+}
+
+// requireAdminClientCert requires a verified client certificate for the admin
+// endpoints under /debug/. Other endpoints serve browsers, which have none
+func requireAdminClientCert(next http.Handler) http.Handler {
+	admin := tlsconfig.RequireClientCert(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/debug/") {
+			admin.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// stopGRPC stops the gRPC server, letting in-flight calls finish until ctx is
+// done
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
 	}
 }
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/torteous44/callservice/internal/analytics"
+)
+
+// migrate applies the analytics schema migrations without starting the service
+func main() {
+	godotenv.Load()
+
+	driver := flag.String("driver", envOrDefault("ANALYTICS_DRIVER", analytics.DriverSQLite), "sqlite or postgres")
+	dsn := flag.String("dsn", os.Getenv("ANALYTICS_DSN"), "analytics database DSN")
+	list := flag.Bool("list", false, "list embedded migrations and exit")
+	flag.Parse()
+
+	if *list {
+		migrations, err := analytics.Migrations()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, migration := range migrations {
+			fmt.Println(migration.Name)
+		}
+		return
+	}
+
+	if *dsn == "" {
+		log.Fatal("❌ -dsn or ANALYTICS_DSN is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	db, err := analytics.Open(*driver, *dsn)
+	if err != nil {
+		log.Fatal("❌ Failed to open database:", err)
+	}
+	defer db.Close()
+
+	if err := analytics.Migrate(ctx, db, *driver); err != nil {
+		log.Fatal("❌ Migration failed:", err)
+	}
+	log.Println("✅ Analytics schema is up to date")
+}
+
+// envOrDefault returns the environment variable or a fallback
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,146 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Migrations returns the embedded migrations ordered by version
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+
+		// Files are named NNNN_description.sql
+		prefix, _, found := strings.Cut(name, "_")
+		if !found {
+			return nil, fmt.Errorf("migration %s is missing a version prefix", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has an invalid version: %w", name, err)
+		}
+
+		content, err := migrationFiles.ReadFile("migrations/" + name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    strings.TrimSuffix(name, ".sql"),
+			SQL:     string(content),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// migrationLock is the Postgres advisory lock held while migrating
+const migrationLock = 0x616e616c79746963 // "analytic"
+
+// Migrate applies every embedded migration that has not been applied yet. Each
+// migration runs in its own transaction together with its bookkeeping row.
+// Instances starting together may migrate at once: on Postgres they take turns
+// on an advisory lock, and every driver checks again inside the transaction
+// that the migration is still pending
+func Migrate(ctx context.Context, db *sql.DB, driver string) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to analytics database: %w", err)
+	}
+	defer conn.Close()
+
+	if driver == DriverPostgres {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLock); err != nil {
+			return fmt.Errorf("failed to lock migrations: %w", err)
+		}
+		defer conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLock)
+	}
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER PRIMARY KEY,
+    name       TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		applied, err := applyMigration(ctx, conn, migration)
+		if err != nil {
+			return err
+		}
+		if applied {
+			slog.Info("Applied analytics migration", "migration", migration.Name)
+		}
+	}
+	return nil
+}
+
+// applyMigration runs a single migration and records it, unless it was
+// already applied. It reports whether it ran the migration
+func applyMigration(ctx context.Context, conn *sql.Conn, migration Migration) (bool, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin migration %s: %w", migration.Name, err)
+	}
+	defer tx.Rollback()
+
+	var applied int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = $1`,
+		migration.Version).Scan(&applied); err != nil {
+		return false, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	if applied > 0 {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, migration.SQL); err != nil {
+		return false, fmt.Errorf("failed to apply migration %s: %w", migration.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`,
+		migration.Version, migration.Name, time.Now().UTC()); err != nil {
+		return false, fmt.Errorf("failed to record migration %s: %w", migration.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit migration %s: %w", migration.Name, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,60 @@
+-- Completed interview sessions. The schema sticks to types and syntax shared by
+-- SQLite and Postgres so the same migrations run against both.
+
+CREATE TABLE IF NOT EXISTS sessions (
+    session_id       TEXT PRIMARY KEY,
+    lesson_id        TEXT NOT NULL DEFAULT '',
+    case_id          TEXT NOT NULL DEFAULT '',
+    status           TEXT NOT NULL,
+    started_at       TIMESTAMP NOT NULL,
+    ended_at         TIMESTAMP NOT NULL,
+    duration_ms      BIGINT NOT NULL,
+    transcript_count INTEGER NOT NULL,
+    utterance_count  INTEGER NOT NULL,
+    hints_used       INTEGER NOT NULL,
+    final_state      TEXT NOT NULL,
+    flushed_at       TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS questions (
+    session_id     TEXT NOT NULL REFERENCES sessions (session_id) ON DELETE CASCADE,
+    question_index INTEGER NOT NULL,
+    question_id    TEXT NOT NULL DEFAULT '',
+    prompt         TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (session_id, question_index)
+);
+
+CREATE TABLE IF NOT EXISTS turns (
+    session_id     TEXT NOT NULL REFERENCES sessions (session_id) ON DELETE CASCADE,
+    seq            BIGINT NOT NULL,
+    question_index INTEGER NOT NULL,
+    speaker        TEXT NOT NULL,
+    text           TEXT NOT NULL,
+    confidence     DOUBLE PRECISION NOT NULL,
+    occurred_at    TIMESTAMP NOT NULL,
+    PRIMARY KEY (session_id, seq)
+);
+
+CREATE TABLE IF NOT EXISTS grades (
+    session_id     TEXT NOT NULL REFERENCES sessions (session_id) ON DELETE CASCADE,
+    seq            BIGINT NOT NULL,
+    question_index INTEGER NOT NULL,
+    overall_score  DOUBLE PRECISION NOT NULL,
+    decision       TEXT NOT NULL,
+    scores         TEXT NOT NULL,
+    feedback       TEXT NOT NULL,
+    occurred_at    TIMESTAMP NOT NULL,
+    PRIMARY KEY (session_id, seq)
+);
+
+CREATE TABLE IF NOT EXISTS events (
+    session_id  TEXT NOT NULL REFERENCES sessions (session_id) ON DELETE CASCADE,
+    seq         BIGINT NOT NULL,
+    event_type  TEXT NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    data        TEXT NOT NULL,
+    PRIMARY KEY (session_id, seq)
+);
+
+CREATE INDEX IF NOT EXISTS sessions_lesson_idx ON sessions (lesson_id);
+CREATE INDEX IF NOT EXISTS events_type_idx ON events (event_type);
@@ -0,0 +1,141 @@
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/torteous44/callservice/internal/sessionstate"
+)
+
+// Speakers recorded on turns
+const (
+	SpeakerCandidate   = "candidate"
+	SpeakerInterviewer = "interviewer"
+)
+
+// SessionMeta describes a completed session before it is flattened
+type SessionMeta struct {
+	SessionID       string
+	LessonID        string
+	CaseID          string
+	Status          string
+	StartedAt       time.Time
+	EndedAt         time.Time
+	TranscriptCount int
+	UtteranceCount  int
+	Questions       []Question
+	FinalState      sessionstate.InterviewState
+}
+
+// Question is a lesson question asked during a session
+type Question struct {
+	Index  int
+	ID     string
+	Prompt string
+}
+
+// Turn is a single candidate or interviewer utterance
+type Turn struct {
+	Seq           int64
+	QuestionIndex int
+	Speaker       string
+	Text          string
+	Confidence    float64
+	OccurredAt    time.Time
+}
+
+// Grade is a grading decision produced for a candidate answer
+type Grade struct {
+	Seq           int64
+	QuestionIndex int
+	OverallScore  float64
+	Decision      string
+	Scores        map[string]float64
+	Feedback      []string
+	OccurredAt    time.Time
+}
+
+// Report is the flattened form of a session written to the analytics store
+type Report struct {
+	Meta   SessionMeta
+	Turns  []Turn
+	Grades []Grade
+	Events []sessionstate.Event
+}
+
+// Flatten builds a report from a session's metadata and its event timeline
+func Flatten(meta SessionMeta, events []sessionstate.Event) (*Report, error) {
+	report := &Report{
+		Meta:   meta,
+		Events: events,
+	}
+
+	currentQuestion := 0
+	for _, event := range events {
+		switch event.Type {
+		case sessionstate.EventSessionCreated:
+			var data sessionstate.SessionCreatedData
+			if err := json.Unmarshal(event.Data, &data); err != nil {
+				return nil, fmt.Errorf("failed to flatten event %d: %w", event.Seq, err)
+			}
+			currentQuestion = data.State.CurrentQuestion
+
+		case sessionstate.EventStateTransition:
+			var data sessionstate.StateTransitionData
+			if err := json.Unmarshal(event.Data, &data); err != nil {
+				return nil, fmt.Errorf("failed to flatten event %d: %w", event.Seq, err)
+			}
+			if raw, changed := data.Changes["current_question"]; changed {
+				if err := json.Unmarshal(raw, &currentQuestion); err != nil {
+					return nil, fmt.Errorf("failed to flatten event %d: %w", event.Seq, err)
+				}
+			}
+
+		case sessionstate.EventSTTTurn:
+			var data sessionstate.TranscriptData
+			if err := json.Unmarshal(event.Data, &data); err != nil {
+				return nil, fmt.Errorf("failed to flatten event %d: %w", event.Seq, err)
+			}
+			report.Turns = append(report.Turns, Turn{
+				Seq:           event.Seq,
+				QuestionIndex: currentQuestion,
+				Speaker:       SpeakerCandidate,
+				Text:          data.Text,
+				Confidence:    data.Confidence,
+				OccurredAt:    event.Timestamp,
+			})
+
+		case sessionstate.EventTTSStarted:
+			var data sessionstate.UtteranceData
+			if err := json.Unmarshal(event.Data, &data); err != nil {
+				return nil, fmt.Errorf("failed to flatten event %d: %w", event.Seq, err)
+			}
+			report.Turns = append(report.Turns, Turn{
+				Seq:           event.Seq,
+				QuestionIndex: currentQuestion,
+				Speaker:       SpeakerInterviewer,
+				Text:          data.Text,
+				Confidence:    1,
+				OccurredAt:    event.Timestamp,
+			})
+
+		case sessionstate.EventGradeProduced:
+			var data sessionstate.GradeData
+			if err := json.Unmarshal(event.Data, &data); err != nil {
+				return nil, fmt.Errorf("failed to flatten event %d: %w", event.Seq, err)
+			}
+			report.Grades = append(report.Grades, Grade{
+				Seq:           event.Seq,
+				QuestionIndex: data.QuestionIndex,
+				OverallScore:  data.OverallScore,
+				Decision:      data.Decision,
+				Scores:        data.Scores,
+				Feedback:      data.Feedback,
+				OccurredAt:    event.Timestamp,
+			})
+		}
+	}
+
+	return report, nil
+}
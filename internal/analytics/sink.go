@@ -0,0 +1,59 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/torteous44/callservice/internal/logging"
+)
+
+// Retry settings for flushing a session
+const (
+	DefaultFlushAttempts = 5
+	initialRetryDelay    = 500 * time.Millisecond
+	maxRetryDelay        = 10 * time.Second
+)
+
+// Sink receives completed sessions. Writes must be idempotent so a flush can be
+// retried after an ambiguous failure without duplicating rows
+type Sink interface {
+	// WriteSession stores a flattened session in a single transaction
+	WriteSession(ctx context.Context, report *Report) error
+	// Close releases sink resources
+	Close() error
+}
+
+// Flush writes a report to the sink, retrying with exponential backoff
+func Flush(ctx context.Context, sink Sink, report *Report, attempts int) error {
+	if attempts <= 0 {
+		attempts = DefaultFlushAttempts
+	}
+
+	delay := initialRetryDelay
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = sink.WriteSession(ctx, report)
+		if err == nil {
+			return nil
+		}
+
+		if attempt == attempts {
+			break
+		}
+		slog.Warn("Analytics flush attempt failed", "attempt", attempt, logging.KeySession, report.Meta.SessionID, logging.Err(err))
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("analytics flush cancelled: %w", ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+
+	return fmt.Errorf("analytics flush failed after %d attempts: %w", attempts, err)
+}
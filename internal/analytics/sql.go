@@ -0,0 +1,196 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	// Postgres driver, registered as "pgx"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	// Pure-Go SQLite driver, registered as "sqlite"
+	_ "modernc.org/sqlite"
+)
+
+// Databases the analytics store runs on
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// Open opens an analytics database. driver is DriverSQLite or DriverPostgres;
+// any other name is passed to database/sql as is, and must be registered
+func Open(driver, dsn string) (*sql.DB, error) {
+	name := driver
+	switch driver {
+	case DriverPostgres:
+		name = "pgx"
+	case DriverSQLite:
+		// Settings in the DSN apply to every pooled connection. Every
+		// transaction writes, so it takes the write lock up front and waits
+		// for writers in other processes, such as a migration
+		dsn = sqliteParam(dsn, "_pragma=foreign_keys", "(1)")
+		dsn = sqliteParam(dsn, "_pragma=busy_timeout", "(5000)")
+		dsn = sqliteParam(dsn, "_txlock", "=immediate")
+	}
+
+	db, err := sql.Open(name, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open analytics database: %w", err)
+	}
+	// SQLite only supports a single writer
+	if driver == DriverSQLite {
+		db.SetMaxOpenConns(1)
+	}
+	return db, nil
+}
+
+// sqliteParam adds a query parameter to a SQLite DSN, unless it already sets
+// it: key is the parameter up to its value, which follows as value
+func sqliteParam(dsn, key, value string) string {
+	if strings.Contains(dsn, key) {
+		return dsn
+	}
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + key + value
+}
+
+// SQLSink writes completed sessions to a SQL database. Statements use $N
+// placeholders and ON CONFLICT clauses understood by both SQLite and Postgres
+type SQLSink struct {
+	db *sql.DB
+}
+
+// NewSQLSink creates a sink on an open database. Call Migrate before the first write
+func NewSQLSink(db *sql.DB) *SQLSink {
+	return &SQLSink{db: db}
+}
+
+// OpenSQLSink opens a database with Open, applies migrations and returns a sink
+func OpenSQLSink(ctx context.Context, driver, dsn string) (*SQLSink, error) {
+	db, err := Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to analytics database: %w", err)
+	}
+
+	if err := Migrate(ctx, db, driver); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return NewSQLSink(db), nil
+}
+
+// WriteSession stores the session, questions, turns, grades and events in one
+// transaction. Rows are keyed by session and sequence number, so a retried
+// write leaves the tables unchanged
+func (s *SQLSink) WriteSession(ctx context.Context, report *Report) error {
+	meta := report.Meta
+
+	finalState, err := json.Marshal(meta.FinalState)
+	if err != nil {
+		return fmt.Errorf("failed to marshal final state: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin analytics transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO sessions (
+    session_id, lesson_id, case_id, status, started_at, ended_at, duration_ms,
+    transcript_count, utterance_count, hints_used, final_state, flushed_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT (session_id) DO UPDATE SET
+    status = excluded.status,
+    ended_at = excluded.ended_at,
+    duration_ms = excluded.duration_ms,
+    transcript_count = excluded.transcript_count,
+    utterance_count = excluded.utterance_count,
+    hints_used = excluded.hints_used,
+    final_state = excluded.final_state,
+    flushed_at = excluded.flushed_at`,
+		meta.SessionID, meta.LessonID, meta.CaseID, meta.Status,
+		meta.StartedAt.UTC(), meta.EndedAt.UTC(), meta.EndedAt.Sub(meta.StartedAt).Milliseconds(),
+		meta.TranscriptCount, meta.UtteranceCount, meta.FinalState.HintsUsed,
+		string(finalState), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to write session %s: %w", meta.SessionID, err)
+	}
+
+	for _, question := range meta.Questions {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO questions (session_id, question_index, question_id, prompt)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (session_id, question_index) DO NOTHING`,
+			meta.SessionID, question.Index, question.ID, question.Prompt); err != nil {
+			return fmt.Errorf("failed to write question %d: %w", question.Index, err)
+		}
+	}
+
+	for _, turn := range report.Turns {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO turns (session_id, seq, question_index, speaker, text, confidence, occurred_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (session_id, seq) DO NOTHING`,
+			meta.SessionID, turn.Seq, turn.QuestionIndex, turn.Speaker, turn.Text,
+			turn.Confidence, turn.OccurredAt.UTC()); err != nil {
+			return fmt.Errorf("failed to write turn %d: %w", turn.Seq, err)
+		}
+	}
+
+	for _, grade := range report.Grades {
+		scores, err := json.Marshal(grade.Scores)
+		if err != nil {
+			return fmt.Errorf("failed to marshal grade scores: %w", err)
+		}
+		feedback, err := json.Marshal(grade.Feedback)
+		if err != nil {
+			return fmt.Errorf("failed to marshal grade feedback: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO grades (session_id, seq, question_index, overall_score, decision, scores, feedback, occurred_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (session_id, seq) DO NOTHING`,
+			meta.SessionID, grade.Seq, grade.QuestionIndex, grade.OverallScore, grade.Decision,
+			string(scores), string(feedback), grade.OccurredAt.UTC()); err != nil {
+			return fmt.Errorf("failed to write grade %d: %w", grade.Seq, err)
+		}
+	}
+
+	for _, event := range report.Events {
+		data := string(event.Data)
+		if data == "" {
+			data = "{}"
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO events (session_id, seq, event_type, occurred_at, data)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (session_id, seq) DO NOTHING`,
+			meta.SessionID, event.Seq, string(event.Type), event.Timestamp.UTC(), data); err != nil {
+			return fmt.Errorf("failed to write event %d: %w", event.Seq, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit analytics for session %s: %w", meta.SessionID, err)
+	}
+	return nil
+}
+
+// Ping checks that the database is reachable
+func (s *SQLSink) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close closes the database
+func (s *SQLSink) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,66 @@
+// Package mulaw converts between G.711 μ-law, the 8kHz telephony encoding,
+// and 16-bit little-endian PCM
+package mulaw
+
+import (
+	"encoding/binary"
+)
+
+// SampleRate is the sample rate of telephony audio
+const SampleRate = 8000
+
+// μ-law encoding constants (ITU-T G.711)
+const (
+	bias = 0x84
+	clip = 32635
+)
+
+// DecodeSample expands a μ-law byte to a 16-bit sample
+func DecodeSample(u byte) int16 {
+	u = ^u
+	magnitude := (int16(u&0x0f)<<3 + bias) << ((u & 0x70) >> 4)
+	if u&0x80 != 0 {
+		return bias - magnitude
+	}
+	return magnitude - bias
+}
+
+// EncodeSample compresses a 16-bit sample to a μ-law byte
+func EncodeSample(sample int16) byte {
+	s := int32(sample)
+	var sign byte
+	if s < 0 {
+		sign = 0x80
+		s = -s
+	}
+	if s > clip {
+		s = clip
+	}
+	s += bias
+
+	exponent := byte(7)
+	for mask := int32(0x4000); s&mask == 0 && exponent > 0; mask >>= 1 {
+		exponent--
+	}
+	mantissa := byte(s>>(exponent+3)) & 0x0f
+	return ^(sign | exponent<<4 | mantissa)
+}
+
+// Decode expands μ-law audio to 16-bit little-endian PCM
+func Decode(data []byte) []byte {
+	pcm := make([]byte, len(data)*2)
+	for i, u := range data {
+		binary.LittleEndian.PutUint16(pcm[i*2:], uint16(DecodeSample(u)))
+	}
+	return pcm
+}
+
+// Encode compresses 16-bit little-endian PCM to μ-law. A trailing odd byte is
+// ignored
+func Encode(pcm []byte) []byte {
+	data := make([]byte, len(pcm)/2)
+	for i := range data {
+		data[i] = EncodeSample(int16(binary.LittleEndian.Uint16(pcm[i*2:])))
+	}
+	return data
+}
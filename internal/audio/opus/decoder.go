@@ -0,0 +1,73 @@
+//go:build opus
+
+package opus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	libopus "gopkg.in/hraban/opus.v2"
+)
+
+// maxPacketDuration is the longest audio an Opus packet can carry
+const maxPacketDuration = 120 * time.Millisecond
+
+// Decoder decodes mono Opus packets to 16-bit little-endian PCM
+type Decoder struct {
+	dec        *libopus.Decoder
+	sampleRate int
+	pcm        []int16
+	lastFrame  int // Samples in the last decoded packet, the size of a concealed one
+}
+
+// Supported reports whether this build can decode Opus
+func Supported() bool {
+	return true
+}
+
+// NewDecoder creates a decoder producing PCM at sampleRate
+func NewDecoder(sampleRate int) (*Decoder, error) {
+	if err := CheckSampleRate(sampleRate); err != nil {
+		return nil, err
+	}
+	dec, err := libopus.NewDecoder(sampleRate, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create opus decoder: %w", err)
+	}
+
+	return &Decoder{
+		dec:        dec,
+		sampleRate: sampleRate,
+		pcm:        make([]int16, sampleRate*int(maxPacketDuration/time.Millisecond)/1000),
+		lastFrame:  sampleRate / 50, // 20ms until the first packet says otherwise
+	}, nil
+}
+
+// Decode decodes one packet
+func (d *Decoder) Decode(packet []byte) ([]byte, error) {
+	n, err := d.dec.Decode(packet, d.pcm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode opus packet: %w", err)
+	}
+	d.lastFrame = n
+	return pcmBytes(d.pcm[:n]), nil
+}
+
+// Conceal synthesizes audio for a lost packet, the length of the last one
+func (d *Decoder) Conceal() ([]byte, error) {
+	pcm := d.pcm[:d.lastFrame]
+	if err := d.dec.DecodePLC(pcm); err != nil {
+		return nil, fmt.Errorf("failed to conceal lost opus packet: %w", err)
+	}
+	return pcmBytes(pcm), nil
+}
+
+// pcmBytes encodes samples as 16-bit little-endian PCM
+func pcmBytes(samples []int16) []byte {
+	data := make([]byte, len(samples)*2)
+	for i, sample := range samples {
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(sample))
+	}
+	return data
+}
@@ -0,0 +1,27 @@
+//go:build !opus
+
+package opus
+
+// Decoder decodes mono Opus packets to 16-bit little-endian PCM. This build
+// has no libopus, so decoders cannot be created
+type Decoder struct{}
+
+// Supported reports whether this build can decode Opus
+func Supported() bool {
+	return false
+}
+
+// NewDecoder returns ErrUnsupported; build with -tags opus to decode Opus
+func NewDecoder(sampleRate int) (*Decoder, error) {
+	return nil, ErrUnsupported
+}
+
+// Decode returns ErrUnsupported
+func (d *Decoder) Decode(packet []byte) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+// Conceal returns ErrUnsupported
+func (d *Decoder) Conceal() ([]byte, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,71 @@
+package opus
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// oggPageHeaderSize is the fixed part of an Ogg page header, before the segment table
+const oggPageHeaderSize = 27
+
+// ReadOggPackets splits an Ogg Opus stream, such as OpenAI's opus speech
+// output, into its Opus packets. The identification and comment headers are
+// skipped. Only a single logical stream is supported
+func ReadOggPackets(data []byte) ([][]byte, error) {
+	var packets [][]byte
+	var packet []byte
+	headers := 0
+
+	for len(data) > 0 {
+		if len(data) < oggPageHeaderSize || !bytes.Equal(data[:4], []byte("OggS")) {
+			return nil, errors.New("invalid ogg page header")
+		}
+		segments := int(data[26])
+		if len(data) < oggPageHeaderSize+segments {
+			return nil, errors.New("truncated ogg segment table")
+		}
+		table := data[oggPageHeaderSize : oggPageHeaderSize+segments]
+		data = data[oggPageHeaderSize+segments:]
+
+		// A packet spans segments until one shorter than 255 bytes, possibly
+		// continuing on the next page
+		for _, size := range table {
+			if int(size) > len(data) {
+				return nil, errors.New("truncated ogg page")
+			}
+			packet = append(packet, data[:size]...)
+			data = data[size:]
+			if size == 255 {
+				continue
+			}
+
+			if headers < 2 {
+				if err := checkOggHeader(headers, packet); err != nil {
+					return nil, err
+				}
+				headers++
+			} else if len(packet) > 0 {
+				packets = append(packets, packet)
+			}
+			packet = nil
+		}
+	}
+
+	if headers < 2 {
+		return nil, errors.New("ogg stream is missing the opus headers")
+	}
+	return packets, nil
+}
+
+// checkOggHeader validates the OpusHead and OpusTags packets that start a stream
+func checkOggHeader(index int, packet []byte) error {
+	magic := []string{"OpusHead", "OpusTags"}[index]
+	if !bytes.HasPrefix(packet, []byte(magic)) {
+		return fmt.Errorf("ogg stream is not opus: missing %s", magic)
+	}
+	if index == 0 && len(packet) >= 10 && packet[9] > 2 {
+		return fmt.Errorf("unsupported opus channel count %d", packet[9])
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+// Package opus handles the Opus audio exchanged with WebRTC clients. Decoding
+// uses libopus through cgo and is only built with the opus build tag; without
+// it NewDecoder returns ErrUnsupported. Reading Ogg Opus is pure Go
+package opus
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ClockRate is the RTP clock rate of Opus, whatever the decoded sample rate
+const ClockRate = 48000
+
+// ErrUnsupported is returned when the binary was built without libopus
+var ErrUnsupported = errors.New("opus support is not built in, rebuild with -tags opus")
+
+// CheckSampleRate reports whether Opus can be decoded straight to sampleRate
+func CheckSampleRate(sampleRate int) error {
+	switch sampleRate {
+	case 8000, 12000, 16000, 24000, 48000:
+		return nil
+	default:
+		return fmt.Errorf("opus cannot be decoded at %d Hz", sampleRate)
+	}
+}
+
+// PacketDuration returns the audio duration of an Opus packet from its TOC
+// byte (RFC 6716 section 3.1)
+func PacketDuration(packet []byte) (time.Duration, error) {
+	if len(packet) == 0 {
+		return 0, errors.New("empty opus packet")
+	}
+
+	toc := packet[0]
+	config := toc >> 3
+
+	var frame time.Duration
+	switch {
+	case config < 12: // SILK
+		frame = []time.Duration{10, 20, 40, 60}[config%4] * time.Millisecond
+	case config < 16: // Hybrid
+		frame = []time.Duration{10, 20}[config%2] * time.Millisecond
+	default: // CELT
+		frame = []time.Duration{2500, 5000, 10000, 20000}[config%4] * time.Microsecond
+	}
+
+	var frames int
+	switch toc & 0x3 {
+	case 0:
+		frames = 1
+	case 1, 2:
+		frames = 2
+	default:
+		if len(packet) < 2 {
+			return 0, errors.New("opus packet is missing its frame count")
+		}
+		frames = int(packet[1] & 0x3f)
+	}
+
+	return frame * time.Duration(frames), nil
+}
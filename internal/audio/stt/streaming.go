@@ -5,7 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
@@ -13,6 +13,8 @@ import (
 	"time"
 
 	"github.com/coder/websocket"
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/metrics"
 )
 
 // StreamingSTT handles real-time speech-to-text using AssemblyAI's streaming API
@@ -24,8 +26,15 @@ type StreamingSTT struct {
 	transcripts chan StreamingResult
 	errors      chan error
 	config      StreamingConfig
+	logger      *slog.Logger
+	sessionTag  *logging.Tag   // AssemblyAI session ID of the log lines
+	closed      chan struct{}  // Closed once Close is called
+	handlers    sync.WaitGroup // Message handlers that may still send
 }
 
+// DefaultStreamingURL is AssemblyAI's real-time streaming endpoint
+const DefaultStreamingURL = "wss://api.assemblyai.com/v2/realtime/ws"
+
 // StreamingConfig holds configuration for the streaming session
 type StreamingConfig struct {
 	SampleRate                       int     `json:"sample_rate"`
@@ -34,6 +43,12 @@ type StreamingConfig struct {
 	EndOfTurnConfidenceThreshold     float64 `json:"end_of_turn_confidence_threshold,omitempty"`
 	MinEndOfTurnSilenceWhenConfident int     `json:"min_end_of_turn_silence_when_confident,omitempty"`
 	MaxTurnSilence                   int     `json:"max_turn_silence,omitempty"`
+
+	// APIKey authenticates with AssemblyAI. If empty, ASSEMBLYAI_API_KEY is used
+	APIKey string `json:"-"`
+
+	// URL is the streaming API endpoint. If empty, AssemblyAI's is used
+	URL string `json:"-"`
 }
 
 // StreamingResult represents a transcription result from the streaming API
@@ -69,17 +84,36 @@ type ConfigUpdateMessage struct {
 
 // NewStreamingSTT creates a new streaming STT instance
 func NewStreamingSTT(config StreamingConfig) *StreamingSTT {
-	apiKey := os.Getenv("ASSEMBLYAI_API_KEY")
+	apiKey := config.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("ASSEMBLYAI_API_KEY")
+	}
 	if apiKey == "" {
 		panic("ASSEMBLYAI_API_KEY environment variable is not set")
 	}
 
-	return &StreamingSTT{
+	s := &StreamingSTT{
 		apiKey:      apiKey,
 		config:      config,
 		transcripts: make(chan StreamingResult, 100),
 		errors:      make(chan error, 10),
+		closed:      make(chan struct{}),
 	}
+	s.SetLogger(nil, nil)
+	return s
+}
+
+// SetLogger sets the logger of the recognizer, which sets sessionTag to the
+// AssemblyAI session ID once a session begins. logger must already carry
+// sessionTag; if sessionTag is nil, the recognizer adds its own. A nil logger
+// uses the default logger. It must be called before Connect
+func (s *StreamingSTT) SetLogger(logger *slog.Logger, sessionTag *logging.Tag) {
+	if sessionTag == nil {
+		sessionTag = logging.NewTag(logging.KeyAssemblyAI)
+		logger = logging.WithTag(logger, sessionTag)
+	}
+	s.logger = logging.OrDefault(logger)
+	s.sessionTag = sessionTag
 }
 
 // Connect establishes a WebSocket connection to AssemblyAI streaming API
@@ -92,25 +126,33 @@ func (s *StreamingSTT) Connect(ctx context.Context) error {
 	}
 
 	// Build WebSocket URL with query parameters
-	u, err := url.Parse("wss://api.assemblyai.com/v2/realtime/ws")
+	endpoint := s.config.URL
+	if endpoint == "" {
+		endpoint = DefaultStreamingURL
+	}
+	u, err := url.Parse(endpoint)
 	if err != nil {
 		return fmt.Errorf("failed to parse WebSocket URL: %w", err)
 	}
 
 	q := u.Query()
 	q.Set("sample_rate", fmt.Sprintf("%d", s.config.SampleRate))
+	if s.config.Encoding != "" {
+		q.Set("encoding", s.config.Encoding)
+	}
 	u.RawQuery = q.Encode()
 
 	// Set up headers
 	headers := http.Header{}
 	headers.Set("Authorization", s.apiKey)
 
-	log.Printf("Connecting to AssemblyAI at %s", u.String())
+	s.logger.Debug("Connecting to AssemblyAI", "url", u.String())
 
 	// Connect to WebSocket with retry logic
 	var retryCount int
 	maxRetries := 3
 	retryDelay := time.Second
+	start := time.Now()
 
 	for retryCount < maxRetries {
 		conn, _, err := websocket.Dial(ctx, u.String(), &websocket.DialOptions{
@@ -118,15 +160,17 @@ func (s *StreamingSTT) Connect(ctx context.Context) error {
 		})
 
 		if err == nil {
+			metrics.STTConnectDuration.WithLabelValues(metrics.OutcomeSuccess).Observe(time.Since(start).Seconds())
 			s.conn = conn
 			s.isConnected = true
 
 			// Start message handler
+			s.handlers.Add(1)
 			go s.handleMessages(ctx)
 			return nil
 		}
 
-		log.Printf("Connection attempt %d failed: %v", retryCount+1, err)
+		s.logger.Warn("AssemblyAI connection attempt failed", "attempt", retryCount+1, logging.Err(err))
 		retryCount++
 		if retryCount < maxRetries {
 			time.Sleep(retryDelay)
@@ -134,6 +178,8 @@ func (s *StreamingSTT) Connect(ctx context.Context) error {
 		}
 	}
 
+	metrics.STTConnectDuration.WithLabelValues(metrics.OutcomeError).Observe(time.Since(start).Seconds())
+	metrics.STTErrors.WithLabelValues(metrics.STTCauseConnect).Inc()
 	return fmt.Errorf("failed to connect after %d retries: %w", maxRetries, err)
 }
 
@@ -163,6 +209,7 @@ func (s *StreamingSTT) SendAudio(audioData []byte) error {
 
 	// Send audio message
 	if err := s.conn.Write(ctx, websocket.MessageText, data); err != nil {
+		metrics.STTErrors.WithLabelValues(metrics.STTCauseSend).Inc()
 		s.sendError(fmt.Errorf("failed to send audio: %w", err))
 		return err
 	}
@@ -170,10 +217,11 @@ func (s *StreamingSTT) SendAudio(audioData []byte) error {
 	return nil
 }
 
-// UpdateConfig sends a configuration update during the session
+// UpdateConfig sends a configuration update during the session. The new
+// configuration is kept so that GetConfig reflects it
 func (s *StreamingSTT) UpdateConfig(config StreamingConfig) error {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	if !s.isConnected || s.conn == nil {
 		return fmt.Errorf("not connected")
@@ -189,7 +237,11 @@ func (s *StreamingSTT) UpdateConfig(config StreamingConfig) error {
 		return fmt.Errorf("failed to marshal config update: %w", err)
 	}
 
-	return s.conn.Write(context.Background(), websocket.MessageText, data)
+	if err := s.conn.Write(context.Background(), websocket.MessageText, data); err != nil {
+		return err
+	}
+	s.config = config
+	return nil
 }
 
 // ForceEndpoint manually forces an endpoint in the transcription
@@ -234,6 +286,7 @@ func (s *StreamingSTT) Close() error {
 	if conn == nil {
 		return nil
 	}
+	close(s.closed)
 
 	// Send session termination message
 	msg := map[string]string{
@@ -243,14 +296,16 @@ func (s *StreamingSTT) Close() error {
 	data, err := json.Marshal(msg)
 	if err == nil {
 		if err := conn.Write(context.Background(), websocket.MessageText, data); err != nil {
-			log.Printf("Failed to send SessionTermination: %v", err)
+			s.logger.Warn("Failed to send SessionTermination", logging.Err(err))
 		}
 	}
 
 	// Close WebSocket connection
 	err = conn.Close(websocket.StatusNormalClosure, "")
 
-	// Close channels after connection is closed
+	// Close channels once the handler, which stops with the connection, can
+	// no longer send on them
+	s.handlers.Wait()
 	close(s.transcripts)
 	close(s.errors)
 
@@ -259,9 +314,10 @@ func (s *StreamingSTT) Close() error {
 
 // handleMessages processes incoming WebSocket messages
 func (s *StreamingSTT) handleMessages(ctx context.Context) {
+	defer s.handlers.Done()
 	defer func() {
 		if r := recover(); r != nil {
-			s.errors <- fmt.Errorf("message handler panic: %v", r)
+			s.sendError(fmt.Errorf("message handler panic: %v", r))
 		}
 	}()
 
@@ -272,44 +328,39 @@ func (s *StreamingSTT) handleMessages(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		default:
+			// Reading blocks, so it must not hold the lock Close takes
 			s.mu.RLock()
 			conn := s.conn
+			s.mu.RUnlock()
 			if conn == nil {
-				s.mu.RUnlock()
-				s.errors <- fmt.Errorf("connection lost")
-				s.reconnect(ctx)
 				return
 			}
 
 			_, message, err := conn.Read(ctx)
-			s.mu.RUnlock()
-
 			if err != nil {
-				if websocket.CloseStatus(err) == websocket.StatusNormalClosure {
+				if s.isClosed() || websocket.CloseStatus(err) == websocket.StatusNormalClosure {
 					return
 				}
+				metrics.STTErrors.WithLabelValues(metrics.STTCauseRead).Inc()
 				select {
 				case s.errors <- fmt.Errorf("failed to read message: %w", err):
 				default:
-					log.Printf("[WARN] Dropping error: failed to read message: %v", err)
+					s.logger.Warn("Dropping error: failed to read message", logging.Err(err))
 				}
-				s.reconnect(ctx)
+				s.reconnect(ctx, metrics.STTCauseRead)
 				return
 			}
 
 			// Parse message
 			var baseMsg map[string]interface{}
 			if err := json.Unmarshal(message, &baseMsg); err != nil {
-				s.sendError(fmt.Errorf("failed to parse message: %w", err))
+				s.sendParseError(fmt.Errorf("failed to parse message: %w", err))
 				continue
 			}
 
-			// Log raw message for debugging
-			log.Printf("[DEBUG] Raw message: %s", string(message))
-
 			msgType, ok := baseMsg["message_type"].(string)
 			if !ok {
-				s.sendError(fmt.Errorf("invalid message type"))
+				s.sendParseError(fmt.Errorf("invalid message type"))
 				continue
 			}
 
@@ -317,41 +368,42 @@ func (s *StreamingSTT) handleMessages(ctx context.Context) {
 			case "SessionBegins":
 				var sessionBegins SessionBegins
 				if err := json.Unmarshal(message, &sessionBegins); err != nil {
-					s.sendError(fmt.Errorf("failed to parse SessionBegins: %w", err))
+					s.sendParseError(fmt.Errorf("failed to parse SessionBegins: %w", err))
 					continue
 				}
 				currentSessionID = sessionBegins.SessionID
-				log.Printf("[INFO] Session established, ID: %s", sessionBegins.SessionID)
+				s.sessionTag.Set(currentSessionID)
+				s.logger.Info("AssemblyAI session established", "expires_at", sessionBegins.ExpiresAt)
 
 			case "Connected":
-				log.Printf("[INFO] Successfully connected to AssemblyAI streaming service")
+				s.logger.Info("Connected to AssemblyAI streaming service")
 
 			case "PartialTranscript":
 				var result StreamingResult
 				if err := json.Unmarshal(message, &result); err != nil {
-					s.sendError(fmt.Errorf("failed to parse PartialTranscript: %w", err))
+					s.sendParseError(fmt.Errorf("failed to parse PartialTranscript: %w", err))
 					continue
 				}
 				result.MessageType = "PartialTranscript"
 				result.IsFinal = false
 				result.SessionID = currentSessionID
 				if result.Text != "" {
-					log.Printf("[DEBUG] Partial: %s", result.Text)
-					s.transcripts <- result
+					s.logger.Debug("Partial transcript", logging.Transcript(result.Text), "confidence", result.Confidence)
+					s.deliver(result)
 				}
 
 			case "FinalTranscript":
 				var result StreamingResult
 				if err := json.Unmarshal(message, &result); err != nil {
-					s.sendError(fmt.Errorf("failed to parse FinalTranscript: %w", err))
+					s.sendParseError(fmt.Errorf("failed to parse FinalTranscript: %w", err))
 					continue
 				}
 				result.MessageType = "FinalTranscript"
 				result.IsFinal = true
 				result.SessionID = currentSessionID
 				if result.Text != "" {
-					log.Printf("[INFO] Final: %s", result.Text)
-					s.transcripts <- result
+					s.logger.Debug("Final transcript", logging.Transcript(result.Text), "confidence", result.Confidence)
+					s.deliver(result)
 				}
 
 			case "Error":
@@ -361,50 +413,75 @@ func (s *StreamingSTT) handleMessages(ctx context.Context) {
 					Code    string `json:"error"`
 				}
 				if err := json.Unmarshal(message, &errorMsg); err != nil {
-					s.sendError(fmt.Errorf("failed to parse error message: %w", err))
+					s.sendParseError(fmt.Errorf("failed to parse error message: %w", err))
 					continue
 				}
-				log.Printf("[ERROR] AssemblyAI error: %s (code: %s)", errorMsg.Message, errorMsg.Code)
+				s.logger.Error("AssemblyAI error", "message", errorMsg.Message, "code", errorMsg.Code)
+				metrics.STTErrors.WithLabelValues(metrics.STTCauseServer).Inc()
 				s.sendError(fmt.Errorf("server error: %s (code: %s)", errorMsg.Message, errorMsg.Code))
 
 			case "SessionTerminated":
-				log.Printf("[INFO] Session terminated by server")
+				s.logger.Info("AssemblyAI session terminated by server")
 				currentSessionID = ""
 				return
 
 			default:
 				if msgType != "" {
-					log.Printf("[DEBUG] Received message type: %s", msgType)
-					log.Printf("[DEBUG] Raw message: %s", string(message))
+					s.logger.Debug("Ignoring AssemblyAI message", "message_type", msgType)
 				}
 			}
 		}
 	}
 }
 
-// reconnect attempts to reestablish the WebSocket connection
-func (s *StreamingSTT) reconnect(ctx context.Context) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// reconnect attempts to reestablish the WebSocket connection after it failed
+// for cause
+func (s *StreamingSTT) reconnect(ctx context.Context, cause string) {
+	metrics.STTReconnects.WithLabelValues(cause).Inc()
 
+	s.mu.Lock()
 	if s.conn != nil {
 		s.conn.Close(websocket.StatusGoingAway, "reconnecting")
 		s.conn = nil
 	}
 	s.isConnected = false
+	s.mu.Unlock()
 
 	// Try to reconnect
-	err := s.Connect(ctx)
-	if err != nil {
-		s.errors <- fmt.Errorf("reconnection failed: %w", err)
+	if err := s.Connect(ctx); err != nil {
+		s.sendError(fmt.Errorf("reconnection failed: %w", err))
 	}
 }
 
-// GetDefaultStreamingConfig returns default configuration for streaming
+// isClosed reports whether Close has been called
+func (s *StreamingSTT) isClosed() bool {
+	select {
+	case <-s.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// deliver passes a transcript on, unless the recognizer is closed and nobody
+// may be reading anymore
+func (s *StreamingSTT) deliver(result StreamingResult) {
+	select {
+	case s.transcripts <- result:
+	case <-s.closed:
+	}
+}
+
+// GetDefaultStreamingConfig returns default configuration for streaming:
+// 16kHz 16-bit PCM with formatted turns
 func GetDefaultStreamingConfig() StreamingConfig {
 	return StreamingConfig{
-		SampleRate: 16000,
-		Encoding:   "pcm_s16le",
+		SampleRate:                       16000,
+		Encoding:                         "pcm_s16le",
+		FormatTurns:                      true,
+		EndOfTurnConfidenceThreshold:     0.7,
+		MinEndOfTurnSilenceWhenConfident: 1000,
+		MaxTurnSilence:                   3000,
 	}
 }
 
@@ -413,10 +490,16 @@ func (s *StreamingSTT) sendError(err error) {
 	select {
 	case s.errors <- err:
 	default:
-		log.Printf("Dropping error: %v", err)
+		s.logger.Warn("Dropping error", logging.Err(err))
 	}
 }
 
+// sendParseError reports a message from AssemblyAI that could not be parsed
+func (s *StreamingSTT) sendParseError(err error) {
+	metrics.STTErrors.WithLabelValues(metrics.STTCauseParse).Inc()
+	s.sendError(err)
+}
+
 // GetConfig returns the current streaming configuration
 func (s *StreamingSTT) GetConfig() StreamingConfig {
 	s.mu.RLock()
@@ -28,6 +28,16 @@ func NewSTT() *STT {
 	}
 }
 
+// Ping checks that AssemblyAI is reachable and accepts apiKey by listing a
+// single transcript, which is not billed
+func Ping(ctx context.Context, apiKey string) error {
+	_, err := assemblyai.NewClient(apiKey).Transcripts.List(ctx, assemblyai.ListTranscriptParams{Limit: assemblyai.Int64(1)})
+	if err != nil {
+		return fmt.Errorf("assemblyai: %w", err)
+	}
+	return nil
+}
+
 // Transcribe converts audio data to text using AssemblyAI
 func (s *STT) Transcribe(audioData []byte) (string, error) {
 	// Create a reader from the audio data
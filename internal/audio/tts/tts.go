@@ -4,14 +4,23 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
+	"time"
 
 	"github.com/sashabaranov/go-openai"
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/metrics"
+	"github.com/torteous44/callservice/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // TTS represents a Text-to-Speech service using OpenAI
 type TTS struct {
 	client *openai.Client
+	logger *slog.Logger
 }
 
 // NewTTS creates a new Text-to-Speech service using OpenAI
@@ -24,9 +33,34 @@ func NewTTS() *TTS {
 	client := openai.NewClient(apiKey)
 	return &TTS{
 		client: client,
+		logger: slog.Default(),
 	}
 }
 
+// NewTTSWithConfig creates a Text-to-Speech service with an OpenAI client
+// configuration, such as one with the API key from the service configuration
+func NewTTSWithConfig(config openai.ClientConfig) *TTS {
+	return &TTS{
+		client: openai.NewClientWithConfig(config),
+		logger: slog.Default(),
+	}
+}
+
+// Ping checks that OpenAI is reachable, accepts apiKey and serves model,
+// without synthesizing any speech
+func Ping(ctx context.Context, apiKey, model string) error {
+	if _, err := openai.NewClient(apiKey).GetModel(ctx, model); err != nil {
+		return fmt.Errorf("openai: %w", err)
+	}
+	return nil
+}
+
+// SetLogger sets the logger of synthesis requests. A nil logger uses the
+// default logger
+func (t *TTS) SetLogger(logger *slog.Logger) {
+	t.logger = logging.OrDefault(logger)
+}
+
 // Synthesize converts text to audio data using OpenAI TTS
 func (t *TTS) Synthesize(text string) ([]byte, error) {
 	return t.SynthesizeWithVoice(text, openai.VoiceAlloy)
@@ -40,23 +74,18 @@ func (t *TTS) SynthesizeWithVoice(text string, voice openai.SpeechVoice) ([]byte
 		Voice: voice,
 	}
 
-	response, err := t.client.CreateSpeech(context.Background(), req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create speech: %w", err)
-	}
-	defer response.Close()
-
-	// Read the audio data
-	audioData, err := io.ReadAll(response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read audio data: %w", err)
-	}
-
-	return audioData, nil
+	return t.createSpeech(context.Background(), req, "failed to create speech")
 }
 
 // SynthesizeWithOptions converts text to audio with custom options
 func (t *TTS) SynthesizeWithOptions(text string, opts SynthesizeOptions) ([]byte, error) {
+	return t.SynthesizeContext(context.Background(), text, opts)
+}
+
+// SynthesizeContext converts text to audio with custom options. The request
+// is traced as a child of the span in ctx, such as the candidate turn it
+// answers, and is canceled with ctx
+func (t *TTS) SynthesizeContext(ctx context.Context, text string, opts SynthesizeOptions) ([]byte, error) {
 	req := openai.CreateSpeechRequest{
 		Model:          opts.Model,
 		Input:          text,
@@ -65,14 +94,40 @@ func (t *TTS) SynthesizeWithOptions(text string, opts SynthesizeOptions) ([]byte
 		Speed:          opts.Speed,
 	}
 
-	response, err := t.client.CreateSpeech(context.Background(), req)
+	return t.createSpeech(ctx, req, "failed to create speech with options")
+}
+
+// createSpeech sends a speech request and reads the audio, recording the
+// request latency and a span with the arrival of the first audio byte
+func (t *TTS) createSpeech(ctx context.Context, req openai.CreateSpeechRequest, failure string) (audioData []byte, err error) {
+	start := time.Now()
+	ctx, span := tracing.Tracer().Start(ctx, "tts.synthesize", trace.WithAttributes(
+		attribute.Int("tts.characters", len(req.Input)), attribute.String("tts.voice", string(req.Voice))))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+		elapsed := time.Since(start)
+		metrics.TTSRequestDuration.WithLabelValues(metrics.Outcome(err)).Observe(elapsed.Seconds())
+		if err != nil {
+			t.logger.Error("Speech synthesis failed", "characters", len(req.Input), "duration_ms", elapsed.Milliseconds(), logging.Err(err))
+			return
+		}
+		t.logger.Debug("Speech synthesized", "characters", len(req.Input), "voice", req.Voice,
+			"bytes", len(audioData), "duration_ms", elapsed.Milliseconds())
+	}()
+
+	response, err := t.client.CreateSpeech(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create speech with options: %w", err)
+		return nil, fmt.Errorf("%s: %w", failure, err)
 	}
 	defer response.Close()
 
 	// Read the audio data
-	audioData, err := io.ReadAll(response)
+	audioData, err = io.ReadAll(&firstByteReader{Reader: response, span: span})
 	if err != nil {
 		return nil, fmt.Errorf("failed to read audio data: %w", err)
 	}
@@ -80,6 +135,22 @@ func (t *TTS) SynthesizeWithOptions(text string, opts SynthesizeOptions) ([]byte
 	return audioData, nil
 }
 
+// firstByteReader records on a span when the first audio byte is read
+type firstByteReader struct {
+	io.Reader
+	span trace.Span
+	seen bool
+}
+
+func (r *firstByteReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 && !r.seen {
+		r.seen = true
+		r.span.AddEvent("first_byte")
+	}
+	return n, err
+}
+
 // SynthesizeToFile converts text to speech and saves to a file
 func (t *TTS) SynthesizeToFile(text, filePath string) error {
 	audioData, err := t.Synthesize(text)
@@ -3,7 +3,10 @@ package vad
 import (
 	"encoding/binary"
 	"fmt"
+	"log/slog"
 	"math"
+
+	"github.com/torteous44/callservice/internal/logging"
 )
 
 // VAD represents a Voice Activity Detector
@@ -16,21 +19,67 @@ type VAD struct {
 	buffer             []bool // circular buffer for smoothing detection
 	bufferSize         int    // size of the circular buffer
 	bufferIndex        int    // current position in the buffer
+	active             bool   // last decision, to log transitions
+	logger             *slog.Logger
+}
+
+// Config holds the detector's tuning
+type Config struct {
+	EnergyThreshold  float64 // RMS energy above which a frame is voice
+	MinVoiceFrames   int     // Consecutive voice frames needed to confirm voice
+	MinSilenceFrames int     // Consecutive silent frames needed to confirm silence
+	SmoothingWindow  int     // Frames in the majority vote smoothing each decision
+}
+
+// DefaultConfig returns the default detector tuning
+func DefaultConfig() Config {
+	return Config{
+		EnergyThreshold:  1000.0,
+		MinVoiceFrames:   3,
+		MinSilenceFrames: 5,
+		SmoothingWindow:  5,
+	}
 }
 
 // NewVAD creates a new Voice Activity Detector
 func NewVAD() *VAD {
-	bufferSize := 5 // Adjust this value to change smoothing window
+	return NewVADWithConfig(DefaultConfig())
+}
+
+// NewVADWithConfig creates a Voice Activity Detector with the given tuning.
+// Zero values use the defaults
+func NewVADWithConfig(config Config) *VAD {
+	defaults := DefaultConfig()
+	if config.EnergyThreshold <= 0 {
+		config.EnergyThreshold = defaults.EnergyThreshold
+	}
+	if config.MinVoiceFrames <= 0 {
+		config.MinVoiceFrames = defaults.MinVoiceFrames
+	}
+	if config.MinSilenceFrames <= 0 {
+		config.MinSilenceFrames = defaults.MinSilenceFrames
+	}
+	if config.SmoothingWindow <= 0 {
+		config.SmoothingWindow = defaults.SmoothingWindow
+	}
+
 	return &VAD{
-		energyThreshold:    1000.0, // Adjustable threshold for energy detection
-		minVoiceDuration:   3,      // Need 3+ frames of voice to confirm
-		minSilenceDuration: 5,      // Need 5+ frames of silence to confirm
-		buffer:             make([]bool, bufferSize),
-		bufferSize:         bufferSize,
+		energyThreshold:    config.EnergyThreshold,
+		minVoiceDuration:   config.MinVoiceFrames,
+		minSilenceDuration: config.MinSilenceFrames,
+		buffer:             make([]bool, config.SmoothingWindow),
+		bufferSize:         config.SmoothingWindow,
 		bufferIndex:        0,
+		logger:             slog.Default(),
 	}
 }
 
+// SetLogger sets the logger voice activity transitions are logged to at debug
+// level. A nil logger uses the default logger
+func (v *VAD) SetLogger(logger *slog.Logger) {
+	v.logger = logging.OrDefault(logger)
+}
+
 // DetectActivity detects voice activity in audio data
 // Assumes 16-bit PCM audio data
 func (v *VAD) DetectActivity(audioData []byte) (bool, error) {
@@ -66,17 +115,24 @@ func (v *VAD) DetectActivity(audioData []byte) (bool, error) {
 	smoothedVoice := trueCount > v.bufferSize/2
 
 	// Apply temporal smoothing to reduce false positives/negatives
+	var active bool
 	if smoothedVoice {
 		v.voiceCounter++
 		v.silenceCounter = 0
 		// Confirm voice only if we have enough consecutive voice frames
-		return v.voiceCounter >= v.minVoiceDuration, nil
+		active = v.voiceCounter >= v.minVoiceDuration
 	} else {
 		v.silenceCounter++
 		v.voiceCounter = 0
 		// Confirm silence only if we have enough consecutive silence frames
-		return v.silenceCounter < v.minSilenceDuration, nil
+		active = v.silenceCounter < v.minSilenceDuration
+	}
+
+	if active != v.active {
+		v.active = active
+		v.logger.Debug("Voice activity changed", "voice", active, "energy", energy, "threshold", v.energyThreshold)
 	}
+	return active, nil
 }
 
 // calculateEnergy computes the RMS energy of audio samples
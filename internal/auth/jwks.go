@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// jsonWebKey is an entry of a JSON Web Key Set. Only RSA signing keys are used
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Modulus   string `json:"n"`
+	Exponent  string `json:"e"`
+}
+
+// keySet holds the RS256 verification keys
+type keySet struct {
+	keys map[string]*rsa.PublicKey // By key ID
+}
+
+// candidates returns the keys a token signed with keyID may verify with:
+// that key if the token names one, otherwise all of them
+func (s *keySet) candidates(keyID string) []*rsa.PublicKey {
+	if keyID != "" {
+		if key, ok := s.keys[keyID]; ok {
+			return []*rsa.PublicKey{key}
+		}
+		return nil
+	}
+	keys := make([]*rsa.PublicKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// loadJWKS reads the RSA signing keys of a JWKS file. Keys of other types or
+// uses are skipped
+func loadJWKS(path string) (*keySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS: %w", err)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS %s: %w", path, err)
+	}
+
+	keys := &keySet{keys: make(map[string]*rsa.PublicKey)}
+	for i, jwk := range set.Keys {
+		if jwk.KeyType != "RSA" || (jwk.Use != "" && jwk.Use != "sig") || (jwk.Algorithm != "" && jwk.Algorithm != "RS256") {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %d in JWKS %s: %w", i, path, err)
+		}
+		if _, exists := keys.keys[jwk.KeyID]; exists {
+			return nil, fmt.Errorf("duplicate key ID %q in JWKS %s", jwk.KeyID, path)
+		}
+		keys.keys[jwk.KeyID] = key
+	}
+	if len(keys.keys) == 0 {
+		return nil, fmt.Errorf("no RS256 signing keys in JWKS %s", path)
+	}
+	return keys, nil
+}
+
+// publicKey decodes an RSA public key
+func (k jsonWebKey) publicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.Modulus)
+	if err != nil || len(n) == 0 {
+		return nil, errors.New("invalid modulus")
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.Exponent)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid exponent")
+	}
+
+	key := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}
+	if key.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("RSA key is %d bits, at least %d required", key.N.BitLen(), minRSAKeyBits)
+	}
+	if key.E < 3 || key.E%2 == 0 {
+		return nil, errors.New("invalid exponent")
+	}
+	return key, nil
+}
@@ -0,0 +1,286 @@
+// Package auth authenticates API callers and enforces the browser origin
+// policy. Callers present a JWT bearer token signed by the identity provider,
+// HS256 with a shared secret or RS256 with keys from a JWKS file; its subject
+// is the user that owns the sessions it creates
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/torteous44/callservice/internal/logging"
+)
+
+// Token errors
+var (
+	ErrMissingToken = errors.New("bearer token required")
+	ErrInvalidToken = errors.New("invalid bearer token")
+	ErrExpiredToken = errors.New("bearer token expired")
+)
+
+// Defaults
+const (
+	DefaultLeeway         = 30 * time.Second // Clock skew allowed on exp and nbf
+	DefaultReloadInterval = 30 * time.Second // How often the JWKS file is checked for changes
+	minRSAKeyBits         = 2048
+)
+
+// Options configures bearer token verification. At least one of Secret and
+// JWKSFile must be set
+type Options struct {
+	Secret   []byte // Verifies HS256 tokens
+	JWKSFile string // JSON Web Key Set verifying RS256 tokens
+
+	Issuer   string // Required iss claim, if set
+	Audience string // Required aud claim, if set
+
+	Leeway         time.Duration // Zero uses DefaultLeeway
+	ReloadInterval time.Duration // Zero uses DefaultReloadInterval
+}
+
+// Enabled reports whether bearer authentication is configured
+func (o Options) Enabled() bool {
+	return len(o.Secret) > 0 || o.JWKSFile != ""
+}
+
+// Claims are the verified claims of a bearer token
+type Claims struct {
+	Subject   string // The user ID
+	Issuer    string
+	Audience  []string
+	ExpiresAt time.Time
+}
+
+// tokenClaims is the payload of a token
+type tokenClaims struct {
+	Subject   string      `json:"sub"`
+	Issuer    string      `json:"iss,omitempty"`
+	Audience  audience    `json:"aud,omitempty"`
+	ExpiresAt numericDate `json:"exp"`
+	NotBefore numericDate `json:"nbf,omitempty"`
+}
+
+// audience is the aud claim, a string or an array of strings
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+// numericDate is a NumericDate claim, in seconds since the epoch
+type numericDate int64
+
+func (t numericDate) time() time.Time {
+	return time.Unix(int64(t), 0)
+}
+
+// header is the JOSE header of a token
+type header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+}
+
+// Verifier verifies bearer tokens
+type Verifier struct {
+	opts    Options
+	keys    atomic.Pointer[keySet]
+	modTime time.Time
+}
+
+// NewVerifier creates a verifier, loading the JWKS file if one is set
+func NewVerifier(opts Options) (*Verifier, error) {
+	if !opts.Enabled() {
+		return nil, errors.New("a JWT secret or JWKS file is required")
+	}
+	if opts.Leeway == 0 {
+		opts.Leeway = DefaultLeeway
+	}
+	if opts.ReloadInterval == 0 {
+		opts.ReloadInterval = DefaultReloadInterval
+	}
+
+	v := &Verifier{opts: opts}
+	v.keys.Store(&keySet{})
+	if opts.JWKSFile != "" {
+		if err := v.reload(); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// reload loads the JWKS file
+func (v *Verifier) reload() error {
+	info, err := os.Stat(v.opts.JWKSFile)
+	if err != nil {
+		return fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	keys, err := loadJWKS(v.opts.JWKSFile)
+	if err != nil {
+		return err
+	}
+	v.keys.Store(keys)
+	v.modTime = info.ModTime()
+	slog.Info("Loaded signing keys", "keys", len(keys.keys), "file", v.opts.JWKSFile)
+	return nil
+}
+
+// Run reloads the JWKS file whenever it changes, until ctx is done, so the
+// identity provider can rotate its keys
+func (v *Verifier) Run(ctx context.Context) {
+	if v.opts.JWKSFile == "" {
+		return
+	}
+
+	ticker := time.NewTicker(v.opts.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(v.opts.JWKSFile)
+		if err != nil {
+			slog.Warn("Cannot check JWKS for changes", logging.Err(err))
+			continue
+		}
+		if info.ModTime().Equal(v.modTime) {
+			continue
+		}
+		if err := v.reload(); err != nil {
+			slog.Error("Failed to reload JWKS, keeping the current keys", logging.Err(err))
+			v.modTime = info.ModTime()
+		}
+	}
+}
+
+// Verify checks a token's signature and claims and returns them. Tokens
+// without a subject are rejected, since it identifies the session owner
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var hdr header
+	if err := decodeSegment(parts[0], &hdr); err != nil {
+		return nil, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := v.verifySignature(hdr, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims tokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidToken)
+	}
+	if v.opts.Issuer != "" && claims.Issuer != v.opts.Issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if v.opts.Audience != "" && !slices.Contains(claims.Audience, v.opts.Audience) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+
+	if claims.ExpiresAt == 0 {
+		return nil, fmt.Errorf("%w: no expiry", ErrInvalidToken)
+	}
+	now := time.Now()
+	if now.After(claims.ExpiresAt.time().Add(v.opts.Leeway)) {
+		return nil, ErrExpiredToken
+	}
+	if claims.NotBefore != 0 && now.Add(v.opts.Leeway).Before(claims.NotBefore.time()) {
+		return nil, fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+	return &Claims{
+		Subject:   claims.Subject,
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+		ExpiresAt: claims.ExpiresAt.time(),
+	}, nil
+}
+
+// verifySignature checks the signature of a token's signing input. The
+// algorithm must match the kind of key configured for it, so an RSA public
+// key can never be used as an HMAC secret
+func (v *Verifier) verifySignature(hdr header, signingInput string, signature []byte) error {
+	switch hdr.Algorithm {
+	case "HS256":
+		if len(v.opts.Secret) == 0 {
+			return fmt.Errorf("%w: HS256 is not accepted", ErrInvalidToken)
+		}
+		mac := hmac.New(sha256.New, v.opts.Secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrInvalidToken
+		}
+		return nil
+	case "RS256":
+		digest := sha256.Sum256([]byte(signingInput))
+		for _, key := range v.keys.Load().candidates(hdr.KeyID) {
+			if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil {
+				return nil
+			}
+		}
+		return ErrInvalidToken
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, hdr.Algorithm)
+	}
+}
+
+// decodeSegment decodes a base64url JSON segment of a token
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// BearerToken returns the token of a request's Authorization header
+func BearerToken(r *http.Request) (string, error) {
+	return ParseAuthorization(r.Header.Get("Authorization"))
+}
+
+// ParseAuthorization returns the token of a "Bearer <token>" authorization
+// value, as sent in an HTTP header or gRPC metadata
+func ParseAuthorization(value string) (string, error) {
+	scheme, token, found := strings.Cut(value, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", ErrMissingToken
+	}
+	return strings.TrimSpace(token), nil
+}
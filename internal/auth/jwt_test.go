@@ -0,0 +1,325 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testSecret = []byte("test-secret")
+	testRSAKey = mustRSAKey()
+)
+
+func mustRSAKey() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// writeJWKS writes a JWKS file holding the public half of key under keyID
+func writeJWKS(t *testing.T, keyID string, key *rsa.PrivateKey) string {
+	t.Helper()
+
+	set := map[string][]jsonWebKey{"keys": {{
+		KeyType:   "RSA",
+		KeyID:     keyID,
+		Use:       "sig",
+		Algorithm: "RS256",
+		Modulus:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		Exponent:  base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// encodeSegment encodes a token segment
+func encodeSegment(t *testing.T, v interface{}) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// hs256 signs a token with an HMAC key
+func hs256(t *testing.T, key []byte, claims map[string]interface{}) string {
+	t.Helper()
+
+	input := encodeSegment(t, header{Algorithm: "HS256"}) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// rs256 signs a token with testRSAKey, naming keyID if it is set
+func rs256(t *testing.T, keyID string, claims map[string]interface{}) string {
+	t.Helper()
+
+	input := encodeSegment(t, header{Algorithm: "RS256", KeyID: keyID}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, testRSAKey, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// validClaims returns the claims of a token the test verifiers accept, with
+// changes applied
+func validClaims(changes map[string]interface{}) map[string]interface{} {
+	claims := map[string]interface{}{
+		"sub": "user",
+		"iss": "https://idp.example.com",
+		"aud": "callservice",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range changes {
+		if value == nil {
+			delete(claims, name)
+			continue
+		}
+		claims[name] = value
+	}
+	return claims
+}
+
+func TestVerify(t *testing.T) {
+	jwks := writeJWKS(t, "key-1", testRSAKey)
+	publicKey, err := x509.MarshalPKIXPublicKey(&testRSAKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		opts    Options
+		token   func(t *testing.T) string
+		wantErr error
+	}{
+		{
+			name:  "HS256",
+			opts:  Options{Secret: testSecret},
+			token: func(t *testing.T) string { return hs256(t, testSecret, validClaims(nil)) },
+		},
+		{
+			name:  "RS256 naming its key",
+			opts:  Options{JWKSFile: jwks},
+			token: func(t *testing.T) string { return rs256(t, "key-1", validClaims(nil)) },
+		},
+		{
+			name:  "RS256 without a key ID",
+			opts:  Options{JWKSFile: jwks},
+			token: func(t *testing.T) string { return rs256(t, "", validClaims(nil)) },
+		},
+		{
+			name:    "RS256 with an unknown key ID",
+			opts:    Options{JWKSFile: jwks},
+			token:   func(t *testing.T) string { return rs256(t, "key-2", validClaims(nil)) },
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "alg none",
+			opts: Options{Secret: testSecret, JWKSFile: jwks},
+			token: func(t *testing.T) string {
+				return encodeSegment(t, header{Algorithm: "none"}) + "." + encodeSegment(t, validClaims(nil)) + "."
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "HS256 signed with the RSA public key",
+			opts:    Options{JWKSFile: jwks},
+			token:   func(t *testing.T) string { return hs256(t, publicKey, validClaims(nil)) },
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "HS256 signed with the RSA public key when a secret is set",
+			opts:    Options{Secret: testSecret, JWKSFile: jwks},
+			token:   func(t *testing.T) string { return hs256(t, publicKey, validClaims(nil)) },
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "RS256 without a JWKS",
+			opts:    Options{Secret: testSecret},
+			token:   func(t *testing.T) string { return rs256(t, "key-1", validClaims(nil)) },
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "tampered claims",
+			opts: Options{Secret: testSecret},
+			token: func(t *testing.T) string {
+				parts := strings.Split(hs256(t, testSecret, validClaims(nil)), ".")
+				parts[1] = encodeSegment(t, validClaims(map[string]interface{}{"sub": "admin"}))
+				return strings.Join(parts, ".")
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "wrong secret",
+			opts:    Options{Secret: testSecret},
+			token:   func(t *testing.T) string { return hs256(t, []byte("other-secret"), validClaims(nil)) },
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "malformed",
+			opts:    Options{Secret: testSecret},
+			token:   func(t *testing.T) string { return "not-a-token" },
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "expired within the leeway",
+			opts: Options{Secret: testSecret},
+			token: func(t *testing.T) string {
+				return hs256(t, testSecret, validClaims(map[string]interface{}{"exp": now.Add(-10 * time.Second).Unix()}))
+			},
+		},
+		{
+			name: "expired past the leeway",
+			opts: Options{Secret: testSecret},
+			token: func(t *testing.T) string {
+				return hs256(t, testSecret, validClaims(map[string]interface{}{"exp": now.Add(-time.Minute).Unix()}))
+			},
+			wantErr: ErrExpiredToken,
+		},
+		{
+			name: "expired with a longer leeway",
+			opts: Options{Secret: testSecret, Leeway: 2 * time.Minute},
+			token: func(t *testing.T) string {
+				return hs256(t, testSecret, validClaims(map[string]interface{}{"exp": now.Add(-time.Minute).Unix()}))
+			},
+		},
+		{
+			name: "no expiry",
+			opts: Options{Secret: testSecret},
+			token: func(t *testing.T) string {
+				return hs256(t, testSecret, validClaims(map[string]interface{}{"exp": nil}))
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "not valid yet within the leeway",
+			opts: Options{Secret: testSecret},
+			token: func(t *testing.T) string {
+				return hs256(t, testSecret, validClaims(map[string]interface{}{"nbf": now.Add(10 * time.Second).Unix()}))
+			},
+		},
+		{
+			name: "not valid yet past the leeway",
+			opts: Options{Secret: testSecret},
+			token: func(t *testing.T) string {
+				return hs256(t, testSecret, validClaims(map[string]interface{}{"nbf": now.Add(time.Minute).Unix()}))
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "no subject",
+			opts: Options{Secret: testSecret},
+			token: func(t *testing.T) string {
+				return hs256(t, testSecret, validClaims(map[string]interface{}{"sub": nil}))
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:  "expected issuer",
+			opts:  Options{Secret: testSecret, Issuer: "https://idp.example.com"},
+			token: func(t *testing.T) string { return hs256(t, testSecret, validClaims(nil)) },
+		},
+		{
+			name: "unexpected issuer",
+			opts: Options{Secret: testSecret, Issuer: "https://idp.example.com"},
+			token: func(t *testing.T) string {
+				return hs256(t, testSecret, validClaims(map[string]interface{}{"iss": "https://evil.example.com"}))
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "expected audience in a list",
+			opts: Options{Secret: testSecret, Audience: "callservice"},
+			token: func(t *testing.T) string {
+				return hs256(t, testSecret, validClaims(map[string]interface{}{"aud": []string{"other", "callservice"}}))
+			},
+		},
+		{
+			name: "unexpected audience",
+			opts: Options{Secret: testSecret, Audience: "callservice"},
+			token: func(t *testing.T) string {
+				return hs256(t, testSecret, validClaims(map[string]interface{}{"aud": "other"}))
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "no audience",
+			opts: Options{Secret: testSecret, Audience: "callservice"},
+			token: func(t *testing.T) string {
+				return hs256(t, testSecret, validClaims(map[string]interface{}{"aud": nil}))
+			},
+			wantErr: ErrInvalidToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := NewVerifier(tt.opts)
+			if err != nil {
+				t.Fatalf("NewVerifier: %v", err)
+			}
+
+			claims, err := verifier.Verify(tt.token(t))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Verify: err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if claims.Subject != "user" {
+				t.Errorf("subject = %q, want user", claims.Subject)
+			}
+		})
+	}
+}
+
+func TestParseAuthorization(t *testing.T) {
+	tests := []struct {
+		value     string
+		wantToken string
+		wantErr   error
+	}{
+		{value: "Bearer abc.def.ghi", wantToken: "abc.def.ghi"},
+		{value: "bearer  abc.def.ghi ", wantToken: "abc.def.ghi"},
+		{value: "", wantErr: ErrMissingToken},
+		{value: "Bearer ", wantErr: ErrMissingToken},
+		{value: "Basic dXNlcjpwYXNz", wantErr: ErrMissingToken},
+	}
+	for _, tt := range tests {
+		token, err := ParseAuthorization(tt.value)
+		if token != tt.wantToken || !errors.Is(err, tt.wantErr) {
+			t.Errorf("ParseAuthorization(%q) = %q, %v, want %q, %v", tt.value, token, err, tt.wantToken, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORS response settings
+const (
+	corsAllowedMethods = "GET, POST, DELETE, OPTIONS"
+	corsAllowedHeaders = "Authorization, Content-Type, Last-Event-ID"
+)
+
+// CORSOptions configures which browser origins may call the API
+type CORSOptions struct {
+	// AllowedOrigins lists origins such as https://app.example.com. An entry
+	// may start with a wildcard subdomain (https://*.example.com), and "*"
+	// allows any origin. Requests from the service's own origin are always
+	// allowed
+	AllowedOrigins []string
+
+	AllowCredentials bool          // Let browsers send cookies and client certificates
+	MaxAge           time.Duration // How long browsers may cache a preflight
+}
+
+// originPattern is an allowed origin, optionally matching any subdomain
+type originPattern struct {
+	scheme   string
+	host     string // Host and port; the parent domain of a wildcard
+	wildcard bool
+}
+
+// OriginPolicy decides which browser origins may call the API and open
+// WebSockets
+type OriginPolicy struct {
+	opts     CORSOptions
+	any      bool
+	patterns []originPattern
+}
+
+// NewOriginPolicy parses the allowed origins
+func NewOriginPolicy(opts CORSOptions) (*OriginPolicy, error) {
+	p := &OriginPolicy{opts: opts}
+	for _, origin := range opts.AllowedOrigins {
+		if origin == "*" {
+			p.any = true
+			continue
+		}
+		pattern, err := parseOriginPattern(origin)
+		if err != nil {
+			return nil, err
+		}
+		p.patterns = append(p.patterns, pattern)
+	}
+	return p, nil
+}
+
+// parseOriginPattern parses an allowed origin
+func parseOriginPattern(origin string) (originPattern, error) {
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return originPattern{}, fmt.Errorf("%q is not an http or https origin", origin)
+	}
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return originPattern{}, fmt.Errorf("origin %q must only have a scheme and host", origin)
+	}
+
+	pattern := originPattern{scheme: u.Scheme, host: strings.ToLower(u.Host)}
+	if parent, found := strings.CutPrefix(pattern.host, "*."); found {
+		if parent == "" || strings.Contains(parent, "*") {
+			return originPattern{}, fmt.Errorf("invalid wildcard origin %q", origin)
+		}
+		pattern.host = parent
+		pattern.wildcard = true
+	} else if strings.Contains(pattern.host, "*") {
+		return originPattern{}, fmt.Errorf("invalid wildcard origin %q", origin)
+	}
+	return pattern, nil
+}
+
+// Allowed reports whether an origin is on the allowlist
+func (p *OriginPolicy) Allowed(origin string) bool {
+	if p.any {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host := strings.ToLower(u.Host)
+	for _, pattern := range p.patterns {
+		if u.Scheme != pattern.scheme {
+			continue
+		}
+		if host == pattern.host && !pattern.wildcard {
+			return true
+		}
+		if pattern.wildcard && strings.HasSuffix(host, "."+pattern.host) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOrigin reports whether a WebSocket upgrade may proceed. Browsers always
+// send an Origin, which must be the service's own or on the allowlist.
+// Requests without one come from other servers, such as telephony providers,
+// and are authenticated by the endpoint itself
+func (p *OriginPolicy) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || sameOrigin(r, origin) || p.Allowed(origin)
+}
+
+// Handler adds CORS headers for allowed origins and answers preflight
+// requests, so handlers need not deal with either
+func (p *OriginPolicy) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		allowed := p.Allowed(origin)
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if p.opts.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		// Preflight
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if !allowed {
+				http.Error(w, "Origin not allowed", http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+			w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+			if p.opts.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(p.opts.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sameOrigin reports whether origin is the host the request was sent to
+func sameOrigin(r *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
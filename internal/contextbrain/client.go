@@ -1,17 +1,128 @@
 package contextbrain
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/torteous44/callservice/internal/metrics"
+	"github.com/torteous44/callservice/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Operations a query is made for, which label its metrics and span
+const (
+	OperationQuery    = "query"
+	OperationGrading  = "grading"
+	OperationFollowUp = "follow_up"
+)
+
+// attrTokens is the span attribute of the tokens a query used
+const attrTokens = attribute.Key("llm.tokens")
+
+// Options configures a Client
+type Options struct {
+	APIKey      string
+	BaseURL     string // Of the OpenAI API, empty uses OpenAI's
+	Model       string
+	Temperature float32
+	MaxTokens   int           // Of each answer
+	Timeout     time.Duration // Of each query, 0 for none
+}
+
+// Request is a query to the context brain
+type Request struct {
+	Operation string // OperationGrading, OperationFollowUp or OperationQuery
+	System    string // Instructions, such as the lesson and the current question
+	Prompt    string // What the candidate said
+	JSON      bool   // Answer with a JSON object
+}
+
+// Response is the answer to a query
+type Response struct {
+	Text   string
+	Tokens int // Prompt and completion tokens used
+}
+
 // Client represents a context brain API client
 type Client struct {
-	// TODO: Add client implementation fields
+	client  *openai.Client
+	options Options
 }
 
 // NewClient creates a new context brain client
-func NewClient() *Client {
-	return &Client{}
+func NewClient(opts Options) *Client {
+	config := openai.DefaultConfig(opts.APIKey)
+	if opts.BaseURL != "" {
+		config.BaseURL = opts.BaseURL
+	}
+	return &Client{client: openai.NewClientWithConfig(config), options: opts}
+}
+
+// Ping checks that the OpenAI model behind the context brain is reachable
+// and accepts apiKey, without generating anything
+func Ping(ctx context.Context, apiKey, model string) error {
+	if _, err := openai.NewClient(apiKey).GetModel(ctx, model); err != nil {
+		return fmt.Errorf("openai: %w", err)
+	}
+	return nil
 }
 
 // Query sends a query to the context brain
-func (c *Client) Query(query string) (string, error) {
-	// TODO: Implement context brain query
-	return "", nil
+func (c *Client) Query(query string) (response string, err error) {
+	answer, err := c.QueryContext(context.Background(), Request{Operation: OperationQuery, Prompt: query})
+	return answer.Text, err
+}
+
+// QueryContext sends a query to the context brain. It is traced as a child of
+// the span in ctx, such as the candidate turn it grades or answers, and is
+// canceled with ctx
+func (c *Client) QueryContext(ctx context.Context, req Request) (response Response, err error) {
+	start := time.Now()
+	ctx, span := tracing.Tracer().Start(ctx, "contextbrain."+req.Operation)
+	defer func() {
+		span.SetAttributes(attrTokens.Int(response.Tokens))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		metrics.LLMRequestDuration.WithLabelValues(req.Operation, metrics.Outcome(err)).Observe(time.Since(start).Seconds())
+	}()
+
+	if c.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.options.Timeout)
+		defer cancel()
+	}
+
+	messages := make([]openai.ChatCompletionMessage, 0, 2)
+	if req.System != "" {
+		messages = append(messages, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleSystem, Content: req.System})
+	}
+	messages = append(messages, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: req.Prompt})
+
+	completion := openai.ChatCompletionRequest{
+		Model:       c.options.Model,
+		Messages:    messages,
+		Temperature: c.options.Temperature,
+		MaxTokens:   c.options.MaxTokens,
+	}
+	if req.JSON {
+		completion.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	}
+
+	answer, err := c.client.CreateChatCompletion(ctx, completion)
+	if err != nil {
+		return response, fmt.Errorf("openai: %w", err)
+	}
+	response.Tokens = answer.Usage.TotalTokens
+	if len(answer.Choices) == 0 {
+		return response, errors.New("openai: no choices in the answer")
+	}
+	response.Text = answer.Choices[0].Message.Content
+	return response, nil
 }
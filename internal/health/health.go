@@ -0,0 +1,198 @@
+// Package health serves the liveness and readiness probes of the service.
+// Liveness only tells that the process serves requests. Readiness tells a load
+// balancer whether to route new interviews to the instance: its dependencies
+// are usable, it is not draining and it has room for more sessions
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Statuses of a readiness report and of its checks
+const (
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+	StatusOK       = "ok"
+	StatusFailing  = "failing"
+)
+
+// DefaultTimeout bounds each probe of a readiness check
+const DefaultTimeout = 2 * time.Second
+
+// Probe checks that a dependency is usable
+type Probe func(ctx context.Context) error
+
+// Check is a named probe. Every check must pass for the instance to be ready
+type Check struct {
+	Name  string
+	Probe Probe
+
+	// Interval reuses the result of the probe for this long, for providers
+	// that are slow, rate limited or billed. Zero probes on every request
+	Interval time.Duration
+}
+
+// Options configures a Checker
+type Options struct {
+	Checks  []Check
+	Timeout time.Duration // Of each probe, defaults to DefaultTimeout
+
+	Load     func() (active, capacity int) // Sessions on the instance and the cap on them, 0 if none
+	Draining func() bool                   // Whether the instance is shutting down
+}
+
+// CheckResult is the outcome of a check
+type CheckResult struct {
+	Status    string    `json:"status"` // ok or failing
+	Error     string    `json:"error,omitempty"`
+	LatencyMS int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Load is the session load of the instance
+type Load struct {
+	ActiveSessions int     `json:"active_sessions"`
+	MaxSessions    int     `json:"max_sessions"` // 0 if there is no cap
+	Utilization    float64 `json:"utilization"`  // Share of the cap in use, 0 if there is none
+}
+
+// Report is the body of a readiness response
+type Report struct {
+	Status   string                 `json:"status"`            // ready or not_ready
+	Reasons  []string               `json:"reasons,omitempty"` // Why the instance is not ready
+	Draining bool                   `json:"draining"`
+	Load     Load                   `json:"load"`
+	Checks   map[string]CheckResult `json:"checks"`
+}
+
+// Checker runs the readiness checks of the instance
+type Checker struct {
+	checks   []*cachedCheck
+	timeout  time.Duration
+	load     func() (active, capacity int)
+	draining func() bool
+}
+
+// cachedCheck is a check with its last result
+type cachedCheck struct {
+	Check
+	mu   sync.Mutex // Held while probing, so concurrent requests share a probe
+	last *CheckResult
+}
+
+// NewChecker creates a checker
+func NewChecker(opts Options) *Checker {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	c := &Checker{
+		timeout:  opts.Timeout,
+		load:     opts.Load,
+		draining: opts.Draining,
+	}
+	for _, check := range opts.Checks {
+		c.checks = append(c.checks, &cachedCheck{Check: check})
+	}
+	return c
+}
+
+// Ready runs the checks and reports whether the instance takes new sessions
+func (c *Checker) Ready(ctx context.Context) Report {
+	report := Report{Checks: make(map[string]CheckResult, len(c.checks))}
+
+	results := make([]CheckResult, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = check.run(ctx, c.timeout)
+		}()
+	}
+	wg.Wait()
+
+	for i, check := range c.checks {
+		report.Checks[check.Name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Reasons = append(report.Reasons, fmt.Sprintf("%s: %s", check.Name, results[i].Error))
+		}
+	}
+
+	if c.draining != nil && c.draining() {
+		report.Draining = true
+		report.Reasons = append(report.Reasons, "draining")
+	}
+
+	if c.load != nil {
+		active, capacity := c.load()
+		report.Load = Load{ActiveSessions: active, MaxSessions: capacity}
+		if capacity > 0 {
+			report.Load.Utilization = float64(active) / float64(capacity)
+			if active >= capacity {
+				report.Reasons = append(report.Reasons, "at capacity")
+			}
+		}
+	}
+
+	report.Status = StatusReady
+	if len(report.Reasons) > 0 {
+		report.Status = StatusNotReady
+	}
+	return report
+}
+
+// run probes the check unless its last result is recent enough
+func (c *cachedCheck) run(ctx context.Context, timeout time.Duration) CheckResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.last != nil && c.Interval > 0 && time.Since(c.last.CheckedAt) < c.Interval {
+		return *c.last
+	}
+
+	// Other requests may share the result, so the probe outlives the request
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.Probe(ctx)
+	result := CheckResult{
+		Status:    StatusOK,
+		LatencyMS: time.Since(start).Milliseconds(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		result.Status = StatusFailing
+		result.Error = err.Error()
+	}
+	c.last = &result
+	return result
+}
+
+// ServeLive answers liveness probes. The process serving the request is all
+// it checks, so an instance with failing dependencies is not restarted
+func (c *Checker) ServeLive(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"status": StatusOK})
+}
+
+// ServeReady answers readiness probes with the report, with a 503 status if
+// the instance should not get new sessions
+func (c *Checker) ServeReady(w http.ResponseWriter, r *http.Request) {
+	report := c.Ready(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status == StatusReady {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,97 @@
+package limits
+
+import (
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often buckets that refilled completely are dropped, so
+// clients that stopped creating sessions are forgotten
+const pruneInterval = time.Minute
+
+// bucket is a token bucket
+type bucket struct {
+	tokens float64
+	last   time.Time // When tokens was last refilled
+}
+
+// keyedBuckets is a token bucket per key, all with the same rate
+type keyedBuckets struct {
+	rate      Rate
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+func newKeyedBuckets(rate Rate) *keyedBuckets {
+	return &keyedBuckets{
+		rate:    rate,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// take takes a token from key's bucket. If it is empty, take returns how
+// long until a token is available
+func (k *keyedBuckets) take(key string, now time.Time) (time.Duration, bool) {
+	if !k.rate.enabled() {
+		return 0, true
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if now.Sub(k.lastPrune) > pruneInterval {
+		k.prune(now)
+	}
+
+	capacity := k.rate.capacity()
+	b, exists := k.buckets[key]
+	if !exists {
+		b = &bucket{tokens: capacity, last: now}
+		k.buckets[key] = b
+	}
+	b.tokens = k.refill(b, now)
+	b.last = now
+
+	if b.tokens < 1 {
+		perToken := time.Duration(float64(time.Minute) / k.rate.PerMinute)
+		return time.Duration((1 - b.tokens) * float64(perToken)), false
+	}
+	b.tokens--
+	return 0, true
+}
+
+// refund returns a token taken from key's bucket
+func (k *keyedBuckets) refund(key string) {
+	if !k.rate.enabled() {
+		return
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if b, exists := k.buckets[key]; exists {
+		b.tokens = min(b.tokens+1, k.rate.capacity())
+	}
+}
+
+// refill returns the tokens in b at now
+func (k *keyedBuckets) refill(b *bucket, now time.Time) float64 {
+	elapsed := now.Sub(b.last)
+	if elapsed <= 0 {
+		return b.tokens
+	}
+	return min(k.rate.capacity(), b.tokens+elapsed.Minutes()*k.rate.PerMinute)
+}
+
+// prune drops full buckets, which behave like missing ones. Caller must hold
+// k.mu
+func (k *keyedBuckets) prune(now time.Time) {
+	capacity := k.rate.capacity()
+	for key, b := range k.buckets {
+		if k.refill(b, now) >= capacity {
+			delete(k.buckets, key)
+		}
+	}
+	k.lastPrune = now
+}
@@ -0,0 +1,77 @@
+package limits
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Spend is what a session spends on paid services. As a budget, zero fields
+// are unlimited
+type Spend struct {
+	Audio         time.Duration // Candidate audio streamed to speech recognition
+	LLMTokens     int           // Prompt and completion tokens
+	TTSCharacters int           // Characters of interviewer speech synthesized
+}
+
+// Meter tracks a session's spend against its budget. Charges are recorded
+// even when they go over, since the service was already used; once the budget
+// is exhausted every charge fails
+type Meter struct {
+	budget Spend
+	mu     sync.Mutex
+	used   Spend
+}
+
+// NewMeter returns a meter for a session that has already spent used
+func NewMeter(budget, used Spend) *Meter {
+	return &Meter{budget: budget, used: used}
+}
+
+// ChargeAudio records audio sent to speech recognition
+func (m *Meter) ChargeAudio(d time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.used.Audio += d
+	if m.budget.Audio > 0 && m.used.Audio > m.budget.Audio {
+		return budgetError(fmt.Sprintf("the session used its %s of audio", m.budget.Audio))
+	}
+	return nil
+}
+
+// ChargeLLMTokens records tokens used by the language model
+func (m *Meter) ChargeLLMTokens(n int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.used.LLMTokens += n
+	if m.budget.LLMTokens > 0 && m.used.LLMTokens > m.budget.LLMTokens {
+		return budgetError(fmt.Sprintf("the session used its %d language model tokens", m.budget.LLMTokens))
+	}
+	return nil
+}
+
+// ChargeTTSCharacters records characters sent to speech synthesis
+func (m *Meter) ChargeTTSCharacters(n int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.used.TTSCharacters += n
+	if m.budget.TTSCharacters > 0 && m.used.TTSCharacters > m.budget.TTSCharacters {
+		return budgetError(fmt.Sprintf("the session used its %d speech synthesis characters", m.budget.TTSCharacters))
+	}
+	return nil
+}
+
+// Used returns what the session has spent
+func (m *Meter) Used() Spend {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.used
+}
+
+// budgetError returns the error for an exhausted budget
+func budgetError(message string) *Error {
+	return &Error{Code: CodeBudgetExceeded, Scope: ScopeSession, Message: message}
+}
@@ -0,0 +1,164 @@
+// Package limits protects the paid services behind each session from runaway
+// clients: token bucket rate limits on new sessions, caps on concurrent
+// sessions and spend budgets per session
+package limits
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Scopes a limit applies to
+const (
+	ScopeUser     = "user"
+	ScopeIP       = "ip"
+	ScopeGlobal   = "global"
+	ScopeInstance = "instance"
+	ScopeSession  = "session"
+)
+
+// Error codes, also used in client error messages
+const (
+	CodeRateLimited     = "rate_limited"
+	CodeTooManySessions = "too_many_sessions"
+	CodeBudgetExceeded  = "budget_exceeded"
+)
+
+// Error reports a request refused by a limit
+type Error struct {
+	Code       string
+	Scope      string
+	Message    string
+	RetryAfter time.Duration // When the request may succeed, 0 if unknown
+}
+
+func (e *Error) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s, retry in %ds", e.Message, e.RetryAfterSeconds())
+	}
+	return e.Message
+}
+
+// RetryAfterSeconds returns RetryAfter rounded up to whole seconds, as sent in
+// a Retry-After header
+func (e *Error) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// Rate is a token bucket: Burst sessions may be created at once, and the
+// bucket refills at PerMinute. A zero PerMinute disables the limit
+type Rate struct {
+	PerMinute float64
+	Burst     int // Defaults to PerMinute, and is at least 1
+}
+
+// enabled reports whether the rate limits anything
+func (r Rate) enabled() bool {
+	return r.PerMinute > 0
+}
+
+// capacity returns the bucket size
+func (r Rate) capacity() float64 {
+	if r.Burst > 0 {
+		return float64(r.Burst)
+	}
+	return math.Max(1, math.Floor(r.PerMinute))
+}
+
+// Options configures a Limiter. Zero values disable a limit
+type Options struct {
+	PerUser Rate // New sessions of one authenticated user
+	PerIP   Rate // New sessions from one client IP
+	Global  Rate // New sessions on the instance
+
+	MaxSessionsPerUser int // Concurrent active sessions of one authenticated user, on this instance
+	MaxSessions        int // Concurrent active sessions on the instance
+
+	Budget Spend // What a session may spend
+}
+
+// Limiter admits new sessions
+type Limiter struct {
+	opts    Options
+	perUser *keyedBuckets
+	perIP   *keyedBuckets
+	global  *keyedBuckets
+}
+
+// New creates a limiter
+func New(opts Options) *Limiter {
+	return &Limiter{
+		opts:    opts,
+		perUser: newKeyedBuckets(opts.PerUser),
+		perIP:   newKeyedBuckets(opts.PerIP),
+		global:  newKeyedBuckets(opts.Global),
+	}
+}
+
+// AllowCreate takes a token for a new session from the buckets of user, ip
+// and the instance, or returns an *Error naming the first that is empty. An
+// empty user or ip skips that limit
+func (l *Limiter) AllowCreate(user, ip string, now time.Time) error {
+	type step struct {
+		buckets *keyedBuckets
+		key     string
+		scope   string
+		message string
+	}
+	steps := []step{
+		{l.perUser, user, ScopeUser, "too many new sessions for this user"},
+		{l.perIP, ip, ScopeIP, "too many new sessions from this address"},
+		{l.global, ScopeGlobal, ScopeGlobal, "the service is creating too many sessions"},
+	}
+
+	for i, s := range steps {
+		if s.key == "" {
+			continue
+		}
+		wait, ok := s.buckets.take(s.key, now)
+		if ok {
+			continue
+		}
+		// Give back what the earlier buckets granted
+		for _, taken := range steps[:i] {
+			if taken.key != "" {
+				taken.buckets.refund(taken.key)
+			}
+		}
+		return &Error{Code: CodeRateLimited, Scope: s.scope, Message: s.message, RetryAfter: wait}
+	}
+	return nil
+}
+
+// CheckActive checks the concurrent session caps against the sessions
+// already active for the user and on the instance. An empty user skips the
+// per-user cap
+func (l *Limiter) CheckActive(user string, userActive, instanceActive int) error {
+	if l.opts.MaxSessions > 0 && instanceActive >= l.opts.MaxSessions {
+		return &Error{
+			Code:    CodeTooManySessions,
+			Scope:   ScopeInstance,
+			Message: "the service is at capacity",
+		}
+	}
+	if user != "" && l.opts.MaxSessionsPerUser > 0 && userActive >= l.opts.MaxSessionsPerUser {
+		return &Error{
+			Code:    CodeTooManySessions,
+			Scope:   ScopeUser,
+			Message: fmt.Sprintf("at most %d active sessions per user, close one first", l.opts.MaxSessionsPerUser),
+		}
+	}
+	return nil
+}
+
+// MaxSessions returns the cap on concurrent sessions on the instance, 0 if
+// there is none
+func (l *Limiter) MaxSessions() int {
+	return l.opts.MaxSessions
+}
+
+// NewMeter returns a meter for a session that has already spent used
+func (l *Limiter) NewMeter(used Spend) *Meter {
+	return NewMeter(l.opts.Budget, used)
+}
@@ -0,0 +1,151 @@
+// Package logging builds the service's structured logger. Pipeline components
+// take a *slog.Logger tagged with the session they serve, so every line can be
+// traced back to a session, its lesson and its AssemblyAI session
+package logging
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Attribute keys shared by all components
+const (
+	KeySession    = "session_id"
+	KeyLesson     = "lesson_id"
+	KeyAssemblyAI = "assemblyai_id"
+	KeyError      = "error"
+
+	// KeyTranscript holds candidate speech. Its value is rewritten according
+	// to Options.Transcripts, so transcript text must only be logged under it
+	KeyTranscript = "transcript"
+)
+
+// How transcript text is logged
+const (
+	TranscriptsHash   = "hash"   // A short SHA-256 prefix, enough to match repeated text
+	TranscriptsRedact = "redact" // Nothing but a placeholder
+	TranscriptsPlain  = "plain"  // The text itself, for development only
+)
+
+// Options configures a logger
+type Options struct {
+	Level       string // debug, info, warn or error
+	Format      string // text or json
+	Transcripts string // hash, redact or plain (defaults to hash)
+}
+
+// New creates a logger writing to w
+func New(w io.Writer, opts Options) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(opts.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", opts.Level)
+	}
+	if opts.Transcripts == "" {
+		opts.Transcripts = TranscriptsHash
+	}
+	if opts.Transcripts != TranscriptsHash && opts.Transcripts != TranscriptsRedact && opts.Transcripts != TranscriptsPlain {
+		return nil, fmt.Errorf("invalid transcript logging %q", opts.Transcripts)
+	}
+
+	handlerOptions := &slog.HandlerOptions{Level: level}
+	if opts.Transcripts != TranscriptsPlain {
+		handlerOptions.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == KeyTranscript && a.Value.Kind() == slog.KindString {
+				a.Value = slog.StringValue(conceal(a.Value.String(), opts.Transcripts))
+			}
+			return a
+		}
+	}
+
+	switch opts.Format {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, handlerOptions)), nil
+	case "text", "":
+		return slog.New(slog.NewTextHandler(w, handlerOptions)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q", opts.Format)
+	}
+}
+
+// conceal rewrites transcript text for the logs
+func conceal(text, mode string) string {
+	if text == "" {
+		return ""
+	}
+	if mode == TranscriptsRedact {
+		return "[redacted]"
+	}
+	sum := sha256.Sum256([]byte(text))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// Transcript returns an attribute holding candidate speech
+func Transcript(text string) slog.Attr {
+	return slog.String(KeyTranscript, text)
+}
+
+// Err returns an attribute holding an error
+func Err(err error) slog.Attr {
+	return slog.Any(KeyError, err)
+}
+
+// OrDefault returns logger, or the default logger if it is nil
+func OrDefault(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+	return logger
+}
+
+// Tag is an attribute whose value changes over the life of the loggers it is
+// added to, such as the AssemblyAI session of a session that reconnects
+type Tag struct {
+	key   string
+	value atomic.Pointer[string]
+}
+
+// NewTag creates a tag with an empty value
+func NewTag(key string) *Tag {
+	t := &Tag{key: key}
+	t.Set("")
+	return t
+}
+
+// Set changes the value of the tag
+func (t *Tag) Set(value string) {
+	t.value.Store(&value)
+}
+
+// Value returns the current value of the tag
+func (t *Tag) Value() string {
+	return *t.value.Load()
+}
+
+// WithTag returns a logger adding the current value of tag to every line
+func WithTag(logger *slog.Logger, tag *Tag) *slog.Logger {
+	return slog.New(tagHandler{Handler: OrDefault(logger).Handler(), tag: tag})
+}
+
+// tagHandler adds a tag to the records of a handler
+type tagHandler struct {
+	slog.Handler
+	tag *Tag
+}
+
+func (h tagHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(slog.String(h.tag.key, h.tag.Value()))
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h tagHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return tagHandler{Handler: h.Handler.WithAttrs(attrs), tag: h.tag}
+}
+
+func (h tagHandler) WithGroup(name string) slog.Handler {
+	return tagHandler{Handler: h.Handler.WithGroup(name), tag: h.tag}
+}
@@ -0,0 +1,156 @@
+// Package metrics defines the Prometheus metrics of the interview pipeline,
+// served at /metrics. Ratios such as the VAD speech ratio are left to queries
+// over the counters
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric
+const namespace = "callservice"
+
+// Outcomes of calls to upstream services
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// latencyBuckets are the histogram buckets of upstream calls and pipeline
+// latencies, in seconds
+var latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 0.75, 1, 1.5, 2, 3, 5, 10, 30}
+
+// Client connections
+var (
+	ClientConnects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "client_connects_total",
+		Help:      "Candidate connections to a session, by transport.",
+	}, []string{"transport"})
+
+	ClientDisconnects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "client_disconnects_total",
+		Help:      "Candidate disconnections from a session, by transport.",
+	}, []string{"transport"})
+)
+
+// Audio
+var (
+	AudioBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audio_bytes_total",
+		Help:      "Audio received from candidates (in) and interviewer audio sent to them (out).",
+	}, []string{"direction"})
+
+	VADAudioSeconds = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "vad_audio_seconds_total",
+		Help:      "Candidate audio classified by voice activity detection, by result (speech or silence).",
+	}, []string{"result"})
+)
+
+// Speech recognition
+var (
+	STTConnectDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "stt_connect_duration_seconds",
+		Help:      "Time to open an AssemblyAI streaming connection, retries included, by outcome.",
+		Buckets:   latencyBuckets,
+	}, []string{"outcome"})
+
+	STTReconnects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stt_reconnects_total",
+		Help:      "AssemblyAI reconnections, by cause.",
+	}, []string{"cause"})
+
+	STTErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stt_errors_total",
+		Help:      "AssemblyAI errors, by cause.",
+	}, []string{"cause"})
+
+	STTFinalLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "stt_final_latency_seconds",
+		Help:      "Time from the last candidate speech to its final transcript.",
+		Buckets:   latencyBuckets,
+	})
+)
+
+// Causes of speech recognition errors and reconnections
+const (
+	STTCauseConnect = "connect"
+	STTCauseSend    = "send"
+	STTCauseRead    = "read"
+	STTCauseParse   = "parse"
+	STTCauseServer  = "server"
+)
+
+// Interviewer
+var (
+	LLMRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "llm_request_duration_seconds",
+		Help:      "Language model request latency, by operation and outcome.",
+		Buckets:   latencyBuckets,
+	}, []string{"operation", "outcome"})
+
+	TTSRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tts_request_duration_seconds",
+		Help:      "Speech synthesis request latency, by outcome.",
+		Buckets:   latencyBuckets,
+	}, []string{"outcome"})
+
+	FirstInterviewerAudio = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "first_interviewer_audio_seconds",
+		Help:      "Time from the end of candidate speech to the first interviewer audio sent back.",
+		Buckets:   latencyBuckets,
+	})
+
+	GradingDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grading_decisions_total",
+		Help:      "Answers graded, by decision.",
+	}, []string{"decision"})
+)
+
+// Sessions
+var (
+	// Sessions describes the sessions held by an instance, collected on scrape
+	Sessions = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "sessions"),
+		"Sessions held by this instance, by status.",
+		[]string{"status"}, nil)
+
+	SessionsReaped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sessions_reaped_total",
+		Help:      "Sessions terminated by the reaper, by reason.",
+	}, []string{"reason"})
+)
+
+// Outcome returns the outcome label of a call that returned err
+func Outcome(err error) string {
+	if err != nil {
+		return OutcomeError
+	}
+	return OutcomeSuccess
+}
+
+// MustRegister registers collectors, such as gauges computed on scrape
+func MustRegister(collectors ...prometheus.Collector) {
+	prometheus.MustRegister(collectors...)
+}
+
+// Handler serves the metrics
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,88 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/torteous44/callservice/internal/analytics"
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/sessionstate"
+)
+
+// analyticsFlushTimeout bounds a session flush including retries
+const analyticsFlushTimeout = 2 * time.Minute
+
+// finalizeSession flushes a closed session to analytics and then deletes its
+// state from the backend. If the flush fails the state is kept until its TTL
+// expires so the data is not lost before someone can look at it
+func (im *InterviewManager) finalizeSession(sessionID string) {
+	if im.analytics != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), analyticsFlushTimeout)
+		err := im.flushAnalytics(ctx, sessionID)
+		cancel()
+
+		if err != nil {
+			im.sessionLogger(sessionID).Error("Failed to flush analytics, keeping state until TTL", logging.Err(err))
+			return
+		}
+		im.sessionLogger(sessionID).Info("Flushed analytics")
+	}
+
+	im.deletePersistedSession(sessionID)
+}
+
+// flushAnalytics flattens a session from the backend and writes it to the analytics sink
+func (im *InterviewManager) flushAnalytics(ctx context.Context, sessionID string) error {
+	record, err := im.backend.LoadSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+
+	events, err := sessionstate.LoadEvents(ctx, im.backend, sessionID, 0)
+	if err != nil {
+		return fmt.Errorf("failed to load events: %w", err)
+	}
+
+	meta := analytics.SessionMeta{
+		SessionID:       record.ID,
+		Status:          record.Status,
+		StartedAt:       record.StartTime,
+		EndedAt:         record.UpdatedAt,
+		TranscriptCount: record.TranscriptCount,
+		UtteranceCount:  record.UtteranceCount,
+		FinalState:      sessionstate.DefaultInterviewState(),
+	}
+	if record.State != nil {
+		meta.FinalState = *record.State
+	}
+
+	if len(record.Lesson) > 0 {
+		var bundle lessonBundle
+		if err := json.Unmarshal(record.Lesson, &bundle); err != nil {
+			return fmt.Errorf("failed to unmarshal lesson: %w", err)
+		}
+		if bundle.Lesson != nil {
+			meta.LessonID = bundle.Lesson.LessonID
+			meta.CaseID = bundle.Lesson.CaseID
+		}
+		for i, question := range bundle.Questions {
+			if question == nil {
+				continue
+			}
+			meta.Questions = append(meta.Questions, analytics.Question{
+				Index:  i,
+				ID:     question.QuestionID,
+				Prompt: question.QuestionPrompt,
+			})
+		}
+	}
+
+	report, err := analytics.Flatten(meta, events)
+	if err != nil {
+		return err
+	}
+
+	return analytics.Flush(ctx, im.analytics, report, analytics.DefaultFlushAttempts)
+}
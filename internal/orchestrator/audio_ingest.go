@@ -1,17 +1,256 @@
 package orchestrator
 
-// AudioIngest handles incoming audio data processing
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/torteous44/callservice/pkg/transport"
+)
+
+// Ingest defaults
+const (
+	defaultIngestQueueSize     = 32
+	defaultMinFrameDuration    = 10 * time.Millisecond
+	defaultMaxFrameDuration    = time.Second
+	defaultBackpressureTimeout = 2 * time.Second
+)
+
+// AudioFrame is a validated, sequenced chunk of client audio
+type AudioFrame struct {
+	Seq        uint64        // Server-assigned sequence number, contiguous per connection
+	ClientSeq  uint32        // Client sequence number, only set for sequenced audio
+	CapturedAt time.Time     // Client capture time, only set for sequenced audio
+	ReceivedAt time.Time     // When the frame was read from the connection
+	Offset     time.Duration // Media time of the first sample since the stream started
+	Duration   time.Duration // Media duration of the frame
+	Data       []byte
+}
+
+// ControlHandler receives control messages routed out of the ingest. It reports
+// true when the client asked to stop streaming
+type ControlHandler func(msg transport.Message) (stop bool)
+
+// IngestConfig configures an AudioIngest
+type IngestConfig struct {
+	Format              transport.AudioFormat // Negotiated audio format
+	Sequenced           bool                  // Binary frames carry a transport.AudioHeaderSize header
+	QueueSize           int                   // Bounded queue between the connection and the pipeline
+	MinFrameDuration    time.Duration         // Shorter frames are rejected
+	MaxFrameDuration    time.Duration         // Longer frames are rejected
+	BackpressureTimeout time.Duration         // How long a full queue may block the reader before frames are dropped
+}
+
+// IngestStats counts what happened to incoming audio
+type IngestStats struct {
+	Frames    uint64 `json:"frames"`
+	Bytes     uint64 `json:"bytes"`
+	Rejected  uint64 `json:"rejected"`  // Invalid frames
+	Dropped   uint64 `json:"dropped"`   // Valid frames dropped because the pipeline was backed up
+	Gaps      uint64 `json:"gaps"`      // Missing client sequence numbers
+	Reordered uint64 `json:"reordered"` // Late or duplicate client frames, dropped
+}
+
+// AudioError is returned for an audio frame that was rejected. The connection
+// is still usable and the error should be reported to the client
+type AudioError struct {
+	Message string
+}
+
+func (e *AudioError) Error() string {
+	return e.Message
+}
+
+// AudioIngest demultiplexes client frames, validates and sequences audio and
+// feeds it to the audio pipeline through a bounded queue
 type AudioIngest struct {
-	// TODO: Add audio ingest implementation fields
+	config     IngestConfig
+	onControl  ControlHandler
+	frameBytes int // Bytes per sample across all channels
+	frames     chan AudioFrame
+	nextSeq    uint64
+	nextClient uint32
+	haveClient bool
+	samples    int64 // Samples received so far, for media timestamps
+	stats      IngestStats
+	statsMu    sync.Mutex
+	closeOnce  sync.Once
 }
 
 // NewAudioIngest creates a new audio ingest handler
-func NewAudioIngest() *AudioIngest {
-	return &AudioIngest{}
+func NewAudioIngest(config IngestConfig, onControl ControlHandler) (*AudioIngest, error) {
+	frameBytes, err := bytesPerSample(config.Format.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	if config.Format.SampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate %d", config.Format.SampleRate)
+	}
+	if config.Format.Channels <= 0 {
+		config.Format.Channels = 1
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultIngestQueueSize
+	}
+	if config.MinFrameDuration <= 0 {
+		config.MinFrameDuration = defaultMinFrameDuration
+	}
+	if config.MaxFrameDuration <= 0 {
+		config.MaxFrameDuration = defaultMaxFrameDuration
+	}
+	if config.BackpressureTimeout <= 0 {
+		config.BackpressureTimeout = defaultBackpressureTimeout
+	}
+
+	return &AudioIngest{
+		config:     config,
+		onControl:  onControl,
+		frameBytes: frameBytes * config.Format.Channels,
+		frames:     make(chan AudioFrame, config.QueueSize),
+	}, nil
+}
+
+// bytesPerSample returns the size of one sample of an encoding
+func bytesPerSample(encoding string) (int, error) {
+	switch encoding {
+	case "pcm_s16le":
+		return 2, nil
+	case "pcm_mulaw":
+		return 1, nil
+	default:
+		return 0, fmt.Errorf("unsupported audio encoding %q", encoding)
+	}
+}
+
+// ProcessFrame routes a client frame: control messages go to the control
+// handler, audio goes to ProcessAudio. It reports true when the client asked
+// to stop streaming
+func (a *AudioIngest) ProcessFrame(ctx context.Context, frame transport.Frame) (bool, error) {
+	switch frame.Kind {
+	case transport.FrameControl:
+		if a.onControl == nil {
+			return false, nil
+		}
+		return a.onControl(frame.Message), nil
+	case transport.FrameAudio:
+		return false, a.ProcessAudio(ctx, frame.Audio)
+	default:
+		return false, fmt.Errorf("unknown frame kind %d", frame.Kind)
+	}
+}
+
+// ProcessAudio validates, sequences and timestamps an audio frame and queues it
+// for the pipeline. A full queue blocks the caller, which pushes back on the
+// client connection, for up to BackpressureTimeout before the frame is dropped
+func (a *AudioIngest) ProcessAudio(ctx context.Context, audioData []byte) error {
+	now := time.Now()
+	frame := AudioFrame{ReceivedAt: now}
+
+	if a.config.Sequenced {
+		if len(audioData) < transport.AudioHeaderSize {
+			return a.reject("audio frame is shorter than the sequence header")
+		}
+		frame.ClientSeq = binary.BigEndian.Uint32(audioData[0:4])
+		frame.CapturedAt = time.UnixMilli(int64(binary.BigEndian.Uint64(audioData[4:12])))
+		audioData = audioData[transport.AudioHeaderSize:]
+
+		if !a.checkClientSeq(frame.ClientSeq) {
+			return nil
+		}
+	}
+
+	if len(audioData) == 0 || len(audioData)%a.frameBytes != 0 {
+		return a.reject(fmt.Sprintf("audio frame of %d bytes is not a whole number of %s samples",
+			len(audioData), a.config.Format.Encoding))
+	}
+
+	samples := int64(len(audioData) / a.frameBytes)
+	frame.Duration = time.Duration(samples) * time.Second / time.Duration(a.config.Format.SampleRate)
+	if frame.Duration < a.config.MinFrameDuration || frame.Duration > a.config.MaxFrameDuration {
+		return a.reject(fmt.Sprintf("audio frame of %s is outside %s-%s",
+			frame.Duration, a.config.MinFrameDuration, a.config.MaxFrameDuration))
+	}
+
+	frame.Seq = a.nextSeq
+	frame.Offset = time.Duration(a.samples) * time.Second / time.Duration(a.config.Format.SampleRate)
+	frame.Data = audioData
+	a.nextSeq++
+	a.samples += samples
+
+	a.statsMu.Lock()
+	a.stats.Frames++
+	a.stats.Bytes += uint64(len(audioData))
+	a.statsMu.Unlock()
+
+	select {
+	case a.frames <- frame:
+		return nil
+	default:
+	}
+
+	timer := time.NewTimer(a.config.BackpressureTimeout)
+	defer timer.Stop()
+
+	select {
+	case a.frames <- frame:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		a.statsMu.Lock()
+		a.stats.Dropped++
+		a.statsMu.Unlock()
+		return &AudioError{Message: fmt.Sprintf("audio pipeline is backed up, dropped frame %d", frame.Seq)}
+	}
+}
+
+// checkClientSeq tracks client sequence numbers. Gaps are counted and the
+// frame is accepted; late and duplicate frames are dropped since recognition
+// cannot rewind
+func (a *AudioIngest) checkClientSeq(seq uint32) bool {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+
+	if a.haveClient {
+		switch {
+		case seq < a.nextClient:
+			a.stats.Reordered++
+			return false
+		case seq > a.nextClient:
+			a.stats.Gaps += uint64(seq - a.nextClient)
+		}
+	}
+
+	a.haveClient = true
+	a.nextClient = seq + 1
+	return true
+}
+
+// reject counts an invalid frame and returns the error to report
+func (a *AudioIngest) reject(message string) error {
+	a.statsMu.Lock()
+	a.stats.Rejected++
+	a.statsMu.Unlock()
+	return &AudioError{Message: message}
+}
+
+// Frames returns the queue of validated frames for the pipeline
+func (a *AudioIngest) Frames() <-chan AudioFrame {
+	return a.frames
+}
+
+// Stats returns a snapshot of the ingest counters
+func (a *AudioIngest) Stats() IngestStats {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+	return a.stats
 }
 
-// ProcessAudio processes incoming audio data
-func (a *AudioIngest) ProcessAudio(audioData []byte) error {
-	// TODO: Implement audio processing logic
-	return nil
+// Close ends the frame queue. ProcessAudio must not be called afterwards
+func (a *AudioIngest) Close() {
+	a.closeOnce.Do(func() {
+		close(a.frames)
+	})
 }
@@ -0,0 +1,384 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/torteous44/callservice/internal/audio/mulaw"
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/metrics"
+	"github.com/torteous44/callservice/internal/sessionstate"
+	"github.com/torteous44/callservice/pkg/transport"
+)
+
+// pipelineQueueSize bounds the queues between audio pipeline stages
+const pipelineQueueSize = 32
+
+// Utterance detection and STT recovery settings
+const (
+	maxUtteranceDuration = 30 * time.Second        // Maximum duration for a single utterance
+	minUtteranceDuration = 500 * time.Millisecond  // Minimum duration to consider as valid utterance
+	silenceCheckInterval = 100 * time.Millisecond  // How often to check silence duration
+	maxSilenceCount      = 12                      // Number of silence intervals before ending utterance
+	reconnectDelay       = 1000 * time.Millisecond // Delay before reconnecting (for errors only)
+	maxReconnectAttempts = 3
+)
+
+// segmentRecord is a unit of work for the recorder stage: a voiced frame, or
+// the start or end of a speech segment
+type segmentRecord struct {
+	event    sessionstate.EventType // EventVADSpeechStart, EventVADSpeechEnd or "" for a frame
+	frame    AudioFrame
+	reason   string
+	duration time.Duration
+}
+
+// readFrames reads client frames into the ingest until the client stops or
+// the connection fails
+func (im *InterviewManager) readFrames(session *InterviewSession, conn *transport.Conn, ingest *AudioIngest) {
+	for {
+		select {
+		case <-session.ctx.Done():
+			return
+		default:
+		}
+
+		frame, err := conn.ReadFrame()
+		if err != nil {
+			var protocolErr *transport.ProtocolError
+			if errors.As(err, &protocolErr) {
+				session.log.Warn("Rejected message", logging.Err(err))
+				conn.SendError(protocolErr.Code, protocolErr.Message)
+				continue
+			}
+			if transport.IsCloseError(err) {
+				session.log.Info("WebSocket closed normally")
+				return
+			}
+			session.log.Error("Error reading WebSocket message", logging.Err(err))
+			return
+		}
+		session.touch()
+
+		if frame.Kind == transport.FrameAudio && session.muted.Load() {
+			continue
+		}
+
+		stop, err := ingest.ProcessFrame(session.ctx, frame)
+		if err != nil {
+			var audioErr *AudioError
+			if errors.As(err, &audioErr) {
+				session.log.Warn("Rejected audio", logging.Err(err))
+				conn.SendError(transport.ErrorCodeAudio, audioErr.Message)
+				continue
+			}
+			return
+		}
+		if stop {
+			return
+		}
+	}
+}
+
+// runAudioPipeline feeds ingested frames through VAD, STT and the recorder.
+// Each stage runs in its own goroutine behind a bounded queue, so a slow stage
+// backs up into the ingest queue and from there onto the client connection.
+// It returns once frames is closed and every stage has drained
+func (im *InterviewManager) runAudioPipeline(session *InterviewSession, frames <-chan AudioFrame) {
+	voiced := make(chan AudioFrame, pipelineQueueSize)
+	records := make(chan segmentRecord, pipelineQueueSize)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		im.runSTTStage(session, voiced)
+	}()
+	go func() {
+		defer wg.Done()
+		im.runRecorderStage(session, records)
+	}()
+
+	im.runVADStage(session, frames, voiced, records)
+	close(voiced)
+	close(records)
+	wg.Wait()
+}
+
+// runVADStage detects speech in each frame, forwards voiced frames to STT and
+// tracks utterance boundaries
+func (im *InterviewManager) runVADStage(session *InterviewSession, frames <-chan AudioFrame,
+	voiced chan<- AudioFrame, records chan<- segmentRecord) {
+
+	// The VAD measures the energy of 16-bit PCM
+	session.mu.RLock()
+	mulawAudio := session.StreamingSTT.GetConfig().Encoding == "pcm_mulaw"
+	session.mu.RUnlock()
+
+	var lastVoiceTime time.Time
+	var continuousSilenceCount int
+	inUtterance := false
+	utteranceStartTime := time.Time{}
+
+	// Sends block while the next stage is backed up
+	sendVoiced := func(frame AudioFrame) bool {
+		select {
+		case voiced <- frame:
+			return true
+		case <-session.ctx.Done():
+			return false
+		}
+	}
+	sendRecord := func(record segmentRecord) bool {
+		select {
+		case records <- record:
+			return true
+		case <-session.ctx.Done():
+			return false
+		}
+	}
+
+	endUtterance := func(reason string, now time.Time) {
+		inUtterance = false
+		session.log.Debug("Utterance ended", "reason", reason, "duration_ms", now.Sub(utteranceStartTime).Milliseconds())
+		if reason != "disconnected" {
+			// The interviewer's answer is timed from the end of speech
+			markTime(&session.turnEndedAt, lastVoiceTime)
+			session.trace.startTurn(lastVoiceTime, reason)
+		}
+		sendRecord(segmentRecord{
+			event:    sessionstate.EventVADSpeechEnd,
+			reason:   reason,
+			duration: now.Sub(utteranceStartTime),
+		})
+	}
+
+	silenceTimer := time.NewTicker(silenceCheckInterval)
+	defer silenceTimer.Stop()
+
+	for {
+		select {
+		case <-session.ctx.Done():
+			return
+		case <-silenceTimer.C:
+			if !inUtterance {
+				continue
+			}
+			now := time.Now()
+			silenceDuration := now.Sub(lastVoiceTime)
+
+			// Only count silence after minimum utterance duration
+			if now.Sub(utteranceStartTime) <= minUtteranceDuration || silenceDuration < silenceCheckInterval {
+				continue
+			}
+
+			continuousSilenceCount++
+			if continuousSilenceCount >= maxSilenceCount {
+				endUtterance("silence", now)
+
+				// The interviewer answers the final transcript, and the recognizer
+				// keeps listening
+			}
+
+		case frame, ok := <-frames:
+			if !ok {
+				if inUtterance {
+					endUtterance("disconnected", time.Now())
+				}
+				return
+			}
+
+			if !im.chargeAudio(session, frame.Duration) {
+				return
+			}
+
+			// Let observers listen along
+			im.observers.broadcastAudio(session.ID, frame.Data)
+
+			pcm := frame.Data
+			if mulawAudio {
+				pcm = mulaw.Decode(frame.Data)
+			}
+			hasVoice, err := session.VAD.DetectActivity(pcm)
+			if err != nil {
+				session.log.Error("VAD error", logging.Err(err))
+				continue
+			}
+			recordFrame(frame, hasVoice)
+
+			now := frame.ReceivedAt
+
+			// Check for maximum utterance duration
+			if inUtterance && now.Sub(utteranceStartTime) > maxUtteranceDuration {
+				endUtterance("max_duration", now)
+
+				// The interviewer answers the final transcript, and the recognizer
+				// keeps listening
+				continue
+			}
+
+			if !hasVoice {
+				continue
+			}
+
+			lastVoiceTime = now
+			continuousSilenceCount = 0 // Reset silence counter when voice is detected
+			markTime(&session.lastSpeechAt, now)
+
+			if !inUtterance {
+				inUtterance = true
+				utteranceStartTime = now
+				session.turnEndedAt.Store(0)
+				session.log.Debug("Utterance started")
+				sendRecord(segmentRecord{event: sessionstate.EventVADSpeechStart})
+			}
+
+			if !sendVoiced(frame) || !sendRecord(segmentRecord{frame: frame}) {
+				return
+			}
+		}
+	}
+}
+
+// runSTTStage streams voiced frames to the recognizer, reconnecting on
+// connection errors. If the recognizer cannot be recovered the client is
+// disconnected and the remaining frames are discarded
+func (im *InterviewManager) runSTTStage(session *InterviewSession, voiced <-chan AudioFrame) {
+	reconnectAttempts := 0
+
+	for frame := range voiced {
+		recognizer := session.recognizer()
+		if recognizer == nil {
+			session.log.Warn("StreamingSTT is nil, skipping audio data")
+			continue
+		}
+
+		if err := recognizer.SendAudio(frame.Data); err != nil {
+			session.log.Error("Error sending audio to STT", logging.Err(err))
+
+			// Attempt to reconnect only on actual connection errors
+			if reconnectAttempts >= maxReconnectAttempts {
+				err = fmt.Errorf("exceeded maximum reconnection attempts")
+			} else {
+				reconnectAttempts++
+				err = im.reconnectSTT(session, reconnectAttempts)
+			}
+			if err != nil {
+				session.log.Error("Failed to recover STT connection", logging.Err(err))
+				im.sendToClient(session, transport.TypeError, transport.ErrorMessage{
+					Code:    transport.ErrorCodeSTT,
+					Message: "speech recognition is unavailable",
+				})
+				im.disconnectClient(session)
+				for range voiced {
+				}
+				return
+			}
+			continue
+		}
+		reconnectAttempts = 0
+	}
+}
+
+// reconnectSTT replaces the session's recognizer with a fresh connection
+func (im *InterviewManager) reconnectSTT(session *InterviewSession, attempt int) error {
+	session.log.Warn("STT connection error, reconnecting", "attempt", attempt)
+	metrics.STTReconnects.WithLabelValues(metrics.STTCauseSend).Inc()
+
+	// Wait before reconnecting to allow cleanup
+	time.Sleep(reconnectDelay)
+
+	// Close existing connection gracefully and wait for it to fully close. The
+	// new one keeps the session's audio format and any turn detection the
+	// client tuned
+	config := im.streamingConfig(0, "")
+	if old := session.recognizer(); old != nil {
+		config = old.GetConfig()
+		session.log.Info("Closing existing STT connection")
+		if err := old.Close(); err != nil {
+			session.log.Warn("Error closing STT connection", logging.Err(err))
+		}
+		// Give time for the connection to fully close
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	// Create completely new STT instance. The session is saved with its
+	// recognizer's config, so it is swapped under the lock
+	recognizer := im.newSTT(session, config)
+	session.mu.Lock()
+	session.StreamingSTT = recognizer
+	ctx := session.ctx
+	session.mu.Unlock()
+
+	// Connect with retry logic
+	var connectErr error
+	for i := 0; i < 3; i++ {
+		connectErr = recognizer.Connect(ctx)
+		if connectErr == nil {
+			break
+		}
+		session.log.Warn("STT connection attempt failed", "attempt", i+1, logging.Err(connectErr))
+		if i < 2 {
+			time.Sleep(500 * time.Millisecond)
+		}
+	}
+
+	if connectErr != nil {
+		session.log.Error("Failed to reconnect STT", "attempt", attempt, logging.Err(connectErr))
+		return connectErr
+	}
+
+	session.log.Info("Reconnected STT", "attempt", attempt)
+
+	// Reset AssemblyAI session ID since we have a new connection
+	session.mu.Lock()
+	session.AssemblyAIID = ""
+	session.mu.Unlock()
+
+	return nil
+}
+
+// runRecorderStage records speech segments on the session timeline
+func (im *InterviewManager) runRecorderStage(session *InterviewSession, records <-chan segmentRecord) {
+	segmentBytes, segmentChunks := 0, 0
+
+	for record := range records {
+		switch record.event {
+		case "":
+			segmentBytes += len(record.frame.Data)
+			segmentChunks++
+		case sessionstate.EventVADSpeechStart:
+			segmentBytes, segmentChunks = 0, 0
+			im.recordEvent(session, sessionstate.EventVADSpeechStart, nil)
+
+			// The candidate talking over the interviewer interrupts it
+			im.stopSpeaking(session, true)
+		case sessionstate.EventVADSpeechEnd:
+			duration := record.duration.Milliseconds()
+			im.recordEvent(session, sessionstate.EventAudioSegment, sessionstate.AudioSegmentData{
+				Bytes:    segmentBytes,
+				Chunks:   segmentChunks,
+				Duration: duration,
+			})
+			im.recordEvent(session, sessionstate.EventVADSpeechEnd, sessionstate.SpeechData{
+				Reason:   record.reason,
+				Duration: duration,
+			})
+			segmentBytes, segmentChunks = 0, 0
+		}
+	}
+}
+
+// disconnectClient closes the session's client connection, which ends its
+// read loop and moves the session to disconnected
+func (im *InterviewManager) disconnectClient(session *InterviewSession) {
+	session.mu.RLock()
+	conn := session.Client
+	session.mu.RUnlock()
+
+	if conn != nil {
+		conn.Close()
+	}
+}
@@ -0,0 +1,206 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/torteous44/callservice/internal/auth"
+	"github.com/torteous44/callservice/internal/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultTicketTTL is how long a WebSocket ticket is valid: long enough to
+// open the connection right after asking for it
+const DefaultTicketTTL = 60 * time.Second
+
+// errTicketRequired is returned when a client connects without a valid ticket
+var errTicketRequired = errors.New("valid ticket required")
+
+// TicketResponse carries a ticket for opening a session's WebSocket
+type TicketResponse struct {
+	SessionID    string    `json:"session_id"`
+	Ticket       string    `json:"ticket"`
+	WebSocketURL string    `json:"websocket_url"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// authEnabled reports whether API callers must authenticate
+func (im *InterviewManager) authEnabled() bool {
+	return im.auth != nil
+}
+
+// authenticate returns the user of a request's bearer token, writing a 401
+// if it is missing or invalid. Without authentication configured every
+// request is anonymous and allowed
+func (im *InterviewManager) authenticate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if !im.authEnabled() {
+		return "", true
+	}
+
+	token, err := auth.BearerToken(r)
+	if err == nil {
+		var claims *auth.Claims
+		if claims, err = im.auth.Verify(token); err == nil {
+			return claims.Subject, true
+		}
+	}
+
+	im.logger.Warn("Rejecting unauthenticated request", "path", r.URL.Path, logging.Err(err))
+	w.Header().Set("WWW-Authenticate", `Bearer realm="callservice"`)
+	http.Error(w, "Valid bearer token required", http.StatusUnauthorized)
+	return "", false
+}
+
+// authorizeSession checks that the request's user owns a session, writing an
+// error response if not. Sessions of other users are reported as not found
+func (im *InterviewManager) authorizeSession(w http.ResponseWriter, r *http.Request, sessionID string) bool {
+	user, ok := im.authenticate(w, r)
+	if !ok {
+		return false
+	}
+	if err := im.checkOwner(sessionID, user); err != nil {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// checkOwner checks that user owns a session. It always passes without
+// authentication configured
+func (im *InterviewManager) checkOwner(sessionID, user string) error {
+	if !im.authEnabled() {
+		return nil
+	}
+	owner, found := im.sessionOwner(sessionID)
+	if !found {
+		return errors.New("session not found")
+	}
+	if owner != user {
+		im.sessionLogger(sessionID).Warn("User denied access to session", "user", user)
+		return errors.New("session belongs to another user")
+	}
+	return nil
+}
+
+// sessionOwner returns the user that created a session, looking in the
+// backend for sessions this instance does not serve, closed ones included
+func (im *InterviewManager) sessionOwner(sessionID string) (string, bool) {
+	im.mu.RLock()
+	session, exists := im.sessions[sessionID]
+	im.mu.RUnlock()
+
+	if exists {
+		session.mu.RLock()
+		defer session.mu.RUnlock()
+		return session.OwnerID, true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+	defer cancel()
+
+	record, err := im.backend.LoadSession(ctx, sessionID)
+	if err != nil {
+		return "", false
+	}
+	return record.OwnerID, true
+}
+
+// issueTicket returns a ticket to open a session's WebSocket, or an empty
+// one without authentication configured
+func (im *InterviewManager) issueTicket(sessionID string) (string, error) {
+	if !im.authEnabled() {
+		return "", nil
+	}
+	return im.tokens.Issue(sessionID, ScopeTicket, im.ticketTTL)
+}
+
+// verifyTicket checks the ticket a client connects to a session with. It
+// always passes without authentication configured
+func (im *InterviewManager) verifyTicket(ticket, sessionID string) error {
+	if !im.authEnabled() {
+		return nil
+	}
+	if _, err := im.tokens.Verify(ticket, sessionID, ScopeTicket); err != nil {
+		im.sessionLogger(sessionID).Warn("Rejecting connection", logging.Err(err))
+		return errTicketRequired
+	}
+	return nil
+}
+
+// IssueTicket issues the session owner a short-lived ticket to open the
+// session's WebSocket, since browsers cannot send a bearer token with the
+// upgrade request. Clients ask for a new one to reconnect
+func (im *InterviewManager) IssueTicket(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := r.PathValue("id")
+	if !im.authorizeSession(w, r, sessionID) {
+		return
+	}
+	if !im.sessionExists(sessionID) {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	ticket, err := im.issueTicket(sessionID)
+	if err != nil {
+		im.sessionLogger(sessionID).Error("Failed to issue ticket", logging.Err(err))
+		http.Error(w, "Failed to issue ticket", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(TicketResponse{
+		SessionID:    sessionID,
+		Ticket:       ticket,
+		WebSocketURL: im.websocketURL(r, "/ws/interview/"+sessionID),
+		ExpiresAt:    time.Now().Add(im.ticketTTL),
+	})
+}
+
+// authenticateRPC returns the user of a gRPC call's bearer token, sent as
+// authorization metadata
+func (im *InterviewManager) authenticateRPC(ctx context.Context) (string, error) {
+	if !im.authEnabled() {
+		return "", nil
+	}
+
+	var value string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			value = values[0]
+		}
+	}
+	token, err := auth.ParseAuthorization(value)
+	if err != nil {
+		return "", status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	claims, err := im.auth.Verify(token)
+	if err != nil {
+		im.logger.Warn("Rejecting unauthenticated gRPC call", logging.Err(err))
+		return "", status.Error(codes.Unauthenticated, "valid bearer token required")
+	}
+	return claims.Subject, nil
+}
+
+// authorizeRPC checks that the caller of a gRPC call owns a session
+func (im *InterviewManager) authorizeRPC(ctx context.Context, sessionID string) error {
+	user, err := im.authenticateRPC(ctx)
+	if err != nil {
+		return err
+	}
+	if err := im.checkOwner(sessionID, user); err != nil {
+		return status.Error(codes.NotFound, "session not found")
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package orchestrator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/torteous44/callservice/internal/audio/stt"
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/metrics"
+	"github.com/torteous44/callservice/pkg/transport"
+)
+
+// handshakeTimeout bounds how long a client may take to send its first frame
+const handshakeTimeout = 10 * time.Second
+
+// sessionAudioFormat returns the audio format clients must stream for a session
+func sessionAudioFormat(config stt.StreamingConfig) transport.AudioFormat {
+	return transport.AudioFormat{
+		SampleRate: config.SampleRate,
+		Encoding:   config.Encoding,
+		Channels:   1,
+	}
+}
+
+// clientConn is a candidate connection, whatever transport it uses
+type clientConn interface {
+	transport.Sink
+	Send(messageType string, payload interface{}) error
+	SendError(code, message string) error
+	Close() error
+}
+
+// handleControl applies a control message from the client. It reports true
+// when the client asked to stop streaming
+func (im *InterviewManager) handleControl(session *InterviewSession, conn clientConn, msg transport.Message) bool {
+	switch msg.Type {
+	case transport.TypeStop:
+		session.log.Info("Client stopped audio stream")
+		if session.StreamingSTT != nil {
+			// Flush whatever the recognizer is holding before the stream closes
+			if err := session.StreamingSTT.ForceEndpoint(); err != nil {
+				session.log.Warn("Failed to force endpoint", logging.Err(err))
+			}
+		}
+		return true
+
+	case transport.TypeMute:
+		var mute transport.MuteMessage
+		if err := msg.Decode(&mute); err != nil {
+			conn.SendError(transport.ErrorCodeBadMessage, err.Error())
+			return false
+		}
+		session.muted.Store(mute.Muted)
+
+		status := "unmuted"
+		if mute.Muted {
+			status = "muted"
+		}
+		session.log.Info("Mute changed by client", "status", status)
+		im.sendToClient(session, transport.TypeStatus, transport.StatusMessage{SessionID: session.ID, Status: status})
+
+	case transport.TypeConfig:
+		var update transport.ConfigMessage
+		if err := msg.Decode(&update); err != nil {
+			conn.SendError(transport.ErrorCodeBadMessage, err.Error())
+			return false
+		}
+		if err := im.applyConfig(session, update); err != nil {
+			session.log.Warn("Failed to update STT config", logging.Err(err))
+			conn.SendError(transport.ErrorCodeSTT, err.Error())
+			return false
+		}
+		im.sendToClient(session, transport.TypeStatus, transport.StatusMessage{
+			SessionID: session.ID,
+			Status:    "configured",
+		})
+
+	case transport.TypePing:
+		conn.Send(transport.TypePong, msg.Payload)
+
+	case transport.TypeHeartbeat:
+		var heartbeat transport.HeartbeatMessage
+		if err := msg.Decode(&heartbeat); err != nil {
+			conn.SendError(transport.ErrorCodeBadMessage, err.Error())
+			return false
+		}
+		session.mu.Lock()
+		session.PlaybackPosition = heartbeat.PlaybackPosition
+		played := session.speechPlaying && !heartbeat.Playing
+		if heartbeat.Playing && session.speaking != "" {
+			session.speechPlaying = true
+		}
+		session.mu.Unlock()
+
+		// A client that stops playing has played the interviewer's speech out
+		if played {
+			im.stopSpeaking(session, false)
+		}
+
+	case transport.TypePong:
+		// Nothing to do, receiving it already counts as activity
+
+	case transport.TypeStart:
+		conn.SendError(transport.ErrorCodeBadMessage, "handshake already completed")
+
+	default:
+		conn.SendError(transport.ErrorCodeUnknownType, fmt.Sprintf("unknown message type %q", msg.Type))
+	}
+
+	return false
+}
+
+// applyConfig merges a client config update into the session's STT config
+func (im *InterviewManager) applyConfig(session *InterviewSession, update transport.ConfigMessage) error {
+	if session.StreamingSTT == nil {
+		return fmt.Errorf("speech recognition is not running")
+	}
+
+	config := session.StreamingSTT.GetConfig()
+	if update.EndOfTurnConfidenceThreshold != nil {
+		if *update.EndOfTurnConfidenceThreshold < 0 || *update.EndOfTurnConfidenceThreshold > 1 {
+			return fmt.Errorf("end_of_turn_confidence_threshold must be between 0 and 1")
+		}
+		config.EndOfTurnConfidenceThreshold = *update.EndOfTurnConfidenceThreshold
+	}
+	if update.MinEndOfTurnSilenceWhenConfident != nil {
+		if *update.MinEndOfTurnSilenceWhenConfident < 0 {
+			return fmt.Errorf("min_end_of_turn_silence_when_confident must not be negative")
+		}
+		config.MinEndOfTurnSilenceWhenConfident = *update.MinEndOfTurnSilenceWhenConfident
+	}
+	if update.MaxTurnSilence != nil {
+		if *update.MaxTurnSilence < 0 {
+			return fmt.Errorf("max_turn_silence must not be negative")
+		}
+		config.MaxTurnSilence = *update.MaxTurnSilence
+	}
+
+	return session.StreamingSTT.UpdateConfig(config)
+}
+
+// sendToClient sends a message to the session's client if one is connected.
+// Replayable messages go through the session outbox so a resuming client can
+// catch up on them
+func (im *InterviewManager) sendToClient(session *InterviewSession, messageType string, payload interface{}) {
+	// Observers hear the interviewer too; everything else reaches them as
+	// timeline events
+	if messageType == transport.TypeInterviewerAudio {
+		im.observers.broadcast(session.ID, messageType, payload)
+		if audio, ok := payload.(transport.AudioMessage); ok {
+			metrics.AudioBytes.WithLabelValues("out").Add(float64(len(audio.Data)))
+		}
+		observeMark(&session.turnEndedAt, metrics.FirstInterviewerAudio)
+		session.trace.answered(time.Now())
+	}
+
+	if transport.Replayable(messageType) {
+		if err := session.Outbox.Publish(messageType, payload); err != nil {
+			session.log.Error("Failed to send message to client", "message_type", messageType, logging.Err(err))
+		}
+		return
+	}
+
+	session.mu.RLock()
+	conn := session.Client
+	session.mu.RUnlock()
+
+	if conn == nil {
+		return
+	}
+	if err := conn.Send(messageType, payload); err != nil {
+		session.log.Error("Failed to send message to client", "message_type", messageType, logging.Err(err))
+	}
+}
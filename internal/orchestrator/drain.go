@@ -0,0 +1,180 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/torteous44/callservice/pkg/transport"
+)
+
+// Draining
+const (
+	drainPollInterval = 250 * time.Millisecond
+	drainCloseTimeout = 5 * time.Second  // For disconnected clients to release their sessions
+	drainFlushTimeout = 15 * time.Second // For pending analytics flushes
+)
+
+// errDraining is returned when a session cannot start because the instance is
+// shutting down
+var errDraining = errors.New("server is draining")
+
+// Draining reports whether the instance is shutting down
+func (im *InterviewManager) Draining() bool {
+	return im.draining.Load()
+}
+
+// Drain prepares the instance to shut down. New sessions and connections are
+// refused, connected clients and observers get a server_draining message and
+// interviews may carry on until ctx is done. Clients still connected then are
+// disconnected and their sessions persisted as disconnected, so they resume on
+// another instance; recognizers are closed with a SessionTermination. Drain
+// returns once pending analytics flushes are done
+func (im *InterviewManager) Drain(ctx context.Context) error {
+	im.draining.Store(true)
+
+	deadline, _ := ctx.Deadline()
+	connected := 0
+	for _, session := range im.liveSessions() {
+		if !session.hasClient() {
+			continue
+		}
+		connected++
+		im.sendToClient(session, transport.TypeServerDraining, transport.ServerDrainingMessage{
+			SessionID: session.ID,
+			Deadline:  deadline,
+			Details:   "the server is shutting down, reconnect with your resume token to continue elsewhere",
+		})
+		im.observers.broadcast(session.ID, transport.TypeServerDraining, transport.ServerDrainingMessage{
+			SessionID: session.ID,
+			Deadline:  deadline,
+			Details:   "the server is shutting down",
+		})
+	}
+	im.logger.Info("Draining connected sessions", "sessions", connected, "deadline", deadline.Format(time.RFC3339))
+
+	// Let interviews finish, then hang up on the rest
+	im.waitForClients(ctx)
+	if remaining := im.connectedSessions(); len(remaining) > 0 {
+		im.logger.Info("Disconnecting sessions still connected after the drain deadline", "sessions", len(remaining))
+		for _, session := range remaining {
+			session.mu.RLock()
+			client := session.Client
+			session.mu.RUnlock()
+			if client != nil {
+				client.Close()
+			}
+		}
+
+		closeCtx, cancel := context.WithTimeout(context.Background(), drainCloseTimeout)
+		im.waitForClients(closeCtx)
+		cancel()
+	}
+
+	var errs []error
+	for _, session := range im.liveSessions() {
+		if err := im.handOffSession(session); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	im.observers.closeAll()
+
+	flushed := make(chan struct{})
+	go func() {
+		im.finalizing.Wait()
+		close(flushed)
+	}()
+	select {
+	case <-flushed:
+	case <-time.After(drainFlushTimeout):
+		errs = append(errs, errors.New("analytics flushes did not finish"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// handOffSession stops a session on this instance and persists it for another
+// instance to resume. A client that did not release the session in time is
+// dropped
+func (im *InterviewManager) handOffSession(session *InterviewSession) error {
+	session.mu.Lock()
+	if session.Status == "closed" {
+		session.mu.Unlock()
+		return nil
+	}
+
+	var err error
+	if session.Client != nil {
+		session.Client = nil
+		err = fmt.Errorf("session %s was still connected at shutdown", session.ID)
+	}
+	if session.Status == "connected" {
+		session.Status = "disconnected"
+		session.DisconnectedAt = time.Now()
+	}
+	recognizer := session.StreamingSTT
+	session.cancel()
+	session.mu.Unlock()
+
+	if recognizer != nil {
+		recognizer.Close()
+	}
+	im.persistSession(session)
+	session.trace.end("handed_off")
+	return err
+}
+
+// waitForClients waits until no session has a connected client or ctx is done
+func (im *InterviewManager) waitForClients(ctx context.Context) {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for len(im.connectedSessions()) > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// liveSessions returns the sessions in memory on this instance
+func (im *InterviewManager) liveSessions() []*InterviewSession {
+	im.mu.RLock()
+	defer im.mu.RUnlock()
+
+	sessions := make([]*InterviewSession, 0, len(im.sessions))
+	for _, session := range im.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// connectedSessions returns the sessions with a connected client
+func (im *InterviewManager) connectedSessions() []*InterviewSession {
+	var connected []*InterviewSession
+	for _, session := range im.liveSessions() {
+		if session.hasClient() {
+			connected = append(connected, session)
+		}
+	}
+	return connected
+}
+
+// hasClient reports whether a client is connected to the session
+func (s *InterviewSession) hasClient() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Client != nil
+}
+
+// scheduleFinalize flushes a closed session to analytics in the background.
+// Drain waits for pending flushes
+func (im *InterviewManager) scheduleFinalize(sessionID string) {
+	im.finalizing.Add(1)
+	go func() {
+		defer im.finalizing.Done()
+		im.finalizeSession(sessionID)
+	}()
+}
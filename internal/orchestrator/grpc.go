@@ -0,0 +1,594 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/torteous44/callservice/internal/limits"
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/metrics"
+	"github.com/torteous44/callservice/internal/sessionstate"
+	"github.com/torteous44/callservice/pkg/interviewpb"
+	"github.com/torteous44/callservice/pkg/transport"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Converse stream settings
+const (
+	grpcQueueSize    = 128             // Responses buffered for a slow client
+	grpcDrainTimeout = 1 * time.Second // How long a closing stream may take to send what is queued
+)
+
+// GRPCServer serves the InterviewService gRPC API from an InterviewManager.
+// Sessions are shared with the REST and WebSocket API
+type GRPCServer struct {
+	interviewpb.UnimplementedInterviewServiceServer
+	im *InterviewManager
+}
+
+// NewGRPCServer creates a gRPC service backed by im
+func NewGRPCServer(im *InterviewManager) *GRPCServer {
+	return &GRPCServer{im: im}
+}
+
+// CreateSession creates a session, with lesson data if given, owned by the
+// caller
+func (s *GRPCServer) CreateSession(ctx context.Context, req *interviewpb.CreateSessionRequest) (*interviewpb.CreateSessionResponse, error) {
+	user, err := s.im.authenticateRPC(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var lesson *SessionInitializationRequest
+	if len(req.GetLessonJson()) > 0 {
+		lesson = &SessionInitializationRequest{}
+		if err := json.Unmarshal(req.GetLessonJson(), lesson); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid lesson_json: %v", err)
+		}
+		if lesson.Lesson.LessonID == "" {
+			return nil, status.Error(codes.InvalidArgument, "lesson_id is required")
+		}
+	}
+
+	config := s.im.streamingConfig(int(req.GetFormat().GetSampleRate()), req.GetFormat().GetEncoding())
+	session, resumeToken, err := s.im.createSession(user, peerIP(ctx), config, lesson)
+	var limitErr *limits.Error
+	if errors.As(err, &limitErr) {
+		return nil, limitStatus(limitErr)
+	}
+	if errors.Is(err, errDraining) {
+		return nil, status.Error(codes.Unavailable, "server is shutting down")
+	}
+	if err != nil {
+		s.im.logger.Error("Failed to create session", logging.Err(err))
+		return nil, status.Error(codes.Internal, "failed to create session")
+	}
+
+	return &interviewpb.CreateSessionResponse{
+		SessionId:   session.ID,
+		Status:      "initialized",
+		ResumeToken: resumeToken,
+		Format:      protoAudioFormat(sessionAudioFormat(config)),
+	}, nil
+}
+
+// GetStatus returns the status of a session
+func (s *GRPCServer) GetStatus(ctx context.Context, req *interviewpb.GetStatusRequest) (*interviewpb.SessionStatus, error) {
+	if err := s.im.authorizeRPC(ctx, req.GetSessionId()); err != nil {
+		return nil, err
+	}
+	response, exists := s.im.lookupSessionStatus(req.GetSessionId())
+	if !exists {
+		return nil, status.Error(codes.NotFound, "session not found")
+	}
+
+	return &interviewpb.SessionStatus{
+		SessionId:          response.SessionID,
+		Status:             response.Status,
+		StartTime:          timestamppb.New(response.StartTime),
+		TranscriptCount:    int32(response.TranscriptCount),
+		UtteranceCount:     int32(response.UtteranceCount),
+		PlaybackPositionMs: response.PlaybackPosition,
+		Observers:          int32(response.Observers),
+	}, nil
+}
+
+// CloseSession terminates a session
+func (s *GRPCServer) CloseSession(ctx context.Context, req *interviewpb.CloseSessionRequest) (*interviewpb.CloseSessionResponse, error) {
+	if err := s.im.authorizeRPC(ctx, req.GetSessionId()); err != nil {
+		return nil, err
+	}
+	if !s.im.closeSession(req.GetSessionId()) {
+		return nil, status.Error(codes.NotFound, "session not found")
+	}
+
+	s.im.sessionLogger(req.GetSessionId()).Info("Interview session closed")
+	return &interviewpb.CloseSessionResponse{
+		SessionId: req.GetSessionId(),
+		Status:    "closed",
+	}, nil
+}
+
+// Converse joins a session as its candidate: audio and control come in on the
+// request stream, and the messages a WebSocket client would receive go out on
+// the response stream
+func (s *GRPCServer) Converse(stream interviewpb.InterviewService_ConverseServer) error {
+	im := s.im
+
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	start := first.GetStart()
+	if start == nil {
+		return status.Error(codes.InvalidArgument, "the first message must be start")
+	}
+	if err := im.authorizeRPC(stream.Context(), start.GetSessionId()); err != nil {
+		return err
+	}
+
+	session, exists := im.acquireSession(start.GetSessionId())
+	if !exists {
+		return status.Error(codes.NotFound, "session not found")
+	}
+
+	client := newGRPCClient(stream.Context(), stream)
+
+	session.mu.Lock()
+	if code, message := im.claimSession(session, start.GetResumeToken()); code != 0 {
+		session.mu.Unlock()
+		return status.Error(grpcCode(code), message)
+	}
+	format := sessionAudioFormat(session.StreamingSTT.GetConfig())
+	session.Client = client
+	session.Status = "connected"
+	session.muted.Store(false)
+	session.touch()
+	session.mu.Unlock()
+	im.persistSession(session)
+
+	im.recordEvent(session, sessionstate.EventClientConnected, nil)
+	metrics.ClientConnects.WithLabelValues(transportGRPC).Inc()
+	session.log.Info("gRPC client connected")
+
+	defer func() {
+		session.Outbox.Detach(client)
+		im.releaseSession(session)
+		metrics.ClientDisconnects.WithLabelValues(transportGRPC).Inc()
+		client.wait()
+		session.log.Info("gRPC client disconnected")
+	}()
+
+	im.processSession(session)
+
+	client.Send(transport.TypeReady, transport.ReadyMessage{
+		SessionID: session.ID,
+		Version:   transport.ProtocolVersion,
+		Format:    format,
+	})
+
+	// Catch the client up before any new message reaches it
+	replayed, complete := session.Outbox.Attach(client, start.GetLastSeq())
+	if replayed > 0 || !complete {
+		session.log.Info("Replayed missed messages", "replayed", replayed, "last_seq", start.GetLastSeq(), "complete", complete)
+	}
+	if !complete {
+		client.Send(transport.TypeStatus, transport.StatusMessage{
+			SessionID: session.ID,
+			Status:    "resync",
+			Details:   "some missed messages are no longer available, reload the session state and timeline",
+		})
+	}
+
+	im.sendToClient(session, transport.TypeStatus, transport.StatusMessage{
+		SessionID: session.ID,
+		Status:    "connected",
+	})
+
+	ingest, err := NewAudioIngest(IngestConfig{Format: format}, nil)
+	if err != nil {
+		session.log.Error("Cannot ingest audio", logging.Err(err))
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	im.runIngest(session, ingest, func() {
+		im.readConverse(session, client, stream, ingest)
+	})
+	return nil
+}
+
+// readConverse feeds a Converse request stream into the ingest until the
+// client stops, the stream ends or the connection is closed
+func (im *InterviewManager) readConverse(session *InterviewSession, client *grpcClient,
+	stream interviewpb.InterviewService_ConverseServer, ingest *AudioIngest) {
+
+	requests := make(chan *interviewpb.ConverseRequest)
+	go func() {
+		defer close(requests)
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			select {
+			case requests <- req:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		var req *interviewpb.ConverseRequest
+		select {
+		case <-session.ctx.Done():
+			return
+		case <-client.closing:
+			return
+		case r, ok := <-requests:
+			if !ok {
+				return
+			}
+			req = r
+		}
+		session.touch()
+
+		if audio := req.GetAudio(); audio != nil {
+			if session.muted.Load() {
+				continue
+			}
+			if err := ingest.ProcessAudio(session.ctx, audio); err != nil {
+				var audioErr *AudioError
+				if !errors.As(err, &audioErr) {
+					return
+				}
+				session.log.Warn("Rejected audio", logging.Err(err))
+				client.SendError(transport.ErrorCodeAudio, audioErr.Message)
+			}
+			continue
+		}
+
+		msg, err := controlMessage(req)
+		if err != nil {
+			client.SendError(transport.ErrorCodeBadMessage, err.Error())
+			continue
+		}
+		if im.handleControl(session, client, msg) {
+			return
+		}
+	}
+}
+
+// controlMessage converts a Converse control request to a protocol message
+func controlMessage(req *interviewpb.ConverseRequest) (transport.Message, error) {
+	switch m := req.GetMessage().(type) {
+	case *interviewpb.ConverseRequest_Mute:
+		return transport.NewMessage(transport.TypeMute, transport.MuteMessage{Muted: m.Mute.GetMuted()})
+
+	case *interviewpb.ConverseRequest_Stop:
+		return transport.NewMessage(transport.TypeStop, nil)
+
+	case *interviewpb.ConverseRequest_Config:
+		update := transport.ConfigMessage{
+			EndOfTurnConfidenceThreshold: m.Config.EndOfTurnConfidenceThreshold,
+		}
+		if m.Config.MinEndOfTurnSilenceWhenConfident != nil {
+			value := int(m.Config.GetMinEndOfTurnSilenceWhenConfident())
+			update.MinEndOfTurnSilenceWhenConfident = &value
+		}
+		if m.Config.MaxTurnSilence != nil {
+			value := int(m.Config.GetMaxTurnSilence())
+			update.MaxTurnSilence = &value
+		}
+		return transport.NewMessage(transport.TypeConfig, update)
+
+	case *interviewpb.ConverseRequest_Heartbeat:
+		return transport.NewMessage(transport.TypeHeartbeat, transport.HeartbeatMessage{
+			PlaybackPosition: m.Heartbeat.GetPlaybackPositionMs(),
+			Playing:          m.Heartbeat.GetPlaying(),
+		})
+
+	case *interviewpb.ConverseRequest_Start:
+		return transport.Message{}, fmt.Errorf("already started")
+
+	default:
+		return transport.Message{}, fmt.Errorf("empty request")
+	}
+}
+
+// converseResponse converts a protocol message to a Converse response. It
+// returns nil for messages gRPC clients do not need, such as pongs
+func converseResponse(msg transport.Message) (*interviewpb.ConverseResponse, error) {
+	resp := &interviewpb.ConverseResponse{
+		Seq:       msg.Seq,
+		Timestamp: timestamppb.New(time.UnixMilli(msg.Timestamp)),
+	}
+
+	switch msg.Type {
+	case transport.TypeReady:
+		var ready transport.ReadyMessage
+		if err := msg.Decode(&ready); err != nil {
+			return nil, err
+		}
+		resp.Message = &interviewpb.ConverseResponse_Ready{Ready: &interviewpb.Ready{
+			SessionId: ready.SessionID,
+			Format:    protoAudioFormat(ready.Format),
+		}}
+
+	case transport.TypeTranscript:
+		var transcript transport.TranscriptMessage
+		if err := msg.Decode(&transcript); err != nil {
+			return nil, err
+		}
+		resp.Message = &interviewpb.ConverseResponse_Transcript{Transcript: &interviewpb.Transcript{
+			MessageType:         transcript.MessageType,
+			Text:                transcript.Text,
+			Confidence:          transcript.Confidence,
+			IsFinal:             transcript.IsFinal,
+			AssemblyaiSessionId: transcript.SessionID,
+		}}
+
+	case transport.TypeStatus:
+		var statusMsg transport.StatusMessage
+		if err := msg.Decode(&statusMsg); err != nil {
+			return nil, err
+		}
+		resp.Message = &interviewpb.ConverseResponse_Status{Status: &interviewpb.Status{
+			SessionId: statusMsg.SessionID,
+			Status:    statusMsg.Status,
+			Details:   statusMsg.Details,
+		}}
+
+	case transport.TypeServerDraining:
+		// Streams see draining as a status, with the deadline in the details
+		var draining transport.ServerDrainingMessage
+		if err := msg.Decode(&draining); err != nil {
+			return nil, err
+		}
+		resp.Message = &interviewpb.ConverseResponse_Status{Status: &interviewpb.Status{
+			SessionId: draining.SessionID,
+			Status:    transport.TypeServerDraining,
+			Details:   fmt.Sprintf("%s (deadline %s)", draining.Details, draining.Deadline.Format(time.RFC3339)),
+		}}
+
+	case transport.TypeGrade:
+		var grade sessionstate.GradeData
+		if err := msg.Decode(&grade); err != nil {
+			return nil, err
+		}
+		resp.Message = &interviewpb.ConverseResponse_Grade{Grade: &interviewpb.Grade{
+			QuestionIndex: int32(grade.QuestionIndex),
+			Scores:        grade.Scores,
+			OverallScore:  grade.OverallScore,
+			Decision:      grade.Decision,
+			Feedback:      grade.Feedback,
+		}}
+
+	case transport.TypeHint:
+		var hint transport.HintMessage
+		if err := msg.Decode(&hint); err != nil {
+			return nil, err
+		}
+		resp.Message = &interviewpb.ConverseResponse_Hint{Hint: &interviewpb.Hint{
+			QuestionIndex: int32(hint.QuestionIndex),
+			Text:          hint.Text,
+			Source:        hint.Source,
+		}}
+
+	case transport.TypeInterviewerAudio:
+		var audio transport.AudioMessage
+		if err := msg.Decode(&audio); err != nil {
+			return nil, err
+		}
+		resp.Message = &interviewpb.ConverseResponse_InterviewerAudio{InterviewerAudio: &interviewpb.InterviewerAudio{
+			Data:   audio.Data,
+			Format: audio.Format,
+		}}
+
+	case transport.TypeError:
+		var errMsg transport.ErrorMessage
+		if err := msg.Decode(&errMsg); err != nil {
+			return nil, err
+		}
+		resp.Message = &interviewpb.ConverseResponse_Error{Error: &interviewpb.Error{
+			Code:    errMsg.Code,
+			Message: errMsg.Message,
+		}}
+
+	default:
+		return nil, nil
+	}
+	return resp, nil
+}
+
+// peerIP returns the address a gRPC call came from, or "" if it is unknown
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addrPort, err := netip.ParseAddrPort(p.Addr.String())
+	if err != nil {
+		return ""
+	}
+	return addrPort.Addr().Unmap().String()
+}
+
+// protoAudioFormat converts an audio format to its protobuf form
+func protoAudioFormat(format transport.AudioFormat) *interviewpb.AudioFormat {
+	return &interviewpb.AudioFormat{
+		SampleRate: int32(format.SampleRate),
+		Encoding:   format.Encoding,
+		Channels:   int32(format.Channels),
+	}
+}
+
+// grpcCode maps the HTTP status claimSession rejects a client with to a gRPC code
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.FailedPrecondition
+	}
+}
+
+// grpcClient is the candidate end of a Converse stream. Responses are queued
+// and sent by a single goroutine, since a gRPC stream cannot be written
+// concurrently. A client that falls behind is disconnected and can rejoin
+// with its last seq
+type grpcClient struct {
+	stream    interviewpb.InterviewService_ConverseServer
+	responses chan *interviewpb.ConverseResponse
+	ctx       context.Context // Cancelled to abandon queued responses
+	cancel    context.CancelFunc
+	closing   chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newGRPCClient starts sending responses on stream, whose context is ctx
+func newGRPCClient(ctx context.Context, stream interviewpb.InterviewService_ConverseServer) *grpcClient {
+	ctx, cancel := context.WithCancel(ctx)
+	c := &grpcClient{
+		stream:    stream,
+		responses: make(chan *interviewpb.ConverseResponse, grpcQueueSize),
+		ctx:       ctx,
+		cancel:    cancel,
+		closing:   make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go c.sendLoop()
+	return c
+}
+
+// sendLoop writes queued responses until the client is closed, then flushes
+// what is left unless the client is abandoned
+func (c *grpcClient) sendLoop() {
+	defer close(c.done)
+
+	for {
+		select {
+		case resp := <-c.responses:
+			if err := c.send(resp); err != nil {
+				c.Close()
+				return
+			}
+		case <-c.ctx.Done():
+			return
+		case <-c.closing:
+			for {
+				select {
+				case resp := <-c.responses:
+					if err := c.send(resp); err != nil {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// send writes a response unless the client was abandoned
+func (c *grpcClient) send(resp *interviewpb.ConverseResponse) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	return c.stream.Send(resp)
+}
+
+// Send queues a message
+func (c *grpcClient) Send(messageType string, payload interface{}) error {
+	msg, err := transport.NewMessage(messageType, payload)
+	if err != nil {
+		return err
+	}
+	return c.SendMessage(msg)
+}
+
+// SendMessage converts and queues a message without blocking
+func (c *grpcClient) SendMessage(msg transport.Message) error {
+	resp, err := converseResponse(msg)
+	if err != nil || resp == nil {
+		return err
+	}
+
+	select {
+	case <-c.closing:
+		return transport.ErrConnClosed
+	default:
+	}
+
+	select {
+	case c.responses <- resp:
+		return nil
+	default:
+		c.Close()
+		return errors.New("slow client: response queue full")
+	}
+}
+
+// ReplayMessage converts and queues a replayed message, waiting for room in
+// the queue
+func (c *grpcClient) ReplayMessage(msg transport.Message) error {
+	resp, err := converseResponse(msg)
+	if err != nil || resp == nil {
+		return err
+	}
+
+	select {
+	case c.responses <- resp:
+		return nil
+	case <-c.closing:
+		return transport.ErrConnClosed
+	case <-c.done:
+		return transport.ErrConnClosed
+	}
+}
+
+// SendError queues an error message
+func (c *grpcClient) SendError(code, message string) error {
+	return c.Send(transport.TypeError, transport.ErrorMessage{Code: code, Message: message})
+}
+
+// Close stops the client once queued responses are sent. It does not wait,
+// so it is safe to call while holding the session lock
+func (c *grpcClient) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closing)
+	})
+	return nil
+}
+
+// wait closes the client and gives queued responses grpcDrainTimeout to be
+// sent, then abandons the rest. It always waits for the sender to stop, since
+// the Converse handler must not return while the stream is in use; a send
+// blocked on a peer that stopped reading returns once keepalive drops the
+// connection
+func (c *grpcClient) wait() {
+	c.Close()
+	select {
+	case <-c.done:
+	case <-time.After(grpcDrainTimeout):
+		c.cancel()
+		<-c.done
+	}
+	c.cancel()
+}
@@ -0,0 +1,240 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/metrics"
+	"github.com/torteous44/callservice/internal/sessionstate"
+	"github.com/torteous44/callservice/pkg/transport"
+)
+
+// uploadChunkDuration is how much audio an HTTP upload feeds the pipeline at a time
+const uploadChunkDuration = 100 * time.Millisecond
+
+// AudioUploadResponse summarizes an HTTP audio upload
+type AudioUploadResponse struct {
+	SessionID string                `json:"session_id"`
+	Status    string                `json:"status"`
+	Ingest    IngestStats           `json:"ingest"`
+	Format    transport.AudioFormat `json:"format"`
+}
+
+// StreamEvents streams the messages a WebSocket client would receive
+// (transcripts, status and hints) as Server-Sent Events, for clients behind
+// proxies that block WebSockets. Browsers resume with Last-Event-ID after a
+// reconnect and missed messages are replayed from the session outbox. The
+// stream is the candidate's downstream while audio is uploaded over HTTP, so it
+// is refused while a candidate client is connected, here or on another instance
+func (im *InterviewManager) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.PathValue("id")
+	if _, err := im.tokens.Verify(r.URL.Query().Get("resume_token"), sessionID, ScopeResume); err != nil {
+		im.sessionLogger(sessionID).Warn("Rejecting event stream", logging.Err(err))
+		http.Error(w, "Valid resume_token required", http.StatusUnauthorized)
+		return
+	}
+
+	lastSeq, err := transport.LastEventID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	session, status, message := im.claimEventStream(sessionID)
+	if status != 0 {
+		http.Error(w, message, status)
+		return
+	}
+
+	stream, err := transport.NewEventStream(w)
+	if err != nil {
+		session.log.Error("Cannot stream events", logging.Err(err))
+		return
+	}
+	defer session.Outbox.Detach(stream)
+
+	replayed, complete := session.Outbox.Attach(stream, lastSeq)
+	session.log.Info("Event stream opened", "replayed", replayed, "last_seq", lastSeq, "complete", complete)
+	if !complete {
+		stream.Send(transport.TypeStatus, transport.StatusMessage{
+			SessionID: sessionID,
+			Status:    "resync",
+			Details:   "some missed messages are no longer available, reload the session state and timeline",
+		})
+	}
+
+	err = stream.Run(r.Context())
+	if errors.Is(err, transport.ErrStreamBehind) {
+		session.log.Warn("Event stream fell behind")
+	}
+	session.log.Info("Event stream closed")
+}
+
+// claimEventStream returns the session an event stream is opened for, taking
+// it over from the instance that had it unless a candidate client is connected
+// there. It returns an HTTP status and message if the stream is refused
+func (im *InterviewManager) claimEventStream(sessionID string) (*InterviewSession, int, string) {
+	session, local := im.localSession(sessionID)
+	if !local {
+		if im.draining.Load() {
+			return nil, http.StatusServiceUnavailable, "Server is shutting down, reconnect to resume"
+		}
+		record, err := im.loadSessionRecord(sessionID)
+		if err != nil {
+			if !errors.Is(err, sessionstate.ErrNotFound) {
+				im.sessionLogger(sessionID).Error("Failed to load session", logging.Err(err))
+			}
+			return nil, http.StatusNotFound, "Session not found"
+		}
+		if record.Status == "connected" {
+			im.sessionLogger(sessionID).Warn("Rejecting event stream, session is connected on another instance")
+			return nil, http.StatusConflict, "Session already connected"
+		}
+
+		var exists bool
+		if session, exists = im.acquireSession(sessionID); !exists {
+			return nil, http.StatusNotFound, "Session not found"
+		}
+	}
+
+	session.mu.Lock()
+	if session.Client != nil {
+		session.mu.Unlock()
+		session.log.Warn("Rejecting event stream, a client is connected")
+		return nil, http.StatusConflict, "Session already connected"
+	}
+	session.touch()
+	session.mu.Unlock()
+
+	// Saving the session makes the instance that had it let go
+	if !local {
+		im.persistSession(session)
+	}
+	return session, 0, ""
+}
+
+// UploadAudio takes the candidate's audio as a chunked HTTP POST body of raw
+// audio in the session format, as an alternative to streaming it over the
+// WebSocket. The upload holds the session's client slot like a WebSocket
+// connection does; ending the body ends the stream
+func (im *InterviewManager) UploadAudio(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := r.PathValue("id")
+	if !im.authorizeSession(w, r, sessionID) {
+		return
+	}
+	session, exists := im.acquireSession(sessionID)
+	if !exists {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	session.mu.Lock()
+	if status, message := im.claimSession(session, r.URL.Query().Get("resume_token")); status != 0 {
+		session.mu.Unlock()
+		http.Error(w, message, status)
+		return
+	}
+	format := sessionAudioFormat(session.StreamingSTT.GetConfig())
+	session.Status = "connected"
+	session.muted.Store(false)
+	session.touch()
+	session.mu.Unlock()
+	im.persistSession(session)
+
+	im.recordEvent(session, sessionstate.EventClientConnected, nil)
+	metrics.ClientConnects.WithLabelValues(transportHTTP).Inc()
+	session.log.Info("HTTP audio upload started")
+
+	defer func() {
+		im.releaseSession(session)
+		metrics.ClientDisconnects.WithLabelValues(transportHTTP).Inc()
+		session.log.Info("HTTP audio upload ended")
+	}()
+
+	ingest, err := NewAudioIngest(IngestConfig{Format: format}, nil)
+	if err != nil {
+		session.log.Error("Cannot ingest audio", logging.Err(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	im.processSession(session)
+	im.sendToClient(session, transport.TypeStatus, transport.StatusMessage{
+		SessionID: sessionID,
+		Status:    "connected",
+	})
+
+	im.runIngest(session, ingest, func() {
+		im.readUpload(session, w, r, ingest, format)
+	})
+
+	json.NewEncoder(w).Encode(AudioUploadResponse{
+		SessionID: sessionID,
+		Status:    "disconnected",
+		Ingest:    ingest.Stats(),
+		Format:    format,
+	})
+}
+
+// readUpload feeds an upload body into the ingest in uploadChunkDuration
+// chunks until the body ends, the upload stalls or the session is closed
+func (im *InterviewManager) readUpload(session *InterviewSession, w http.ResponseWriter, r *http.Request,
+	ingest *AudioIngest, format transport.AudioFormat) {
+
+	sampleBytes, _ := bytesPerSample(format.Encoding) // Validated by NewAudioIngest
+	chunkBytes := sampleBytes * format.SampleRate * int(uploadChunkDuration/time.Millisecond) / 1000
+	rc := http.NewResponseController(w)
+
+	for {
+		select {
+		case <-session.ctx.Done():
+			return
+		default:
+		}
+
+		// A stalled upload is treated like a dead connection
+		rc.SetReadDeadline(time.Now().Add(im.clientReadTimeout))
+
+		chunk := make([]byte, chunkBytes)
+		n, err := io.ReadFull(r.Body, chunk)
+		if n > 0 {
+			session.touch()
+		}
+		// The last chunk may end mid-sample
+		chunk = chunk[:n-n%sampleBytes]
+		if len(chunk) > 0 && !session.muted.Load() {
+			if err := ingest.ProcessAudio(session.ctx, chunk); err != nil {
+				var audioErr *AudioError
+				if !errors.As(err, &audioErr) {
+					return
+				}
+				session.log.Warn("Rejected audio", logging.Err(err))
+			}
+		}
+
+		if err != nil {
+			if err != io.EOF && err != io.ErrUnexpectedEOF {
+				session.log.Error("Error reading audio upload", logging.Err(err))
+				return
+			}
+			// Flush whatever the recognizer is holding before the stream closes
+			if session.StreamingSTT != nil {
+				if err := session.StreamingSTT.ForceEndpoint(); err != nil {
+					session.log.Warn("Failed to force endpoint", logging.Err(err))
+				}
+			}
+			return
+		}
+	}
+}
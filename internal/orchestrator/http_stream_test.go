@@ -0,0 +1,88 @@
+package orchestrator
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/torteous44/callservice/internal/audio/stt"
+	"github.com/torteous44/callservice/pkg/transport"
+)
+
+// stubClient is a connected candidate that drops what it is sent
+type stubClient struct{}
+
+func (stubClient) SendMessage(transport.Message) error { return nil }
+func (stubClient) Send(string, interface{}) error      { return nil }
+func (stubClient) SendError(string, string) error      { return nil }
+func (stubClient) Close() error                        { return nil }
+
+func TestClaimEventStream(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(session *InterviewSession)
+		sessionID  string // Instead of the session's
+		wantStatus int
+		wantMoved  bool // The second instance took the session over
+	}{
+		{
+			name:      "session on another instance",
+			wantMoved: true,
+		},
+		{
+			name: "client connected on another instance",
+			setup: func(session *InterviewSession) {
+				session.mu.Lock()
+				session.Status = "connected"
+				session.Client = stubClient{}
+				session.mu.Unlock()
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "unknown session",
+			sessionID:  "unknown",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, second := newSharedManagers(t)
+			session := newLessonSession(t, first)
+			if tt.setup != nil {
+				tt.setup(session)
+			}
+			first.persistSession(session)
+
+			sessionID := session.ID
+			if tt.sessionID != "" {
+				sessionID = tt.sessionID
+			}
+			_, status, _ := second.claimEventStream(sessionID)
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if _, local := second.localSession(session.ID); local != tt.wantMoved {
+				t.Errorf("session on the second instance = %v, want %v", local, tt.wantMoved)
+			}
+			if elsewhere, err := first.ownedElsewhere(session); err != nil || elsewhere != tt.wantMoved {
+				t.Errorf("ownedElsewhere = %v, %v, want %v", elsewhere, err, tt.wantMoved)
+			}
+		})
+	}
+}
+
+func TestClaimEventStreamWithClient(t *testing.T) {
+	im := NewInterviewManager(ManagerOptions{STT: stt.StreamingConfig{APIKey: "test"}})
+	session := newLessonSession(t, im)
+
+	if _, status, _ := im.claimEventStream(session.ID); status != 0 {
+		t.Fatalf("status = %d before a client connects, want the stream", status)
+	}
+
+	session.mu.Lock()
+	session.Client = stubClient{}
+	session.mu.Unlock()
+	if _, status, _ := im.claimEventStream(session.ID); status != http.StatusConflict {
+		t.Errorf("status = %d with a client connected, want %d", status, http.StatusConflict)
+	}
+}
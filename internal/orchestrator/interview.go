@@ -3,17 +3,27 @@ package orchestrator
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/gorilla/websocket"
+	"github.com/torteous44/callservice/internal/analytics"
 	"github.com/torteous44/callservice/internal/audio/stt"
+	"github.com/torteous44/callservice/internal/audio/tts"
 	"github.com/torteous44/callservice/internal/audio/vad"
+	"github.com/torteous44/callservice/internal/auth"
+	"github.com/torteous44/callservice/internal/contextbrain"
+	"github.com/torteous44/callservice/internal/limits"
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/metrics"
 	"github.com/torteous44/callservice/internal/sessionstate"
+	"github.com/torteous44/callservice/pkg/transport"
 )
 
 // Lesson object structures based on system design
@@ -89,18 +99,9 @@ type PersonaObject struct {
 	GeneralPersona       string `json:"general_persona"`
 }
 
-// SessionStateObject tracks the runtime context of the interview
-type SessionStateObject struct {
-	CurrentQuestion   int      `json:"current_question"`
-	SilenceTimer      int      `json:"silence_timer"`
-	HintsUsed         int      `json:"hints_used"`
-	ComponentsHit     []string `json:"components_hit"`
-	StepsHit          []string `json:"steps_hit"`
-	FollowUpsUsed     []int    `json:"follow_ups_used"`
-	UserReady         bool     `json:"user_ready"`
-	UserReadyQuestion string   `json:"user_ready_question"`
-	Completed         bool     `json:"completed"`
-}
+// SessionStateObject tracks the runtime context of the interview. It is owned by
+// sessionstate so the orchestrator and HTTP readers share one source of truth
+type SessionStateObject = sessionstate.InterviewState
 
 // TranscriptEntry represents a single transcript entry
 type TranscriptEntry struct {
@@ -113,34 +114,70 @@ type TranscriptEntry struct {
 
 // InterviewSession represents an active interview session
 type InterviewSession struct {
-	ID              string                     `json:"id"`
-	StartTime       time.Time                  `json:"start_time"`
-	Status          string                     `json:"status"`
-	StreamingSTT    *stt.StreamingSTT          `json:"-"`
-	VAD             *vad.VAD                   `json:"-"`
-	SessionState    *sessionstate.SessionState `json:"-"`
-	WebSocketConn   *websocket.Conn            `json:"-"`
-	AudioBuffer     []byte                     `json:"-"`
-	TranscriptCount int                        `json:"transcript_count"`
-	UtteranceCount  int                        `json:"utterance_count"`
-	AssemblyAIID    string                     `json:"assemblyai_id,omitempty"` // Track AssemblyAI session ID
-	mu              sync.RWMutex               `json:"-"`
-	ctx             context.Context            `json:"-"`
-	cancel          context.CancelFunc         `json:"-"`
+	ID               string                     `json:"id"`
+	StartTime        time.Time                  `json:"start_time"`
+	Status           string                     `json:"status"`
+	OwnerID          string                     `json:"owner_id,omitempty"` // User that created the session, if authenticated
+	StreamingSTT     *stt.StreamingSTT          `json:"-"`
+	VAD              *vad.VAD                   `json:"-"`
+	SessionState     *sessionstate.SessionState `json:"-"`
+	Events           *sessionstate.EventLog     `json:"-"`
+	Outbox           *transport.Outbox          `json:"-"` // Replayable messages for resuming clients
+	Spend            *limits.Meter              `json:"-"` // Spend on paid services against the session budget
+	Client           clientConn                 `json:"-"` // Connected candidate, over any transport
+	AudioBuffer      []byte                     `json:"-"`
+	TranscriptCount  int                        `json:"transcript_count"`
+	UtteranceCount   int                        `json:"utterance_count"`
+	AssemblyAIID     string                     `json:"assemblyai_id,omitempty"` // Track AssemblyAI session ID
+	DisconnectedAt   time.Time                  `json:"disconnected_at,omitempty"`
+	PlaybackPosition int64                      `json:"playback_position_ms"` // Interviewer audio played by the client, from heartbeats
+	lastActivity     atomic.Int64               `json:"-"`                    // Unix nanoseconds of the last client activity
+	muted            atomic.Bool                `json:"-"`                    // Client asked to pause audio processing
+	lastSpeechAt     atomic.Int64               `json:"-"`                    // Unix nanoseconds of the last voiced frame awaiting a final transcript
+	turnEndedAt      atomic.Int64               `json:"-"`                    // Unix nanoseconds the candidate's last turn ended, until the interviewer answers
+	answering        atomic.Bool                `json:"-"`                    // The interviewer is answering an utterance
+	speaking         string                     `json:"-"`                    // Interviewer speech sent to the client until it stops, guarded by mu
+	speechPlaying    bool                       `json:"-"`                    // The client reported playing the speech, guarded by mu
+	log              *slog.Logger               `json:"-"`                    // Tagged with the session, lesson and AssemblyAI session
+	assemblyAITag    *logging.Tag               `json:"-"`                    // AssemblyAI session of the log lines, set by the recognizer
+	trace            *sessionTrace              `json:"-"`                    // Spans of the session and its turns
+	mu               sync.RWMutex               `json:"-"`
+	persistMu        sync.Mutex                 `json:"-"` // Serializes saves to the backend, taken before mu
+	persistStopped   bool                       `json:"-"` // No more saves: the session was closed or taken over, guarded by persistMu
+	persistedAt      atomic.Int64               `json:"-"` // Unix nanoseconds of the UpdatedAt of the record last saved or loaded
+	ctx              context.Context            `json:"-"`
+	cancel           context.CancelFunc         `json:"-"`
 
 	// Lesson and Context Data
-	Lesson                *LessonObject                `json:"lesson"`
-	Introduction          *IntroductionObject          `json:"introduction"`
-	Questions             []*QuestionObject            `json:"questions"`
-	GuideStepsMap         map[string]*GuideStepsObject `json:"guide_steps_map"`
-	Conclusion            *ConclusionObject            `json:"conclusion"`
-	Persona               *PersonaObject               `json:"persona"`
-	InterviewSessionState *SessionStateObject          `json:"interview_session_state"`
+	Lesson        *LessonObject                `json:"lesson"`
+	Introduction  *IntroductionObject          `json:"introduction"`
+	Questions     []*QuestionObject            `json:"questions"`
+	GuideStepsMap map[string]*GuideStepsObject `json:"guide_steps_map"`
+	Conclusion    *ConclusionObject            `json:"conclusion"`
+	Persona       *PersonaObject               `json:"persona"`
 
 	// Ephemeral transcript storage
 	Transcript []TranscriptEntry `json:"transcript"`
 }
 
+// recognizer returns the session's speech recognizer, which reconnecting
+// replaces
+func (s *InterviewSession) recognizer() *stt.StreamingSTT {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.StreamingSTT
+}
+
+// touch records client activity on the session
+func (s *InterviewSession) touch() {
+	s.lastActivity.Store(time.Now().UnixNano())
+}
+
+// LastActivity returns the time of the last client activity on the session
+func (s *InterviewSession) LastActivity() time.Time {
+	return time.Unix(0, s.lastActivity.Load())
+}
+
 // SessionInitializationRequest represents the request to initialize with lesson data
 type SessionInitializationRequest struct {
 	Lesson       LessonObject                `json:"lesson"`
@@ -155,23 +192,152 @@ type SessionInitializationRequest struct {
 
 // InterviewManager manages interview sessions
 type InterviewManager struct {
-	sessions map[string]*InterviewSession
-	store    *sessionstate.Store
-	mu       sync.RWMutex
-	upgrader websocket.Upgrader
+	sessions    map[string]*InterviewSession
+	store       *sessionstate.Store
+	backend     sessionstate.Backend
+	sessionTTL  time.Duration
+	analytics   analytics.Sink
+	mu          sync.RWMutex
+	ws          *transport.WSHandler
+	tokens      *TokenSigner
+	observers   *observerHub
+	observerWS  *transport.WSHandler
+	observerKey string
+	rtcOptions  transport.RTCOptions
+	sttDefaults stt.StreamingConfig
+	vadConfig   vad.Config
+	urls        URLOptions
+	auth        *auth.Verifier
+	ticketTTL   time.Duration
+	limits      *limits.Limiter
+	logger      *slog.Logger
+	draining    atomic.Bool    // Shutting down: no new sessions or connections
+	finalizing  sync.WaitGroup // Pending analytics flushes
+
+	// The interviewer's answers: grading and follow-ups, and their speech
+	brain         *contextbrain.Client
+	speech        *tts.TTS
+	speechOptions tts.SynthesizeOptions
+
+	// clientReadTimeout bounds how long a client may go silent, for transports
+	// without their own keepalive
+	clientReadTimeout time.Duration
+}
+
+// ManagerOptions configures an InterviewManager
+type ManagerOptions struct {
+	Backend    sessionstate.Backend // Session persistence backend (defaults to in-memory)
+	SessionTTL time.Duration        // How long idle sessions are kept in the backend
+	Analytics  analytics.Sink       // Receives completed sessions (optional)
+	WebSocket  transport.WSOptions  // Client connection keepalive (zero values use defaults)
+
+	// ResumeSecret signs resume and observer tokens. It must be shared by all
+	// instances; if empty a random per-process secret is used
+	ResumeSecret []byte
+
+	// ObserverKey authorizes requests for observer tokens. If empty, sessions
+	// cannot be observed
+	ObserverKey string
+
+	WebRTC transport.RTCOptions // WebRTC peers (zero values use defaults)
+
+	// STT is the recognizer config of new sessions: the default audio format,
+	// turn detection and API key. Zero values use stt.GetDefaultStreamingConfig
+	STT stt.StreamingConfig
+
+	VAD vad.Config // Voice activity detection tuning (zero values use defaults)
+
+	URLs URLOptions // URLs handed to clients
+
+	// Auth verifies the bearer tokens of API callers, whose sessions are then
+	// owned by them. If nil, the API is open and sessions have no owner
+	Auth *auth.Verifier
+
+	TicketTTL time.Duration // How long WebSocket tickets are valid (defaults to DefaultTicketTTL)
+
+	// Limits rate limits session creation, caps concurrent sessions and
+	// budgets their spend. Zero values leave sessions unlimited
+	Limits limits.Options
+
+	// Brain grades the candidate's answers and writes the interviewer's
+	// follow-ups, which Speech speaks with SpeechOptions. If either is nil the
+	// interviewer does not answer
+	Brain         *contextbrain.Client
+	Speech        *tts.TTS
+	SpeechOptions tts.SynthesizeOptions // Zero values use tts.GetDefaultOptions
+
+	Logger *slog.Logger // Logger of the manager and its sessions (defaults to slog.Default)
 }
 
 // NewInterviewManager creates a new interview manager
-func NewInterviewManager() *InterviewManager {
+func NewInterviewManager(opts ManagerOptions) *InterviewManager {
+	if opts.Backend == nil {
+		opts.Backend = sessionstate.NewMemoryBackend()
+	}
+	if opts.SessionTTL <= 0 {
+		opts.SessionTTL = sessionstate.DefaultSessionTTL
+	}
+	if opts.TicketTTL <= 0 {
+		opts.TicketTTL = DefaultTicketTTL
+	}
+	if opts.WebSocket.ReadTimeout <= 0 {
+		opts.WebSocket.ReadTimeout = transport.DefaultWSOptions().ReadTimeout
+	}
+	sttDefaults := stt.GetDefaultStreamingConfig()
+	if opts.STT.SampleRate == 0 {
+		opts.STT.SampleRate = sttDefaults.SampleRate
+	}
+	if opts.STT.Encoding == "" {
+		opts.STT.Encoding = sttDefaults.Encoding
+	}
+	if opts.STT.EndOfTurnConfidenceThreshold == 0 {
+		// Turn detection was not configured
+		opts.STT.FormatTurns = sttDefaults.FormatTurns
+		opts.STT.EndOfTurnConfidenceThreshold = sttDefaults.EndOfTurnConfidenceThreshold
+		opts.STT.MinEndOfTurnSilenceWhenConfident = sttDefaults.MinEndOfTurnSilenceWhenConfident
+		opts.STT.MaxTurnSilence = sttDefaults.MaxTurnSilence
+	}
+
+	speechDefaults := tts.GetDefaultOptions()
+	if opts.SpeechOptions.Model == "" {
+		opts.SpeechOptions.Model = speechDefaults.Model
+	}
+	if opts.SpeechOptions.Voice == "" {
+		opts.SpeechOptions.Voice = speechDefaults.Voice
+	}
+	if opts.SpeechOptions.Format == "" {
+		opts.SpeechOptions.Format = speechDefaults.Format
+	}
+	if opts.SpeechOptions.Speed == 0 {
+		opts.SpeechOptions.Speed = speechDefaults.Speed
+	}
+
+	logger := logging.OrDefault(opts.Logger)
 	return &InterviewManager{
-		sessions: make(map[string]*InterviewSession),
-		store:    sessionstate.NewStore(),
-		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				// Allow all origins for testing - restrict in production
-				return true
-			},
-		},
+		sessions:    make(map[string]*InterviewSession),
+		store:       sessionstate.NewStore(),
+		backend:     opts.Backend,
+		sessionTTL:  opts.SessionTTL,
+		analytics:   opts.Analytics,
+		ws:          transport.NewWSHandler(opts.WebSocket),
+		tokens:      NewTokenSigner(opts.ResumeSecret),
+		observers:   newObserverHub(logger),
+		observerWS:  transport.NewWSHandler(opts.WebSocket),
+		observerKey: opts.ObserverKey,
+		rtcOptions:  opts.WebRTC,
+		sttDefaults: opts.STT,
+		vadConfig:   opts.VAD,
+		urls:        opts.URLs,
+		auth:        opts.Auth,
+		ticketTTL:   opts.TicketTTL,
+		limits:      limits.New(opts.Limits),
+		logger:      logger,
+
+		brain:         opts.Brain,
+		speech:        opts.Speech,
+		speechOptions: opts.SpeechOptions,
+
+		clientReadTimeout: opts.WebSocket.ReadTimeout,
 	}
 }
 
@@ -183,116 +349,215 @@ type CreateSessionRequest struct {
 
 // CreateSessionResponse represents the response when creating a session
 type CreateSessionResponse struct {
-	SessionID    string `json:"session_id"`
-	WebSocketURL string `json:"websocket_url"`
-	Status       string `json:"status"`
+	SessionID    string         `json:"session_id"`
+	WebSocketURL string         `json:"websocket_url"`
+	Status       string         `json:"status"`
+	ResumeToken  string         `json:"resume_token"`         // Required to reconnect once the session is disconnected
+	Ticket       string         `json:"ticket,omitempty"`     // Opens the WebSocket, if authentication is enabled
+	Transports   *TransportURLs `json:"transports,omitempty"` // Alternatives to the WebSocket, if advertised
 }
 
 // SessionStatusResponse represents session status information
 type SessionStatusResponse struct {
-	SessionID       string    `json:"session_id"`
-	Status          string    `json:"status"`
-	StartTime       time.Time `json:"start_time"`
-	TranscriptCount int       `json:"transcript_count"`
-	UtteranceCount  int       `json:"utterance_count"`
+	SessionID        string    `json:"session_id"`
+	Status           string    `json:"status"`
+	StartTime        time.Time `json:"start_time"`
+	TranscriptCount  int       `json:"transcript_count"`
+	UtteranceCount   int       `json:"utterance_count"`
+	PlaybackPosition int64     `json:"playback_position_ms"`
+	Observers        int       `json:"observers"` // Observers connected on this instance
+
+	// Outbound queues of the client connection, if one is open
+	Outbound *transport.QueueStats `json:"outbound,omitempty"`
 }
 
-// InitializeSession creates a new interview session
-func (im *InterviewManager) InitializeSession(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+// errSessionExists is returned when a generated session ID is already taken
+var errSessionExists = errors.New("session already exists")
 
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
-		return
+// streamingConfig returns the STT config for a session streaming audio at
+// sampleRate in encoding, defaulting to the configured format
+func (im *InterviewManager) streamingConfig(sampleRate int, encoding string) stt.StreamingConfig {
+	config := im.sttDefaults
+	if sampleRate != 0 {
+		config.SampleRate = sampleRate
 	}
-
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+	if encoding != "" {
+		config.Encoding = encoding
 	}
+	return config
+}
 
-	var req CreateSessionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		// Use defaults if no body provided
-		req.SampleRate = 16000
-		req.Encoding = "pcm_s16le"
+// createSession creates and registers a session owned by ownerID, with lesson
+// data if lesson is not nil, and returns it with the token the client needs
+// to resume it. clientIP is the address the request came from, if known.
+// Requests over the session limits fail with a *limits.Error
+func (im *InterviewManager) createSession(ownerID, clientIP string, config stt.StreamingConfig, lesson *SessionInitializationRequest) (*InterviewSession, string, error) {
+	if im.draining.Load() {
+		return nil, "", errDraining
+	}
+	if err := im.admitSession(ownerID, clientIP); err != nil {
+		return nil, "", err
 	}
 
 	// Generate session ID
 	sessionID := uuid.New().String()
 
-	// Check if session already exists
-	im.mu.Lock()
-	if _, exists := im.sessions[sessionID]; exists {
-		im.mu.Unlock()
-		http.Error(w, "Session already exists", http.StatusConflict)
-		return
-	}
-
-	// Create streaming configuration
-	config := stt.StreamingConfig{
-		SampleRate:                       req.SampleRate,
-		Encoding:                         req.Encoding,
-		FormatTurns:                      true,
-		EndOfTurnConfidenceThreshold:     0.7,
-		MinEndOfTurnSilenceWhenConfident: 1000,
-		MaxTurnSilence:                   3000,
-	}
-
 	// Create context for the session
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// Create session
 	session := &InterviewSession{
-		ID:           sessionID,
-		StartTime:    time.Now(),
-		Status:       "initialized",
-		StreamingSTT: stt.NewStreamingSTT(config),
-		VAD:          vad.NewVAD(),
-		SessionState: sessionstate.NewSessionState(sessionID),
-		ctx:          ctx,
-		cancel:       cancel,
-		Transcript:   make([]TranscriptEntry, 0),
+		ID:         sessionID,
+		StartTime:  time.Now(),
+		Status:     "initialized",
+		OwnerID:    ownerID,
+		VAD:        vad.NewVADWithConfig(im.vadConfig),
+		Events:     sessionstate.NewEventLog(sessionID, im.backend, im.sessionTTL),
+		Outbox:     transport.NewOutbox(0, 0, 0),
+		Spend:      im.limits.NewMeter(limits.Spend{}),
+		ctx:        ctx,
+		cancel:     cancel,
+		Transcript: make([]TranscriptEntry, 0),
 	}
 
-	// Store session
+	created := sessionstate.SessionCreatedData{}
+	if lesson != nil {
+		// Convert questions to pointers
+		questions := make([]*QuestionObject, len(lesson.Questions))
+		for i := range lesson.Questions {
+			questions[i] = &lesson.Questions[i]
+		}
+
+		// Convert guide steps to pointer map
+		guideStepsMap := make(map[string]*GuideStepsObject)
+		for key, steps := range lesson.GuideSteps {
+			stepsCopy := steps
+			guideStepsMap[key] = &stepsCopy
+		}
+
+		session.Lesson = &lesson.Lesson
+		session.Introduction = &lesson.Introduction
+		session.Questions = questions
+		session.GuideStepsMap = guideStepsMap
+		session.Conclusion = &lesson.Conclusion
+		session.Persona = &lesson.Persona
+		created.LessonID = lesson.Lesson.LessonID
+	}
+	im.attachLogger(session)
+	session.StreamingSTT = im.newSTT(session, config)
+
+	session.touch()
+
+	// Check if session already exists, then store it
+	im.mu.Lock()
+	if _, exists := im.sessions[sessionID]; exists {
+		im.mu.Unlock()
+		cancel()
+		return nil, "", errSessionExists
+	}
+	if err := im.checkActiveSessions(ownerID); err != nil {
+		im.mu.Unlock()
+		cancel()
+		return nil, "", err
+	}
+	session.SessionState = im.store.CreateSession(sessionID)
+	session.trace = startTrace(session, false)
 	im.sessions[sessionID] = session
 	im.mu.Unlock()
 
-	log.Printf("[INFO] Interview session initialized: %s", sessionID)
+	im.persistSession(session)
+
+	created.State = session.SessionState.Snapshot()
+	im.recordEvent(session, sessionstate.EventSessionCreated, created)
+
+	session.log.Info("Interview session initialized", "owner", ownerID)
+
+	resumeToken, err := im.tokens.Issue(sessionID, ScopeResume, im.sessionTTL)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to issue resume token: %w", err)
+	}
+	return session, resumeToken, nil
+}
+
+// writeCreateSessionResponse creates a session for an init request and writes
+// the response
+func (im *InterviewManager) writeCreateSessionResponse(w http.ResponseWriter, r *http.Request, ownerID string, config stt.StreamingConfig, lesson *SessionInitializationRequest) {
+	session, resumeToken, err := im.createSession(ownerID, im.clientIP(r), config, lesson)
+	var limitErr *limits.Error
+	if errors.As(err, &limitErr) {
+		writeLimitError(w, limitErr)
+		return
+	}
+	if errors.Is(err, errSessionExists) {
+		http.Error(w, "Session already exists", http.StatusConflict)
+		return
+	}
+	if errors.Is(err, errDraining) {
+		http.Error(w, "Server is shutting down, try again", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		im.logger.Error("Failed to create session", logging.Err(err))
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+	ticket, err := im.issueTicket(session.ID)
+	if err != nil {
+		session.log.Error("Failed to issue ticket", logging.Err(err))
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
 
 	response := CreateSessionResponse{
-		SessionID:    sessionID,
-		WebSocketURL: fmt.Sprintf("ws://localhost:8080/ws/interview/%s", sessionID),
+		SessionID:    session.ID,
+		WebSocketURL: im.websocketURL(r, "/ws/interview/"+session.ID),
+		Transports:   im.transportURLs(r, session.ID),
 		Status:       "initialized",
+		ResumeToken:  resumeToken,
+		Ticket:       ticket,
 	}
 
 	json.NewEncoder(w).Encode(response)
 }
 
-// InitializeSessionWithLesson creates a new interview session with lesson data
-func (im *InterviewManager) InitializeSessionWithLesson(w http.ResponseWriter, r *http.Request) {
+// InitializeSession creates a new interview session
+func (im *InterviewManager) InitializeSession(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := im.authenticate(w, r)
+	if !ok {
 		return
 	}
 
+	// Use defaults if no body provided
+	var req CreateSessionRequest
+	json.NewDecoder(r.Body).Decode(&req)
+
+	im.writeCreateSessionResponse(w, r, user, im.streamingConfig(req.SampleRate, req.Encoding), nil)
+}
+
+// InitializeSessionWithLesson creates a new interview session with lesson data
+func (im *InterviewManager) InitializeSessionWithLesson(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
 	if r.Method != "POST" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	user, ok := im.authenticate(w, r)
+	if !ok {
+		return
+	}
+
 	var req SessionInitializationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("[ERROR] Failed to decode session initialization request: %v", err)
+		im.logger.Warn("Failed to decode session initialization request", logging.Err(err))
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
@@ -303,134 +568,119 @@ func (im *InterviewManager) InitializeSessionWithLesson(w http.ResponseWriter, r
 		return
 	}
 
-	// Use defaults for audio config if not provided
-	if req.SampleRate == 0 {
-		req.SampleRate = 16000
-	}
-	if req.Encoding == "" {
-		req.Encoding = "pcm_s16le"
-	}
+	im.writeCreateSessionResponse(w, r, user, im.streamingConfig(req.SampleRate, req.Encoding), &req)
+}
 
-	// Generate session ID
-	sessionID := uuid.New().String()
+// GetSessionStatus returns the current status of a session
+func (im *InterviewManager) GetSessionStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	// Check if session already exists
-	im.mu.Lock()
-	if _, exists := im.sessions[sessionID]; exists {
-		im.mu.Unlock()
-		http.Error(w, "Session already exists", http.StatusConflict)
+	sessionID := r.URL.Query().Get("session_id")
+	if sessionID == "" {
+		http.Error(w, "session_id required", http.StatusBadRequest)
 		return
 	}
-
-	// Create streaming configuration
-	config := stt.StreamingConfig{
-		SampleRate:                       req.SampleRate,
-		Encoding:                         req.Encoding,
-		FormatTurns:                      true,
-		EndOfTurnConfidenceThreshold:     0.7,
-		MinEndOfTurnSilenceWhenConfident: 1000,
-		MaxTurnSilence:                   3000,
+	if !im.authorizeSession(w, r, sessionID) {
+		return
 	}
 
-	// Create context for the session
-	ctx, cancel := context.WithCancel(context.Background())
-
-	// Initialize session state with defaults
-	sessionState := &SessionStateObject{
-		CurrentQuestion:   0,
-		SilenceTimer:      0,
-		HintsUsed:         0,
-		ComponentsHit:     make([]string, 0),
-		StepsHit:          make([]string, 0),
-		FollowUpsUsed:     make([]int, 0),
-		UserReady:         false,
-		UserReadyQuestion: "Are you ready to move on to the next question?",
-		Completed:         false,
+	response, exists := im.lookupSessionStatus(sessionID)
+	if !exists {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
 	}
 
-	// Convert questions to pointers
-	questions := make([]*QuestionObject, len(req.Questions))
-	for i := range req.Questions {
-		questions[i] = &req.Questions[i]
-	}
+	json.NewEncoder(w).Encode(response)
+}
 
-	// Convert guide steps to pointer map
-	guideStepsMap := make(map[string]*GuideStepsObject)
-	for key, steps := range req.GuideSteps {
-		stepsCopy := steps
-		guideStepsMap[key] = &stepsCopy
+// lookupSessionStatus reports the status of a session, from memory if this
+// instance serves it and from its persisted record otherwise
+func (im *InterviewManager) lookupSessionStatus(sessionID string) (SessionStatusResponse, bool) {
+	if session, exists := im.localSession(sessionID); exists {
+		return im.sessionStatus(session), true
 	}
 
-	// Create session with lesson data
-	session := &InterviewSession{
-		ID:           sessionID,
-		StartTime:    time.Now(),
-		Status:       "initialized",
-		StreamingSTT: stt.NewStreamingSTT(config),
-		VAD:          vad.NewVAD(),
-		SessionState: sessionstate.NewSessionState(sessionID),
-		ctx:          ctx,
-		cancel:       cancel,
-
-		// Lesson data
-		Lesson:                &req.Lesson,
-		Introduction:          &req.Introduction,
-		Questions:             questions,
-		GuideStepsMap:         guideStepsMap,
-		Conclusion:            &req.Conclusion,
-		Persona:               &req.Persona,
-		InterviewSessionState: sessionState,
-
-		// Initialize transcript
-		Transcript: make([]TranscriptEntry, 0),
+	record, err := im.loadSessionRecord(sessionID)
+	if err != nil {
+		if !errors.Is(err, sessionstate.ErrNotFound) {
+			im.sessionLogger(sessionID).Error("Failed to load session", logging.Err(err))
+		}
+		return SessionStatusResponse{}, false
 	}
+	return SessionStatusResponse{
+		SessionID:        record.ID,
+		Status:           record.Status,
+		StartTime:        record.StartTime,
+		TranscriptCount:  record.TranscriptCount,
+		UtteranceCount:   record.UtteranceCount,
+		PlaybackPosition: record.PlaybackPosition,
+		Observers:        im.observers.count(sessionID),
+	}, true
+}
 
-	// Store session
-	im.sessions[sessionID] = session
-	im.mu.Unlock()
-
-	log.Printf("[INFO] Interview session with lesson initialized: %s (lesson: %s)", sessionID, req.Lesson.LessonID)
+// sessionStatus reports the status of a session
+func (im *InterviewManager) sessionStatus(session *InterviewSession) SessionStatusResponse {
+	session.mu.RLock()
+	defer session.mu.RUnlock()
 
-	response := CreateSessionResponse{
-		SessionID:    sessionID,
-		WebSocketURL: fmt.Sprintf("ws://localhost:8080/ws/interview/%s", sessionID),
-		Status:       "initialized",
+	response := SessionStatusResponse{
+		SessionID:        session.ID,
+		Status:           session.Status,
+		StartTime:        session.StartTime,
+		TranscriptCount:  session.TranscriptCount,
+		UtteranceCount:   session.UtteranceCount,
+		PlaybackPosition: session.PlaybackPosition,
+		Observers:        im.observers.count(session.ID),
 	}
-
-	json.NewEncoder(w).Encode(response)
+	if conn, ok := session.Client.(*transport.Conn); ok {
+		stats := conn.QueueStats()
+		response.Outbound = &stats
+	}
+	return response
 }
 
-// GetSessionStatus returns the current status of a session
-func (im *InterviewManager) GetSessionStatus(w http.ResponseWriter, r *http.Request) {
+// GetSessionState returns the typed interview state of a session
+func (im *InterviewManager) GetSessionState(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	sessionID := r.URL.Query().Get("session_id")
 	if sessionID == "" {
 		http.Error(w, "session_id required", http.StatusBadRequest)
 		return
 	}
+	if !im.authorizeSession(w, r, sessionID) {
+		return
+	}
 
-	im.mu.RLock()
-	session, exists := im.sessions[sessionID]
-	im.mu.RUnlock()
+	if state, exists := im.store.GetSession(sessionID); exists {
+		json.NewEncoder(w).Encode(state.Snapshot())
+		return
+	}
 
-	if !exists {
+	// Sessions served by another instance are read from the backend
+	record, err := im.loadSessionRecord(sessionID)
+	if err != nil {
+		if !errors.Is(err, sessionstate.ErrNotFound) {
+			im.sessionLogger(sessionID).Error("Failed to load session", logging.Err(err))
+		}
 		http.Error(w, "Session not found", http.StatusNotFound)
 		return
 	}
 
-	session.mu.RLock()
-	response := SessionStatusResponse{
-		SessionID:       session.ID,
-		Status:          session.Status,
-		StartTime:       session.StartTime,
-		TranscriptCount: session.TranscriptCount,
-		UtteranceCount:  session.UtteranceCount,
+	json.NewEncoder(w).Encode(recordState(record))
+}
+
+// GetSessionStateSchema returns the JSON schema of the interview state
+func (im *InterviewManager) GetSessionStateSchema(w http.ResponseWriter, r *http.Request) {
+	schema, err := sessionstate.Schema()
+	if err != nil {
+		im.logger.Error("Failed to build session state schema", logging.Err(err))
+		http.Error(w, "Failed to build schema", http.StatusInternalServerError)
+		return
 	}
-	session.mu.RUnlock()
 
-	json.NewEncoder(w).Encode(response)
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(schema)
 }
 
 // HandleWebSocket handles WebSocket connections for audio streaming
@@ -438,71 +688,55 @@ func (im *InterviewManager) HandleWebSocket(w http.ResponseWriter, r *http.Reque
 	// Extract session ID from URL path
 	sessionID := r.URL.Path[len("/ws/interview/"):]
 
-	im.mu.RLock()
-	session, exists := im.sessions[sessionID]
-	im.mu.RUnlock()
+	// Resuming clients pass the last message sequence they saw to catch up
+	lastSeq, err := lastSeqParam(r)
+	if err != nil {
+		http.Error(w, "Invalid last_seq", http.StatusBadRequest)
+		return
+	}
+
+	// Browsers cannot send a bearer token with the upgrade, so the owner asks
+	// for a ticket first
+	if err := im.verifyTicket(r.URL.Query().Get("ticket"), sessionID); err != nil {
+		http.Error(w, "Valid ticket required", http.StatusUnauthorized)
+		return
+	}
 
+	// Sessions created on another instance are rehydrated from the backend
+	session, exists := im.acquireSession(sessionID)
 	if !exists {
-		log.Printf("[ERROR] Session not found: %s", sessionID)
+		im.sessionLogger(sessionID).Warn("Session not found")
 		http.Error(w, "Session not found", http.StatusNotFound)
 		return
 	}
 
 	// Acquire session lock for state check and update
 	session.mu.Lock()
-
-	// Check session state and handle accordingly
-	switch session.Status {
-	case "disconnected":
-		// Allow reconnection of disconnected sessions
-		log.Printf("[INFO] Reconnecting disconnected session: %s", sessionID)
-	case "connected":
-		// Reject if already connected
-		session.mu.Unlock()
-		log.Printf("[WARN] Rejecting duplicate connection for session: %s", sessionID)
-		http.Error(w, "Session already connected", http.StatusConflict)
-		return
-	case "initialized":
-		// First connection, proceed normally
-		log.Printf("[INFO] First connection for session: %s", sessionID)
-	default:
-		// Invalid state
+	if status, message := im.claimSession(session, r.URL.Query().Get("resume_token")); status != 0 {
 		session.mu.Unlock()
-		log.Printf("[ERROR] Invalid session state '%s' for session: %s", session.Status, sessionID)
-		http.Error(w, "Invalid session state", http.StatusBadRequest)
+		http.Error(w, message, status)
 		return
 	}
 
-	// Close any existing connection before establishing new one
-	if session.WebSocketConn != nil {
-		log.Printf("[INFO] Closing existing connection for session: %s", sessionID)
-		session.WebSocketConn.Close()
-		session.WebSocketConn = nil
-	}
-
-	// Reset session state if needed
-	if session.Status == "disconnected" {
-		if session.StreamingSTT != nil {
-			session.StreamingSTT.Close()
-		}
-		// Create new context for the session
-		session.ctx, session.cancel = context.WithCancel(context.Background())
-		session.StreamingSTT = stt.NewStreamingSTT(session.StreamingSTT.GetConfig())
-	}
-
 	// Upgrade connection to WebSocket
-	conn, err := im.upgrader.Upgrade(w, r, nil)
+	conn, err := im.ws.Upgrade(w, r)
 	if err != nil {
 		session.mu.Unlock()
-		log.Printf("[ERROR] WebSocket upgrade failed for session %s: %v", sessionID, err)
+		session.log.Error("WebSocket upgrade failed", logging.Err(err))
 		return
 	}
 
-	session.WebSocketConn = conn
+	session.Client = conn
 	session.Status = "connected"
+	session.muted.Store(false)
+	session.touch()
 	session.mu.Unlock()
+	im.persistSession(session)
+
+	im.recordEvent(session, sessionstate.EventClientConnected, nil)
+	metrics.ClientConnects.WithLabelValues(transportWebSocket).Inc()
 
-	log.Printf("[INFO] WebSocket connected for session: %s", sessionID)
+	session.log.Info("WebSocket connected")
 
 	// Start the session processing in a separate goroutine
 	processingStarted := make(chan struct{})
@@ -515,7 +749,71 @@ func (im *InterviewManager) HandleWebSocket(w http.ResponseWriter, r *http.Reque
 	<-processingStarted
 
 	// Handle incoming audio data
-	im.handleAudioStream(session)
+	im.handleAudioStream(session, conn, lastSeq)
+}
+
+// lastSeqParam returns the last message sequence a resuming client saw, from
+// the last_seq query parameter, or 0
+func lastSeqParam(r *http.Request) (int64, error) {
+	value := r.URL.Query().Get("last_seq")
+	if value == "" {
+		return 0, nil
+	}
+	lastSeq, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || lastSeq < 0 {
+		return 0, fmt.Errorf("invalid last_seq %q", value)
+	}
+	return lastSeq, nil
+}
+
+// claimSession checks that a new client may stream to a session and prepares a
+// disconnected session for it. The caller must hold session.mu. It returns the
+// HTTP status and message to reject the client with, or 0
+func (im *InterviewManager) claimSession(session *InterviewSession, resumeToken string) (int, string) {
+	// A draining instance hands sessions off instead of taking them
+	if im.draining.Load() {
+		return http.StatusServiceUnavailable, "Server is shutting down, reconnect to resume"
+	}
+
+	// Check session state and handle accordingly
+	switch session.Status {
+	case "disconnected":
+		// Reconnecting requires the resume token issued at init
+		if _, err := im.tokens.Verify(resumeToken, session.ID, ScopeResume); err != nil {
+			session.log.Warn("Rejecting reconnection", logging.Err(err))
+			return http.StatusUnauthorized, "Valid resume_token required"
+		}
+		session.log.Info("Resuming disconnected session")
+	case "connected":
+		// Reject if already connected
+		session.log.Warn("Rejecting duplicate connection")
+		return http.StatusConflict, "Session already connected"
+	case "initialized":
+		// First connection, proceed normally
+		session.log.Info("First connection")
+	default:
+		// Invalid state
+		session.log.Error("Invalid session state", "status", session.Status)
+		return http.StatusBadRequest, "Invalid session state"
+	}
+
+	// Close any existing connection before establishing new one
+	if session.Client != nil {
+		session.log.Info("Closing existing connection")
+		session.Client.Close()
+		session.Client = nil
+	}
+
+	// Reset session state if needed
+	if session.Status == "disconnected" {
+		if session.StreamingSTT != nil {
+			session.StreamingSTT.Close()
+		}
+		// Create new context for the session
+		session.ctx, session.cancel = context.WithCancel(context.Background())
+		session.StreamingSTT = im.newSTT(session, session.StreamingSTT.GetConfig())
+	}
+	return 0, ""
 }
 
 // processSession handles the streaming STT and VAD processing
@@ -523,11 +821,11 @@ func (im *InterviewManager) processSession(session *InterviewSession) {
 	// Connect to AssemblyAI streaming API
 	err := session.StreamingSTT.Connect(session.ctx)
 	if err != nil {
-		log.Printf("[ERROR] Failed to connect streaming STT for session %s: %v", session.ID, err)
+		session.log.Error("Failed to connect streaming STT", logging.Err(err))
 		return
 	}
 
-	log.Printf("[INFO] Streaming STT connected for session: %s", session.ID)
+	session.log.Info("Streaming STT connected")
 
 	// Start listening for transcripts - this will restart automatically after reconnections
 	go im.listenForTranscripts(session)
@@ -541,21 +839,22 @@ func (im *InterviewManager) listenForTranscripts(session *InterviewSession) {
 			return
 		default:
 			// Check if StreamingSTT is available
-			if session.StreamingSTT == nil {
+			recognizer := session.recognizer()
+			if recognizer == nil {
 				time.Sleep(100 * time.Millisecond)
 				continue
 			}
 
 			// Listen for transcripts
 			select {
-			case transcript, ok := <-session.StreamingSTT.GetTranscripts():
+			case transcript, ok := <-recognizer.GetTranscripts():
 				if !ok {
 					// Channel closed, wait for reconnection
 					time.Sleep(100 * time.Millisecond)
 					continue
 				}
 				im.handleTranscript(session, transcript)
-			case err, ok := <-session.StreamingSTT.GetErrors():
+			case err, ok := <-recognizer.GetErrors():
 				if !ok {
 					// Channel closed, wait for reconnection
 					time.Sleep(100 * time.Millisecond)
@@ -564,10 +863,14 @@ func (im *InterviewManager) listenForTranscripts(session *InterviewSession) {
 				if err != nil {
 					// Check if it's a connection lost error during reconnection
 					if err.Error() == "connection lost" {
-						log.Printf("[DEBUG] STT connection lost for session %s (expected during reconnection)", session.ID)
+						session.log.Debug("STT connection lost, expected during reconnection")
 						continue
 					}
-					log.Printf("[ERROR] Streaming STT error for session %s: %v", session.ID, err)
+					session.log.Error("Streaming STT error", logging.Err(err))
+					im.sendToClient(session, transport.TypeError, transport.ErrorMessage{
+						Code:    transport.ErrorCodeSTT,
+						Message: err.Error(),
+					})
 				}
 			case <-session.ctx.Done():
 				return
@@ -576,20 +879,10 @@ func (im *InterviewManager) listenForTranscripts(session *InterviewSession) {
 	}
 }
 
-// handleTranscript processes transcription results and detects utterances
+// handleTranscript processes transcription results and detects utterances. The
+// session is updated under its lock, and the backend, timeline and client are
+// written to after it is released
 func (im *InterviewManager) handleTranscript(session *InterviewSession, result stt.StreamingResult) {
-	session.mu.Lock()
-	defer session.mu.Unlock()
-
-	// Track AssemblyAI session ID if we receive it
-	if result.SessionID != "" && session.AssemblyAIID == "" {
-		session.AssemblyAIID = result.SessionID
-		log.Printf("[INFO] Tracking AssemblyAI session ID: %s for session: %s",
-			result.SessionID, session.ID)
-	}
-
-	session.TranscriptCount++
-
 	// Store transcript entry in ephemeral storage
 	transcriptEntry := TranscriptEntry{
 		Timestamp:  time.Now(),
@@ -598,68 +891,93 @@ func (im *InterviewManager) handleTranscript(session *InterviewSession, result s
 		Confidence: result.Confidence,
 		SessionID:  result.SessionID,
 	}
+
+	session.mu.Lock()
+	// Track AssemblyAI session ID if we receive it
+	if result.SessionID != "" && session.AssemblyAIID == "" {
+		session.AssemblyAIID = result.SessionID
+		session.log.Info("Tracking AssemblyAI session")
+	}
+	if result.MessageType == "SessionBegins" && result.SessionID != "" {
+		session.AssemblyAIID = result.SessionID
+		session.log.Info("New AssemblyAI session established")
+	}
+
+	session.TranscriptCount++
+	if result.IsFinal {
+		observeMark(&session.lastSpeechAt, metrics.STTFinalLatency)
+		session.trace.transcribed(time.Now(), session.AssemblyAIID)
+	}
 	session.Transcript = append(session.Transcript, transcriptEntry)
+	if result.MessageType == "Turn" && result.Text != "" {
+		session.UtteranceCount++
+	}
+	assemblyAIID := session.AssemblyAIID
+	utteranceCount := session.UtteranceCount
+	session.mu.Unlock()
+
+	im.persistTranscriptEntry(session, transcriptEntry)
 
-	// Terminal output with clear formatting
+	transcript := sessionstate.TranscriptData{
+		Text:         result.Text,
+		Confidence:   result.Confidence,
+		AssemblyAIID: assemblyAIID,
+	}
 	switch result.MessageType {
-	case "SessionBegins":
-		if result.SessionID != "" {
-			session.AssemblyAIID = result.SessionID
-			log.Printf("[INFO] New AssemblyAI session established: %s for session: %s",
-				result.SessionID, session.ID)
-		}
 	case "PartialTranscript":
 		if result.Text != "" {
-			fmt.Printf("[%s] [PARTIAL] %s (conf: %.2f)\n",
-				session.ID[:8], result.Text, result.Confidence)
+			// Partials are superseded within moments, so they reach the client
+			// but are not kept on the timeline
+			session.log.Debug("Partial transcript", logging.Transcript(result.Text), "confidence", result.Confidence)
 		}
 	case "FinalTranscript":
 		if result.Text != "" {
-			fmt.Printf("[%s] [FINAL] %s (conf: %.2f)\n",
-				session.ID[:8], result.Text, result.Confidence)
+			session.log.Info("Final transcript", logging.Transcript(result.Text), "confidence", result.Confidence)
+
+			im.recordEvent(session, sessionstate.EventSTTFinal, transcript)
 
 			// Update session state with transcript
-			session.SessionState.UpdateState("last_transcript", result.Text)
-			session.SessionState.UpdateState("last_confidence", result.Confidence)
-			session.SessionState.UpdateState("last_timestamp", time.Now())
+			im.updateState(session, func(state *SessionStateObject) {
+				state.LastTranscript = result.Text
+				state.LastConfidence = result.Confidence
+				state.LastTranscriptAt = transcriptEntry.Timestamp
+			})
 		}
 	case "Turn":
 		if result.Text != "" {
-			session.UtteranceCount++
-			fmt.Printf("\n[%s] [UTTERANCE #%d] ================================\n",
-				session.ID[:8], session.UtteranceCount)
-			fmt.Printf("[%s] [UTTERANCE #%d] %s\n",
-				session.ID[:8], session.UtteranceCount, result.Text)
-			fmt.Printf("[%s] [UTTERANCE #%d] Confidence: %.2f\n",
-				session.ID[:8], session.UtteranceCount, result.Confidence)
-			fmt.Printf("[%s] [UTTERANCE #%d] ================================\n\n",
-				session.ID[:8], session.UtteranceCount)
+			session.log.Info("Utterance", "utterance", utteranceCount,
+				logging.Transcript(result.Text), "confidence", result.Confidence)
+
+			im.recordEvent(session, sessionstate.EventSTTTurn, transcript)
 
 			// Update session state with complete utterance
-			session.SessionState.UpdateState("utterance_count", session.UtteranceCount)
-			session.SessionState.UpdateState("last_utterance", result.Text)
-			session.SessionState.UpdateState("last_utterance_confidence", result.Confidence)
+			im.updateState(session, func(state *SessionStateObject) {
+				state.UtteranceCount = utteranceCount
+				state.LastUtterance = result.Text
+				state.LastUtteranceConfidence = result.Confidence
+			})
 
-			// TODO: Trigger context brain analysis for utterance
-			im.analyzeUtterance(session, result.Text)
+			im.respond(session, result.Text)
 		}
 	}
 
-	// Send transcript back to frontend via WebSocket
-	if session.WebSocketConn != nil {
-		transcriptMsg := map[string]interface{}{
-			"type":         "transcript",
-			"message_type": result.MessageType,
-			"text":         result.Text,
-			"confidence":   result.Confidence,
-			"is_final":     result.IsFinal,
-			"timestamp":    time.Now().Unix(),
-			"session_id":   session.AssemblyAIID,
-		}
+	// Persist anything that changes the resumable state of the session
+	switch result.MessageType {
+	case "SessionBegins", "FinalTranscript", "Turn":
+		im.persistSession(session)
+	}
 
-		if err := session.WebSocketConn.WriteJSON(transcriptMsg); err != nil {
-			log.Printf("[ERROR] Failed to send transcript to client: %v", err)
-		}
+	// Send transcript back to frontend, keeping it for clients that resume
+	transcriptMsg := transport.TranscriptMessage{
+		MessageType: result.MessageType,
+		Text:        result.Text,
+		Confidence:  result.Confidence,
+		IsFinal:     result.IsFinal,
+		SessionID:   assemblyAIID,
+	}
+
+	if err := session.Outbox.Publish(transport.TypeTranscript, transcriptMsg); err != nil {
+		session.log.Error("Failed to send transcript to client", logging.Err(err))
 	}
 }
 
@@ -677,260 +995,153 @@ func getTranscriptType(messageType string) string {
 	}
 }
 
-// analyzeUtterance analyzes the utterance against lesson context (placeholder for context brain)
-func (im *InterviewManager) analyzeUtterance(session *InterviewSession, utteranceText string) {
-	// TODO: Implement context brain analysis
-	// This will analyze the utterance against:
-	// - Current question's expected components
-	// - Guide steps for structural analysis
-	// - Session state for progress tracking
-	// - Persona for appropriate response generation
-
-	log.Printf("[INFO] Context brain analysis needed for session %s, utterance: %s",
-		session.ID, utteranceText)
-}
+// handleAudioStream processes incoming audio data from WebSocket. Messages the
+// client missed after lastSeq are replayed once the handshake completes
+func (im *InterviewManager) handleAudioStream(session *InterviewSession, conn *transport.Conn, lastSeq int64) {
+	session.mu.RLock()
+	format := sessionAudioFormat(session.StreamingSTT.GetConfig())
+	session.mu.RUnlock()
 
-// handleAudioStream processes incoming audio data from WebSocket
-func (im *InterviewManager) handleAudioStream(session *InterviewSession) {
 	defer func() {
-		session.mu.Lock()
-		if session.WebSocketConn != nil {
-			session.WebSocketConn.Close()
-			session.WebSocketConn = nil
-		}
-		session.Status = "disconnected"
-		if session.StreamingSTT != nil {
-			session.StreamingSTT.Close()
-		}
-		session.cancel()
-		session.mu.Unlock()
-
-		log.Printf("[INFO] WebSocket disconnected for session: %s", session.ID)
+		session.Outbox.Detach(conn)
+		im.releaseSession(session)
+		metrics.ClientDisconnects.WithLabelValues(transportWebSocket).Inc()
+		session.log.Info("WebSocket disconnected")
 	}()
 
-	var lastVoiceTime time.Time
-	var silenceDuration time.Duration
-	var continuousSilenceCount int
-	const (
-		endOfUtteranceThreshold = 1200 * time.Millisecond // 1.2 seconds of silence for end of utterance
-		maxUtteranceDuration    = 30 * time.Second        // Maximum duration for a single utterance
-		minUtteranceDuration    = 500 * time.Millisecond  // Minimum duration to consider as valid utterance
-		silenceCheckInterval    = 100 * time.Millisecond  // How often to check silence duration
-		maxSilenceCount         = 12                      // Number of silence intervals before ending utterance
-		reconnectDelay          = 1000 * time.Millisecond // Delay before reconnecting (for errors only)
-	)
-
-	inUtterance := false
-	utteranceStartTime := time.Time{}
-	reconnectAttempts := 0
-	const maxReconnectAttempts = 3
-
-	// Only reconnect on actual connection errors, not for end of utterance
-	reconnectSTTOnError := func() error {
-		if reconnectAttempts >= maxReconnectAttempts {
-			return fmt.Errorf("exceeded maximum reconnection attempts")
-		}
-		reconnectAttempts++
-
-		log.Printf("[ERROR] STT connection error, initiating reconnection for session %s (attempt %d)",
-			session.ID, reconnectAttempts)
-
-		// Wait before reconnecting to allow cleanup
-		time.Sleep(reconnectDelay)
-
-		// Close existing connection gracefully and wait for it to fully close
-		if session.StreamingSTT != nil {
-			log.Printf("[INFO] Closing existing STT connection for session %s", session.ID)
-			if err := session.StreamingSTT.Close(); err != nil {
-				log.Printf("[WARN] Error closing STT connection: %v", err)
-			}
-			// Give time for the connection to fully close
-			time.Sleep(500 * time.Millisecond)
-		}
-
-		// Create completely new STT instance with fresh configuration
-		config := stt.StreamingConfig{
-			SampleRate:                       16000,
-			Encoding:                         "pcm_s16le",
-			FormatTurns:                      true,
-			EndOfTurnConfidenceThreshold:     0.7,
-			MinEndOfTurnSilenceWhenConfident: 1000,
-			MaxTurnSilence:                   3000,
-		}
-		session.StreamingSTT = stt.NewStreamingSTT(config)
-
-		// Connect with retry logic
-		var connectErr error
-		for i := 0; i < 3; i++ {
-			connectErr = session.StreamingSTT.Connect(session.ctx)
-			if connectErr == nil {
-				break
-			}
-			log.Printf("[WARN] STT connection attempt %d failed for session %s: %v",
-				i+1, session.ID, connectErr)
-			if i < 2 {
-				time.Sleep(500 * time.Millisecond)
-			}
-		}
-
-		if connectErr != nil {
-			log.Printf("[ERROR] Failed to reconnect STT for session %s after %d attempts: %v",
-				session.ID, reconnectAttempts, connectErr)
-			return connectErr
-		}
-
-		log.Printf("[INFO] Successfully reconnected STT for session %s (attempt %d)",
-			session.ID, reconnectAttempts)
-
-		// Reset AssemblyAI session ID since we have a new connection
-		session.mu.Lock()
-		session.AssemblyAIID = ""
-		session.mu.Unlock()
+	// Negotiate the protocol before any audio is processed
+	if err := conn.Handshake(session.ID, format, handshakeTimeout); err != nil {
+		session.log.Error("WebSocket handshake failed", logging.Err(err))
+		return
+	}
+	session.log.Info("Protocol version negotiated", "version", conn.Version())
 
-		return nil
+	// Catch the client up before any new message reaches it
+	replayed, complete := session.Outbox.Attach(conn, lastSeq)
+	if replayed > 0 || !complete {
+		session.log.Info("Replayed missed messages", "replayed", replayed, "last_seq", lastSeq, "complete", complete)
+	}
+	if !complete {
+		// Some missed messages were evicted or sent by another instance
+		conn.Send(transport.TypeStatus, transport.StatusMessage{
+			SessionID: session.ID,
+			Status:    "resync",
+			Details:   "some missed messages are no longer available, reload the session state and timeline",
+		})
 	}
 
-	silenceTimer := time.NewTicker(silenceCheckInterval)
-	defer silenceTimer.Stop()
+	im.sendToClient(session, transport.TypeStatus, transport.StatusMessage{
+		SessionID: session.ID,
+		Status:    "connected",
+	})
 
-	for {
-		select {
-		case <-session.ctx.Done():
-			return
-		case <-silenceTimer.C:
-			if inUtterance {
-				now := time.Now()
-				silenceDuration = now.Sub(lastVoiceTime)
-
-				// Only count silence after minimum utterance duration
-				if now.Sub(utteranceStartTime) > minUtteranceDuration {
-					if silenceDuration >= silenceCheckInterval {
-						continuousSilenceCount++
-						if continuousSilenceCount >= maxSilenceCount {
-							inUtterance = false
-							fmt.Printf("\n[%s] [UTTERANCE-END] ⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻\n", session.ID[:8])
-							fmt.Printf("[%s] [RESPONSE-TRIGGER] Preparing to generate response after %.1f seconds of silence\n",
-								session.ID[:8], silenceDuration.Seconds())
-							fmt.Printf("[%s] [UTTERANCE-END] ⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻\n\n", session.ID[:8])
-
-							// TODO: Trigger response generation here
-							// Keep STT connection alive for continued listening
-							log.Printf("[INFO] End of utterance detected for session %s - ready for response generation", session.ID)
-						} else {
-							fmt.Printf("[%s] [SILENCE] Waiting for more speech... (%.1fs) [%d/%d]\n",
-								session.ID[:8], silenceDuration.Seconds(), continuousSilenceCount, maxSilenceCount)
-						}
-					}
-				}
-			}
-		default:
-			// Read audio data from WebSocket
-			_, audioData, err := session.WebSocketConn.ReadMessage()
-			if err != nil {
-				if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
-					log.Printf("[INFO] WebSocket closed normally for session %s", session.ID)
-					return
-				}
-				log.Printf("[ERROR] Error reading WebSocket message: %v", err)
-				return
-			}
+	ingest, err := NewAudioIngest(IngestConfig{
+		Format:    format,
+		Sequenced: conn.SequencedAudio(),
+	}, func(msg transport.Message) bool {
+		return im.handleControl(session, conn, msg)
+	})
+	if err != nil {
+		session.log.Error("Cannot ingest audio", logging.Err(err))
+		conn.SendError(transport.ErrorCodeAudio, err.Error())
+		return
+	}
 
-			// Process with VAD
-			hasVoice, err := session.VAD.DetectActivity(audioData)
-			if err != nil {
-				log.Printf("[ERROR] VAD error: %v", err)
-				continue
-			}
+	im.runIngest(session, ingest, func() {
+		im.readFrames(session, conn, ingest)
+	})
 
-			now := time.Now()
+	queues := conn.QueueStats()
+	session.log.Info("Outbound queues closed",
+		"dropped_transcript", queues.Dropped[transport.PriorityTranscript.String()],
+		"dropped_audio", queues.Dropped[transport.PriorityAudio.String()])
+}
 
-			// Check for maximum utterance duration
-			if inUtterance && now.Sub(utteranceStartTime) > maxUtteranceDuration {
-				inUtterance = false
-				fmt.Printf("\n[%s] [UTTERANCE-END] ⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻\n", session.ID[:8])
-				fmt.Printf("[%s] [MAX-DURATION] Maximum utterance duration reached (30s)\n", session.ID[:8])
-				fmt.Printf("[%s] [UTTERANCE-END] ⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻⸻\n\n", session.ID[:8])
+// releaseSession marks a session disconnected once its client stops
+// streaming. Speech recognition stops until the client comes back
+func (im *InterviewManager) releaseSession(session *InterviewSession) {
+	session.mu.Lock()
+	client := session.Client
+	session.Client = nil
+	closed := session.Status == "closed"
+	if !closed {
+		session.Status = "disconnected"
+		session.DisconnectedAt = time.Now()
+	}
+	recognizer := session.StreamingSTT
+	session.cancel()
+	session.mu.Unlock()
 
-				// TODO: Trigger response generation here
-				// Keep STT connection alive for continued listening
-				log.Printf("[INFO] Max duration utterance ended for session %s - ready for response generation", session.ID)
-				continue
-			}
+	// Closing waits for queued messages to drain, so it happens unlocked
+	if client != nil {
+		client.Close()
+	}
+	if recognizer != nil {
+		recognizer.Close()
+	}
 
-			// Send to streaming STT if voice detected
-			if hasVoice {
-				if session.StreamingSTT == nil {
-					log.Printf("[WARN] StreamingSTT is nil, skipping audio data")
-					continue
-				}
+	// A closed session is being finalized and must not be written back
+	if !closed {
+		im.persistSession(session)
+		im.stopSpeaking(session, true)
+		im.recordEvent(session, sessionstate.EventClientDisconnected, nil)
+	}
+}
 
-				err = session.StreamingSTT.SendAudio(audioData)
-				if err != nil {
-					log.Printf("[ERROR] Error sending audio to STT: %v", err)
+// runIngest runs the audio pipeline while read feeds the ingest, and logs the
+// ingest stats once both have finished
+func (im *InterviewManager) runIngest(session *InterviewSession, ingest *AudioIngest, read func()) {
+	pipelineDone := make(chan struct{})
+	go func() {
+		im.runAudioPipeline(session, ingest.Frames())
+		close(pipelineDone)
+	}()
 
-					// Attempt to reconnect only on actual connection errors
-					if err := reconnectSTTOnError(); err != nil {
-						log.Printf("[ERROR] Failed to recover STT connection: %v", err)
-						return
-					}
-					continue
-				}
+	read()
+	ingest.Close()
+	<-pipelineDone
 
-				lastVoiceTime = now
-				continuousSilenceCount = 0 // Reset silence counter when voice is detected
+	stats := ingest.Stats()
+	session.log.Info("Audio ingest finished", "frames", stats.Frames, "bytes", stats.Bytes, "rejected", stats.Rejected,
+		"dropped", stats.Dropped, "gaps", stats.Gaps, "reordered", stats.Reordered)
+}
 
-				if !inUtterance {
-					inUtterance = true
-					utteranceStartTime = now
-					reconnectAttempts = 0 // Reset reconnect counter for new utterance
-					fmt.Printf("\n[%s] [UTTERANCE-START] User started speaking\n", session.ID[:8])
-				}
+// closeSession terminates a session at the client's request. It reports
+// false if the session does not exist
+func (im *InterviewManager) closeSession(sessionID string) bool {
+	im.mu.RLock()
+	session, exists := im.sessions[sessionID]
+	im.mu.RUnlock()
 
-				fmt.Printf("[%s] [VOICE] Audio chunk sent to STT (%d bytes)\n",
-					session.ID[:8], len(audioData))
-			}
-		}
+	if exists {
+		exists = im.terminateSession(session, CloseReasonClient)
+	}
+	if !exists {
+		// The session may only live in the backend if this instance never served it
+		exists = im.markPersistedSessionClosed(sessionID)
 	}
+	return exists
 }
 
 // CloseSession terminates a session
 func (im *InterviewManager) CloseSession(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	sessionID := r.URL.Query().Get("session_id")
 	if sessionID == "" {
 		http.Error(w, "session_id required", http.StatusBadRequest)
 		return
 	}
-
-	im.mu.Lock()
-	session, exists := im.sessions[sessionID]
-	if exists {
-		// Only close if session exists and is connected
-		if session.Status == "connected" {
-			session.mu.Lock()
-			if session.WebSocketConn != nil {
-				session.WebSocketConn.Close()
-				session.WebSocketConn = nil
-			}
-			session.Status = "disconnected"
-			session.cancel()
-			session.mu.Unlock()
-
-			// Close streaming STT
-			session.StreamingSTT.Close()
-		}
-		delete(im.sessions, sessionID)
+	if !im.authorizeSession(w, r, sessionID) {
+		return
 	}
-	im.mu.Unlock()
 
-	if !exists {
+	if !im.closeSession(sessionID) {
 		http.Error(w, "Session not found", http.StatusNotFound)
 		return
 	}
 
-	log.Printf("[INFO] Interview session closed: %s", sessionID)
+	im.sessionLogger(sessionID).Info("Interview session closed")
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
@@ -0,0 +1,134 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/torteous44/callservice/internal/limits"
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/sessionstate"
+	"github.com/torteous44/callservice/pkg/transport"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LimitErrorResponse is the body of a 429 response
+type LimitErrorResponse struct {
+	Error             string `json:"error"` // rate_limited or too_many_sessions
+	Scope             string `json:"scope"` // user, ip, global or instance
+	Message           string `json:"message"`
+	RetryAfterSeconds int    `json:"retry_after_seconds,omitempty"`
+}
+
+// writeLimitError writes a 429 response for a refused request
+func writeLimitError(w http.ResponseWriter, err *limits.Error) {
+	if err.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(err.RetryAfterSeconds()))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(LimitErrorResponse{
+		Error:             err.Code,
+		Scope:             err.Scope,
+		Message:           err.Message,
+		RetryAfterSeconds: err.RetryAfterSeconds(),
+	})
+}
+
+// limitStatus converts a refused request into a gRPC status
+func limitStatus(err *limits.Error) error {
+	return status.Error(codes.ResourceExhausted, err.Error())
+}
+
+// admitSession checks the session creation rate limits of a user creating a
+// session from ip
+func (im *InterviewManager) admitSession(user, ip string) error {
+	if err := im.limits.AllowCreate(user, ip, time.Now()); err != nil {
+		im.logger.Warn("Refusing new session", "user", user, "ip", ip, logging.Err(err))
+		return err
+	}
+	return nil
+}
+
+// checkActiveSessions checks the concurrent session caps before a session of
+// user is added. Caller must hold im.mu
+func (im *InterviewManager) checkActiveSessions(user string) error {
+	instanceActive, userActive := im.activeSessions(user)
+	if err := im.limits.CheckActive(user, userActive, instanceActive); err != nil {
+		im.logger.Warn("Refusing new session", "user", user, logging.Err(err))
+		return err
+	}
+	return nil
+}
+
+// activeSessions counts the sessions active on the instance, and those of
+// user among them. Caller must hold im.mu
+func (im *InterviewManager) activeSessions(user string) (instanceActive, userActive int) {
+	for _, session := range im.sessions {
+		session.mu.RLock()
+		closed := session.Status == "closed"
+		session.mu.RUnlock()
+		if closed {
+			continue
+		}
+		instanceActive++
+		if user != "" && session.OwnerID == user {
+			userActive++
+		}
+	}
+	return instanceActive, userActive
+}
+
+// Load returns the sessions active on the instance and the cap on them, 0 if
+// there is none
+func (im *InterviewManager) Load() (active, capacity int) {
+	im.mu.RLock()
+	active, _ = im.activeSessions("")
+	im.mu.RUnlock()
+	return active, im.limits.MaxSessions()
+}
+
+// chargeAudio records audio streamed by the candidate against the session
+// budget, ending the session once it is exhausted. It reports false if the
+// session is over budget
+func (im *InterviewManager) chargeAudio(session *InterviewSession, d time.Duration) bool {
+	return im.enforceBudget(session, session.Spend.ChargeAudio(d))
+}
+
+// enforceBudget ends a session whose budget a charge exhausted, telling the
+// client why. It reports false if err is a budget error
+func (im *InterviewManager) enforceBudget(session *InterviewSession, err error) bool {
+	var limitErr *limits.Error
+	if !errors.As(err, &limitErr) {
+		return true
+	}
+
+	session.log.Warn("Ending session over budget", logging.Err(err))
+	im.sendToClient(session, transport.TypeError, transport.ErrorMessage{
+		Code:    transport.ErrorCodeBudgetExceeded,
+		Message: limitErr.Message,
+	})
+	im.terminateSession(session, CloseReasonBudget)
+	return false
+}
+
+// usageRecord converts a session's spend for the backend
+func usageRecord(spend limits.Spend) sessionstate.Usage {
+	return sessionstate.Usage{
+		AudioMS:       spend.Audio.Milliseconds(),
+		LLMTokens:     spend.LLMTokens,
+		TTSCharacters: spend.TTSCharacters,
+	}
+}
+
+// recordSpend converts a persisted session's spend
+func recordSpend(usage sessionstate.Usage) limits.Spend {
+	return limits.Spend{
+		Audio:         time.Duration(usage.AudioMS) * time.Millisecond,
+		LLMTokens:     usage.LLMTokens,
+		TTSCharacters: usage.TTSCharacters,
+	}
+}
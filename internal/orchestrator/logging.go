@@ -0,0 +1,42 @@
+package orchestrator
+
+import (
+	"log/slog"
+
+	"github.com/torteous44/callservice/internal/audio/stt"
+	"github.com/torteous44/callservice/internal/logging"
+)
+
+// attachLogger gives a session its logger, tagged with the session, its
+// lesson and the AssemblyAI session it streams to, and hands it to the
+// session's detector. Sessions must have their lesson set first
+func (im *InterviewManager) attachLogger(session *InterviewSession) {
+	lessonID := ""
+	if session.Lesson != nil {
+		lessonID = session.Lesson.LessonID
+	}
+	session.assemblyAITag = logging.NewTag(logging.KeyAssemblyAI)
+	session.assemblyAITag.Set(session.AssemblyAIID)
+	session.log = logging.WithTag(im.logger.With(logging.KeySession, session.ID, logging.KeyLesson, lessonID), session.assemblyAITag)
+	session.VAD.SetLogger(session.log)
+}
+
+// newSTT creates a recognizer for a session, authenticated with the
+// configured API key and endpoint unless config has its own
+func (im *InterviewManager) newSTT(session *InterviewSession, config stt.StreamingConfig) *stt.StreamingSTT {
+	if config.APIKey == "" {
+		config.APIKey = im.sttDefaults.APIKey
+	}
+	if config.URL == "" {
+		config.URL = im.sttDefaults.URL
+	}
+	recognizer := stt.NewStreamingSTT(config)
+	recognizer.SetLogger(session.log, session.assemblyAITag)
+	return recognizer
+}
+
+// sessionLogger returns a logger for a session known only by its ID, such as
+// one a request names before it is looked up
+func (im *InterviewManager) sessionLogger(sessionID string) *slog.Logger {
+	return im.logger.With(logging.KeySession, sessionID)
+}
@@ -0,0 +1,74 @@
+package orchestrator
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/torteous44/callservice/internal/metrics"
+)
+
+// Transports candidates connect over, as metric labels
+const (
+	transportWebSocket = "websocket"
+	transportHTTP      = "http"
+	transportWebRTC    = "webrtc"
+	transportGRPC      = "grpc"
+	transportTelephony = "telephony"
+)
+
+// sessionStatuses are always reported by the sessions gauge, so that a
+// status without sessions reads 0 rather than disappearing
+var sessionStatuses = []string{"initialized", "connected", "disconnected"}
+
+// sessionCollector reports the sessions of a manager by status on scrape
+type sessionCollector struct {
+	im *InterviewManager
+}
+
+// MetricsCollector returns a collector of the sessions held by this instance
+func (im *InterviewManager) MetricsCollector() prometheus.Collector {
+	return sessionCollector{im: im}
+}
+
+func (c sessionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- metrics.Sessions
+}
+
+func (c sessionCollector) Collect(ch chan<- prometheus.Metric) {
+	counts := make(map[string]int)
+	for _, status := range sessionStatuses {
+		counts[status] = 0
+	}
+	for _, session := range c.im.liveSessions() {
+		session.mu.RLock()
+		counts[session.Status]++
+		session.mu.RUnlock()
+	}
+	for status, count := range counts {
+		ch <- prometheus.MustNewConstMetric(metrics.Sessions, prometheus.GaugeValue, float64(count), status)
+	}
+}
+
+// recordFrame counts a candidate audio frame and how the VAD classified it
+func recordFrame(frame AudioFrame, hasVoice bool) {
+	metrics.AudioBytes.WithLabelValues("in").Add(float64(len(frame.Data)))
+	result := "silence"
+	if hasVoice {
+		result = "speech"
+	}
+	metrics.VADAudioSeconds.WithLabelValues(result).Add(frame.Duration.Seconds())
+}
+
+// markTime records t in a timing mark
+func markTime(mark *atomic.Int64, t time.Time) {
+	mark.Store(t.UnixNano())
+}
+
+// observeMark observes the time since a mark was set in h and clears it, so
+// each mark is observed once. Unset marks are skipped
+func observeMark(mark *atomic.Int64, h prometheus.Observer) {
+	if t := mark.Swap(0); t != 0 {
+		h.Observe(time.Since(time.Unix(0, t)).Seconds())
+	}
+}
@@ -0,0 +1,438 @@
+package orchestrator
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/sessionstate"
+	"github.com/torteous44/callservice/pkg/transport"
+)
+
+// observer is a connection watching a session
+type observer struct {
+	conn  *transport.Conn
+	role  string // ScopeObserve or ScopeCoach
+	audio bool   // Mirror candidate and interviewer audio
+}
+
+// observerHub tracks the observers of each session served by this instance
+type observerHub struct {
+	sessions map[string]map[*observer]struct{}
+	mu       sync.RWMutex
+	logger   *slog.Logger
+}
+
+func newObserverHub(logger *slog.Logger) *observerHub {
+	return &observerHub{sessions: make(map[string]map[*observer]struct{}), logger: logger}
+}
+
+func (h *observerHub) add(sessionID string, o *observer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.sessions[sessionID] == nil {
+		h.sessions[sessionID] = make(map[*observer]struct{})
+	}
+	h.sessions[sessionID][o] = struct{}{}
+}
+
+func (h *observerHub) remove(sessionID string, o *observer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.sessions[sessionID], o)
+	if len(h.sessions[sessionID]) == 0 {
+		delete(h.sessions, sessionID)
+	}
+}
+
+// list returns the observers of a session
+func (h *observerHub) list(sessionID string) []*observer {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	observers := make([]*observer, 0, len(h.sessions[sessionID]))
+	for o := range h.sessions[sessionID] {
+		observers = append(observers, o)
+	}
+	return observers
+}
+
+// count returns the number of observers of a session
+func (h *observerHub) count(sessionID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.sessions[sessionID])
+}
+
+// broadcast sends a message to every observer of a session. Audio messages
+// only go to observers that asked for audio. Sends never block, so a slow
+// observer cannot hold up the interview
+func (h *observerHub) broadcast(sessionID, messageType string, payload interface{}) {
+	observers := h.list(sessionID)
+	if len(observers) == 0 {
+		return
+	}
+
+	msg, err := transport.NewMessage(messageType, payload)
+	if err != nil {
+		h.logger.Error("Failed to build message for observers", logging.KeySession, sessionID,
+			"message_type", messageType, logging.Err(err))
+		return
+	}
+	audio := transport.PriorityFor(messageType) == transport.PriorityAudio
+	for _, o := range observers {
+		if audio && !o.audio {
+			continue
+		}
+		o.conn.SendMessage(msg)
+	}
+}
+
+// broadcastAudio mirrors candidate audio to observers that asked for it
+func (h *observerHub) broadcastAudio(sessionID string, data []byte) {
+	for _, o := range h.list(sessionID) {
+		if o.audio {
+			o.conn.SendAudio(data)
+		}
+	}
+}
+
+// closeSession tells the observers of a session that it ended and disconnects them
+func (h *observerHub) closeSession(sessionID string) {
+	h.broadcast(sessionID, transport.TypeStatus, transport.StatusMessage{
+		SessionID: sessionID,
+		Status:    "closed",
+	})
+	for _, o := range h.list(sessionID) {
+		go o.conn.Close()
+	}
+}
+
+// closeAll disconnects every observer, waiting for their queued messages to
+// be flushed
+func (h *observerHub) closeAll() {
+	h.mu.RLock()
+	var observers []*observer
+	for _, session := range h.sessions {
+		for o := range session {
+			observers = append(observers, o)
+		}
+	}
+	h.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, o := range observers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o.conn.Close()
+		}()
+	}
+	wg.Wait()
+}
+
+// ObserverTokenRequest asks for a token to observe a session
+type ObserverTokenRequest struct {
+	SessionID string `json:"session_id"`
+	Role      string `json:"role,omitempty"` // "observe" (default) or "coach"
+}
+
+// ObserverTokenResponse carries a token for the observer endpoint
+type ObserverTokenResponse struct {
+	SessionID  string    `json:"session_id"`
+	Role       string    `json:"role"`
+	Token      string    `json:"token"`
+	ObserveURL string    `json:"observe_url"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// IssueObserverToken issues a token to watch or coach a session. Callers
+// authenticate with the observer key as a bearer token; candidate credentials
+// never grant observer access
+func (im *InterviewManager) IssueObserverToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if im.observerKey == "" {
+		http.Error(w, "Observer access is not configured", http.StatusForbidden)
+		return
+	}
+	key, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || subtle.ConstantTimeCompare([]byte(key), []byte(im.observerKey)) != 1 {
+		http.Error(w, "Invalid observer key", http.StatusUnauthorized)
+		return
+	}
+
+	var req ObserverTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.SessionID == "" {
+		http.Error(w, "session_id required", http.StatusBadRequest)
+		return
+	}
+	if req.Role == "" {
+		req.Role = ScopeObserve
+	}
+	if req.Role != ScopeObserve && req.Role != ScopeCoach {
+		http.Error(w, "role must be observe or coach", http.StatusBadRequest)
+		return
+	}
+
+	if !im.sessionExists(req.SessionID) {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	token, err := im.tokens.Issue(req.SessionID, req.Role, im.sessionTTL)
+	if err != nil {
+		im.sessionLogger(req.SessionID).Error("Failed to issue observer token", logging.Err(err))
+		http.Error(w, "Failed to issue token", http.StatusInternalServerError)
+		return
+	}
+
+	im.sessionLogger(req.SessionID).Info("Issued observer token", "role", req.Role)
+
+	json.NewEncoder(w).Encode(ObserverTokenResponse{
+		SessionID:  req.SessionID,
+		Role:       req.Role,
+		Token:      token,
+		ObserveURL: im.websocketURL(r, "/ws/interview/"+req.SessionID+"/observe"),
+		ExpiresAt:  time.Now().Add(im.sessionTTL),
+	})
+}
+
+// HandleObserve serves a read-only view of a live session: timeline events
+// (transcripts, state transitions, grades, hints) and optionally the audio of
+// both sides. Coaches may also whisper to steer the interview
+func (im *InterviewManager) HandleObserve(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.PathValue("id")
+
+	role, err := im.tokens.Verify(r.URL.Query().Get("token"), sessionID, ScopeObserve, ScopeCoach)
+	if err != nil {
+		im.sessionLogger(sessionID).Warn("Rejecting observer", logging.Err(err))
+		http.Error(w, "Valid observer token required", http.StatusUnauthorized)
+		return
+	}
+
+	var audio bool
+	if value := r.URL.Query().Get("audio"); value != "" {
+		audio, err = strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid audio", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Timeline events are only broadcast by the instance serving the session
+	session, exists := im.localSession(sessionID)
+	if !exists {
+		if im.sessionExists(sessionID) {
+			http.Error(w, "Session is served by another instance", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	conn, err := im.observerWS.Upgrade(w, r)
+	if err != nil {
+		session.log.Error("Observer WebSocket upgrade failed", logging.Err(err))
+		return
+	}
+	defer conn.Close()
+
+	session.mu.RLock()
+	format := sessionAudioFormat(session.StreamingSTT.GetConfig())
+	session.mu.RUnlock()
+
+	if err := conn.Handshake(session.ID, format, handshakeTimeout); err != nil {
+		session.log.Warn("Observer handshake failed", logging.Err(err))
+		return
+	}
+
+	o := &observer{conn: conn, role: role, audio: audio}
+	im.observers.add(session.ID, o)
+	defer im.observers.remove(session.ID, o)
+
+	session.log.Info("Observer connected", "role", role, "audio", audio)
+
+	// The observer gets the state now and again whenever it changes
+	changes, unsubscribe := session.SessionState.Subscribe()
+	defer unsubscribe()
+	conn.Send(transport.TypeState, session.SessionState.Snapshot())
+	go func() {
+		for change := range changes {
+			conn.Send(transport.TypeState, change.State)
+		}
+	}()
+	conn.Send(transport.TypeStatus, transport.StatusMessage{
+		SessionID: session.ID,
+		Status:    "observing",
+		Details:   role,
+	})
+
+	for {
+		frame, err := conn.ReadFrame()
+		if err != nil {
+			if !transport.IsCloseError(err) {
+				session.log.Info("Observer disconnected", "role", role, logging.Err(err))
+			}
+			break
+		}
+
+		if frame.Kind == transport.FrameAudio {
+			conn.SendError(transport.ErrorCodeForbidden, "observers cannot send audio")
+			continue
+		}
+		im.handleObserverControl(session, o, frame.Message)
+	}
+
+	session.log.Info("Observer left", "role", role)
+}
+
+// handleObserverControl applies a control message from an observer
+func (im *InterviewManager) handleObserverControl(session *InterviewSession, o *observer, msg transport.Message) {
+	switch msg.Type {
+	case transport.TypeWhisper:
+		if o.role != ScopeCoach {
+			o.conn.SendError(transport.ErrorCodeForbidden, "only coaches can whisper")
+			return
+		}
+		var whisper transport.WhisperMessage
+		if err := msg.Decode(&whisper); err != nil {
+			o.conn.SendError(transport.ErrorCodeBadMessage, err.Error())
+			return
+		}
+		if err := im.applyWhisper(session, whisper); err != nil {
+			o.conn.SendError(transport.ErrorCodeBadMessage, err.Error())
+			return
+		}
+		o.conn.Send(transport.TypeStatus, transport.StatusMessage{
+			SessionID: session.ID,
+			Status:    "whisper_applied",
+			Details:   whisper.Action,
+		})
+
+	case transport.TypePing:
+		o.conn.Send(transport.TypePong, msg.Payload)
+
+	case transport.TypePong, transport.TypeHeartbeat:
+		// Nothing to do, receiving it already counts as activity
+
+	case transport.TypeStart:
+		o.conn.SendError(transport.ErrorCodeBadMessage, "handshake already completed")
+
+	default:
+		o.conn.SendError(transport.ErrorCodeUnknownType, fmt.Sprintf("unknown message type %q", msg.Type))
+	}
+}
+
+// applyWhisper carries out a coach's whisper. The candidate must be connected
+// to this instance: otherwise the session here is a stale copy, and another
+// instance may be serving it
+func (im *InterviewManager) applyWhisper(session *InterviewSession, whisper transport.WhisperMessage) error {
+	if current, exists := im.localSession(session.ID); !exists || current != session || !session.hasClient() {
+		return errors.New("the candidate is not connected to this instance")
+	}
+	session.log.Info("Coach whisper", "action", whisper.Action)
+
+	switch whisper.Action {
+	case transport.WhisperHint:
+		return im.giveHint(session, whisper.Text, "coach")
+	case transport.WhisperNextQuestion:
+		return im.advanceQuestion(session)
+	default:
+		return fmt.Errorf("unknown whisper action %q", whisper.Action)
+	}
+}
+
+// giveHint sends the candidate a hint for the current question. Without text
+// the next unused hint of the question is given; free text leaves the
+// question's hints for later
+func (im *InterviewManager) giveHint(session *InterviewSession, text, source string) error {
+	var (
+		hint sessionstate.HintData
+		err  error
+	)
+	im.updateState(session, func(state *SessionStateObject) {
+		hint = sessionstate.HintData{
+			QuestionIndex: state.CurrentQuestion,
+			HintIndex:     -1,
+			Text:          text,
+			Source:        source,
+		}
+		if hint.Text != "" {
+			return
+		}
+		if state.CurrentQuestion >= len(session.Questions) ||
+			state.HintsUsed >= len(session.Questions[state.CurrentQuestion].Hints) {
+			err = fmt.Errorf("no hints left for question %d", state.CurrentQuestion)
+			return
+		}
+		hint.HintIndex = state.HintsUsed
+		hint.Text = session.Questions[state.CurrentQuestion].Hints[state.HintsUsed]
+		state.HintsUsed++
+	})
+	if err != nil {
+		return err
+	}
+
+	im.recordEvent(session, sessionstate.EventHintGiven, hint)
+	im.sendToClient(session, transport.TypeHint, transport.HintMessage{
+		QuestionIndex: hint.QuestionIndex,
+		Text:          hint.Text,
+		Source:        source,
+	})
+	return nil
+}
+
+// advanceQuestion moves the interview on from the question the state is at
+// now, resetting the per-question progress. It only applies to the state it
+// read, so two coaches asking at once advance by one question, not two
+func (im *InterviewManager) advanceQuestion(session *InterviewSession) error {
+	current := session.SessionState.Snapshot()
+	if current.CurrentQuestion+1 >= len(session.Questions) {
+		return fmt.Errorf("question %d is the last question", current.CurrentQuestion)
+	}
+	next := current.CurrentQuestion + 1
+
+	change, err := session.SessionState.CompareAndSwap(current.Version, func(state *SessionStateObject) {
+		state.CurrentQuestion = next
+		state.SilenceTimer = 0
+		state.HintsUsed = 0
+		state.ComponentsHit = make([]string, 0)
+		state.StepsHit = make([]string, 0)
+		state.FollowUpsUsed = make([]int, 0)
+		state.UserReady = false
+	})
+	if errors.Is(err, sessionstate.ErrVersionConflict) {
+		return fmt.Errorf("the state changed while leaving question %d, check it and retry", current.CurrentQuestion)
+	}
+	if err != nil {
+		return err
+	}
+	im.recordStateChange(session, change)
+
+	im.sendToClient(session, transport.TypeStatus, transport.StatusMessage{
+		SessionID: session.ID,
+		Status:    "question_changed",
+		Details:   session.Questions[next].QuestionPrompt,
+	})
+	return nil
+}
@@ -0,0 +1,41 @@
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/torteous44/callservice/internal/audio/stt"
+)
+
+func TestGiveHint(t *testing.T) {
+	tests := []struct {
+		name          string
+		texts         []string // Empty asks for the next lesson hint
+		wantErr       bool
+		wantHintsUsed int
+	}{
+		{"lesson hints", []string{"", ""}, false, 2},
+		{"coach hints", []string{"Think about fixed costs", "And variable ones"}, false, 0},
+		{"lesson hint after a coach hint", []string{"Think about fixed costs", ""}, false, 1},
+		{"no lesson hints left", []string{"", "", ""}, true, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im := NewInterviewManager(ManagerOptions{STT: stt.StreamingConfig{APIKey: "test"}})
+			session := newLessonSession(t, im)
+			session.Questions[0].Hints = []string{"Split it into revenue and costs", "Which costs rose?"}
+
+			var err error
+			for _, text := range tt.texts {
+				if err = im.giveHint(session, text, "coach"); err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("giveHint: err = %v, want error %v", err, tt.wantErr)
+			}
+			if used := session.SessionState.Snapshot().HintsUsed; used != tt.wantHintsUsed {
+				t.Errorf("HintsUsed = %d, want %d", used, tt.wantHintsUsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/torteous44/callservice/internal/audio/stt"
+	"github.com/torteous44/callservice/internal/audio/vad"
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/sessionstate"
+	"github.com/torteous44/callservice/pkg/transport"
+)
+
+// persistTimeout bounds every call to the session backend
+const persistTimeout = 2 * time.Second
+
+// lessonBundle groups the lesson data persisted alongside a session
+type lessonBundle struct {
+	Lesson        *LessonObject                `json:"lesson"`
+	Introduction  *IntroductionObject          `json:"introduction"`
+	Questions     []*QuestionObject            `json:"questions"`
+	GuideStepsMap map[string]*GuideStepsObject `json:"guide_steps_map"`
+	Conclusion    *ConclusionObject            `json:"conclusion"`
+	Persona       *PersonaObject               `json:"persona"`
+}
+
+// sessionRecord serializes a session for the backend. Caller must hold session.mu
+func sessionRecord(session *InterviewSession) (*sessionstate.Record, error) {
+	record := &sessionstate.Record{
+		ID:               session.ID,
+		Status:           session.Status,
+		OwnerID:          session.OwnerID,
+		StartTime:        session.StartTime,
+		UpdatedAt:        time.Now(),
+		TranscriptCount:  session.TranscriptCount,
+		UtteranceCount:   session.UtteranceCount,
+		AssemblyAIID:     session.AssemblyAIID,
+		PlaybackPosition: session.PlaybackPosition,
+	}
+
+	if session.Outbox != nil {
+		record.OutboxSeq = session.Outbox.LastSeq()
+	}
+	if session.Spend != nil {
+		record.Usage = usageRecord(session.Spend.Used())
+	}
+
+	if session.StreamingSTT != nil {
+		config, err := json.Marshal(session.StreamingSTT.GetConfig())
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal streaming config: %w", err)
+		}
+		record.StreamingConfig = config
+	}
+
+	if session.Lesson != nil {
+		lesson, err := json.Marshal(lessonBundle{
+			Lesson:        session.Lesson,
+			Introduction:  session.Introduction,
+			Questions:     session.Questions,
+			GuideStepsMap: session.GuideStepsMap,
+			Conclusion:    session.Conclusion,
+			Persona:       session.Persona,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal lesson: %w", err)
+		}
+		record.Lesson = lesson
+	}
+
+	if session.SessionState != nil {
+		state := session.SessionState.Snapshot()
+		record.State = &state
+	}
+
+	return record, nil
+}
+
+// persistSession saves the session record to the backend. The record is taken
+// under session.mu, which the caller must not hold, and written after it is
+// released. Saves of a session are serialized so the newest record is written
+// last, and a closed session is written once: after that it is being finalized
+// and must not be written back
+func (im *InterviewManager) persistSession(session *InterviewSession) {
+	session.persistMu.Lock()
+	defer session.persistMu.Unlock()
+
+	if session.persistStopped {
+		return
+	}
+
+	session.mu.RLock()
+	record, err := sessionRecord(session)
+	session.mu.RUnlock()
+	if err != nil {
+		session.log.Error("Failed to serialize session", logging.Err(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+	defer cancel()
+
+	if err := im.backend.SaveSession(ctx, record, im.sessionTTL); err != nil {
+		session.log.Warn("Failed to persist session", logging.Err(err))
+		return
+	}
+	session.persistedAt.Store(record.UpdatedAt.UnixNano())
+	session.persistStopped = record.Status == "closed"
+}
+
+// stopPersisting stops saving a session, once another instance took it over
+func (s *InterviewSession) stopPersisting() {
+	s.persistMu.Lock()
+	defer s.persistMu.Unlock()
+	s.persistStopped = true
+}
+
+// ownedElsewhere reports whether another instance took the session over or
+// closed it since this instance last saved or loaded its record
+func (im *InterviewManager) ownedElsewhere(session *InterviewSession) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+	defer cancel()
+
+	record, err := im.backend.LoadSession(ctx, session.ID)
+	if errors.Is(err, sessionstate.ErrNotFound) {
+		// Finalized by the instance that closed it
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return record.Status == "closed" || record.UpdatedAt.UnixNano() != session.persistedAt.Load(), nil
+}
+
+// persistTranscriptEntry appends a transcript entry to the backend
+func (im *InterviewManager) persistTranscriptEntry(session *InterviewSession, entry TranscriptEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		session.log.Error("Failed to serialize transcript entry", logging.Err(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+	defer cancel()
+
+	if err := im.backend.AppendTranscript(ctx, session.ID, data, im.sessionTTL); err != nil {
+		session.log.Warn("Failed to persist transcript", logging.Err(err))
+	}
+}
+
+// deletePersistedSession removes a session from the backend
+func (im *InterviewManager) deletePersistedSession(sessionID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+	defer cancel()
+
+	if err := im.backend.DeleteSession(ctx, sessionID); err != nil {
+		im.sessionLogger(sessionID).Warn("Failed to delete persisted session", logging.Err(err))
+	}
+}
+
+// markPersistedSessionClosed closes a session that only exists in the backend and
+// schedules its analytics flush, reporting whether it was found
+func (im *InterviewManager) markPersistedSessionClosed(sessionID string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+	defer cancel()
+
+	record, err := im.backend.LoadSession(ctx, sessionID)
+	if err != nil {
+		return false
+	}
+
+	record.Status = "closed"
+	record.UpdatedAt = time.Now()
+	if err := im.backend.SaveSession(ctx, record, im.sessionTTL); err != nil {
+		im.sessionLogger(sessionID).Warn("Failed to mark session closed", logging.Err(err))
+	}
+	if _, err := sessionstate.NewEventLog(sessionID, im.backend, im.sessionTTL).
+		Append(ctx, sessionstate.EventSessionClosed, sessionstate.SessionClosedData{Reason: CloseReasonClient}); err != nil {
+		im.sessionLogger(sessionID).Warn("Failed to record session_closed event", logging.Err(err))
+	}
+	im.scheduleFinalize(sessionID)
+	return true
+}
+
+// localSession returns a session from memory, if this instance holds it
+func (im *InterviewManager) localSession(sessionID string) (*InterviewSession, bool) {
+	im.mu.RLock()
+	defer im.mu.RUnlock()
+
+	session, exists := im.sessions[sessionID]
+	return session, exists
+}
+
+// loadSessionRecord reads the persisted record of a session that has not been
+// closed, returning sessionstate.ErrNotFound otherwise
+func (im *InterviewManager) loadSessionRecord(sessionID string) (*sessionstate.Record, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+	defer cancel()
+
+	record, err := im.backend.LoadSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Closed sessions are waiting to be flushed and cannot be resumed
+	if record.Status == "closed" {
+		return nil, sessionstate.ErrNotFound
+	}
+	return record, nil
+}
+
+// sessionExists reports whether a session is live, on this instance or on
+// another one
+func (im *InterviewManager) sessionExists(sessionID string) bool {
+	if _, exists := im.localSession(sessionID); exists {
+		return true
+	}
+
+	_, err := im.loadSessionRecord(sessionID)
+	if err != nil && !errors.Is(err, sessionstate.ErrNotFound) {
+		im.sessionLogger(sessionID).Error("Failed to load session", logging.Err(err))
+	}
+	return err == nil
+}
+
+// acquireSession returns a session from memory, rehydrating it from the backend
+// if another instance created it. Only the connection paths that claim the
+// session may call it: a rehydrated session belongs to this instance from then
+// on and is reaped here. Reads use localSession and loadSessionRecord instead
+func (im *InterviewManager) acquireSession(sessionID string) (*InterviewSession, bool) {
+	if session, exists := im.localSession(sessionID); exists {
+		return session, true
+	}
+
+	restored, err := im.restoreSession(sessionID)
+	if err != nil {
+		if !errors.Is(err, sessionstate.ErrNotFound) {
+			im.sessionLogger(sessionID).Error("Failed to restore session", logging.Err(err))
+		}
+		return nil, false
+	}
+
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	// Another request may have restored the session while we were loading it
+	if session, exists := im.sessions[sessionID]; exists {
+		restored.cancel()
+		return session, true
+	}
+	restored.trace = startTrace(restored, true)
+	im.sessions[sessionID] = restored
+	restored.SessionState = im.store.RestoreSession(sessionID, restored.SessionState.Snapshot())
+
+	restored.log.Info("Rehydrated session from backend", "transcript_entries", len(restored.Transcript))
+
+	return restored, true
+}
+
+// recordStreamingConfig returns the streaming configuration of a persisted session
+func recordStreamingConfig(record *sessionstate.Record) (stt.StreamingConfig, error) {
+	config := stt.GetDefaultStreamingConfig()
+	if len(record.StreamingConfig) > 0 {
+		if err := json.Unmarshal(record.StreamingConfig, &config); err != nil {
+			return config, fmt.Errorf("failed to unmarshal streaming config: %w", err)
+		}
+	}
+	return config, nil
+}
+
+// recordState returns the interview state of a persisted session
+func recordState(record *sessionstate.Record) sessionstate.InterviewState {
+	if record.State != nil {
+		return *record.State
+	}
+	return sessionstate.DefaultInterviewState()
+}
+
+// restoreSession rebuilds an InterviewSession from its persisted record
+func (im *InterviewManager) restoreSession(sessionID string) (*InterviewSession, error) {
+	record, err := im.loadSessionRecord(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+	defer cancel()
+
+	rawTranscript, err := im.backend.LoadTranscript(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := recordStreamingConfig(record)
+	if err != nil {
+		return nil, err
+	}
+
+	transcript := make([]TranscriptEntry, 0, len(rawTranscript))
+	for _, raw := range rawTranscript {
+		var entry TranscriptEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal transcript entry: %w", err)
+		}
+		transcript = append(transcript, entry)
+	}
+
+	sessionCtx, sessionCancel := context.WithCancel(context.Background())
+
+	// A restored session has no live connection on this instance, so it can
+	// only be resumed through the reconnection path
+	session := &InterviewSession{
+		ID:        record.ID,
+		StartTime: record.StartTime,
+		Status:    "disconnected",
+		OwnerID:   record.OwnerID,
+		VAD:       vad.NewVADWithConfig(im.vadConfig),
+		Events:    sessionstate.NewEventLog(record.ID, im.backend, im.sessionTTL),
+		// Messages sent before the hop are not available here, but numbering
+		// continues so clients can tell they missed them
+		Outbox:           transport.NewOutbox(0, 0, record.OutboxSeq),
+		Spend:            im.limits.NewMeter(recordSpend(record.Usage)),
+		TranscriptCount:  record.TranscriptCount,
+		UtteranceCount:   record.UtteranceCount,
+		AssemblyAIID:     record.AssemblyAIID,
+		PlaybackPosition: record.PlaybackPosition,
+		DisconnectedAt:   time.Now(),
+		ctx:              sessionCtx,
+		cancel:           sessionCancel,
+		Transcript:       transcript,
+	}
+	session.touch()
+	session.persistedAt.Store(record.UpdatedAt.UnixNano())
+
+	if len(record.Lesson) > 0 {
+		var bundle lessonBundle
+		if err := json.Unmarshal(record.Lesson, &bundle); err != nil {
+			sessionCancel()
+			return nil, fmt.Errorf("failed to unmarshal lesson: %w", err)
+		}
+		session.Lesson = bundle.Lesson
+		session.Introduction = bundle.Introduction
+		session.Questions = bundle.Questions
+		session.GuideStepsMap = bundle.GuideStepsMap
+		session.Conclusion = bundle.Conclusion
+		session.Persona = bundle.Persona
+	}
+	im.attachLogger(session)
+	session.StreamingSTT = im.newSTT(session, config)
+
+	session.SessionState = sessionstate.RestoreSessionState(record.ID, recordState(record))
+
+	return session, nil
+}
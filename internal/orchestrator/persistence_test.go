@@ -0,0 +1,123 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/torteous44/callservice/internal/audio/stt"
+	"github.com/torteous44/callservice/internal/sessionstate"
+)
+
+// newSharedManagers returns two instances sharing a Redis backend
+func newSharedManagers(t *testing.T) (*InterviewManager, *InterviewManager) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	newManager := func() *InterviewManager {
+		backend, err := sessionstate.NewRedisBackend("redis://" + server.Addr())
+		if err != nil {
+			t.Fatalf("NewRedisBackend: %v", err)
+		}
+		t.Cleanup(func() { backend.Close() })
+		return NewInterviewManager(ManagerOptions{
+			Backend: backend,
+			STT:     stt.StreamingConfig{APIKey: "test"},
+		})
+	}
+	return newManager(), newManager()
+}
+
+func TestSessionRoundTripAcrossInstances(t *testing.T) {
+	first, second := newSharedManagers(t)
+	session := newLessonSession(t, first)
+	first.handleTranscript(session, stt.StreamingResult{
+		MessageType: "Turn",
+		Text:        "I would split profit into revenue and costs.",
+		Confidence:  0.9,
+		IsFinal:     true,
+	})
+
+	// Reads are served from the record without claiming the session
+	status, ok := second.lookupSessionStatus(session.ID)
+	if !ok {
+		t.Fatal("session not found on the second instance")
+	}
+	if status.TranscriptCount != 1 || status.UtteranceCount != 1 {
+		t.Errorf("status counts = %d transcripts, %d utterances, want 1 and 1", status.TranscriptCount, status.UtteranceCount)
+	}
+	if _, local := second.localSession(session.ID); local {
+		t.Fatal("reading the status rehydrated the session")
+	}
+	recorder := httptest.NewRecorder()
+	second.GetSessionState(recorder, httptest.NewRequest(http.MethodGet, "/api/interview/state?session_id="+session.ID, nil))
+	var state SessionStateObject
+	if err := json.NewDecoder(recorder.Body).Decode(&state); err != nil || state.UtteranceCount != 1 {
+		t.Errorf("GetSessionState = %d %+v, want the utterance count", recorder.Code, state)
+	}
+
+	// Claiming the session rebuilds it from the backend
+	restored, ok := second.acquireSession(session.ID)
+	if !ok {
+		t.Fatal("session not restored on the second instance")
+	}
+	if restored.Status != "disconnected" {
+		t.Errorf("restored status = %q, want disconnected", restored.Status)
+	}
+	if restored.Lesson == nil || restored.Lesson.LessonID != "lesson" || len(restored.Questions) != 1 {
+		t.Errorf("restored lesson = %+v with %d questions, want lesson with 1 question", restored.Lesson, len(restored.Questions))
+	}
+	if len(restored.Transcript) != 1 || restored.Transcript[0].Text != "I would split profit into revenue and costs." {
+		t.Errorf("restored transcript = %+v, want the utterance", restored.Transcript)
+	}
+	if state := restored.SessionState.Snapshot(); state.UtteranceCount != 1 {
+		t.Errorf("restored utterance count = %d, want 1", state.UtteranceCount)
+	}
+
+	// The first instance sees it was taken over once the new owner saves
+	second.persistSession(restored)
+	if elsewhere, err := first.ownedElsewhere(session); err != nil || !elsewhere {
+		t.Errorf("ownedElsewhere = %v, %v, want true", elsewhere, err)
+	}
+}
+
+func TestReconnectSTTWhilePersisting(t *testing.T) {
+	recognizer := newFakeAssemblyAI(t)
+	im := NewInterviewManager(ManagerOptions{
+		STT: stt.StreamingConfig{APIKey: "test", URL: "ws" + strings.TrimPrefix(recognizer.URL, "http")},
+	})
+	session := newLessonSession(t, im)
+	old := session.recognizer()
+
+	// Saving the session reads the recognizer's config while it is replaced
+	stop := make(chan struct{})
+	saved := make(chan struct{})
+	go func() {
+		defer close(saved)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				im.persistSession(session)
+			}
+		}
+	}()
+	err := im.reconnectSTT(session, 1)
+	close(stop)
+	<-saved
+	if err != nil {
+		t.Fatalf("reconnectSTT: %v", err)
+	}
+	defer session.recognizer().Close()
+
+	if session.recognizer() == old {
+		t.Error("the recognizer was not replaced")
+	}
+	if record, err := im.loadSessionRecord(session.ID); err != nil || len(record.StreamingConfig) == 0 {
+		t.Errorf("saved record = %+v, %v, want the streaming config", record, err)
+	}
+}
@@ -0,0 +1,193 @@
+package orchestrator
+
+import (
+	"context"
+	"time"
+
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/metrics"
+	"github.com/torteous44/callservice/internal/sessionstate"
+)
+
+// Reasons a session was terminated
+const (
+	CloseReasonClient       = "client_closed"
+	CloseReasonIdle         = "idle"
+	CloseReasonDisconnected = "disconnected"
+	CloseReasonLifetime     = "lifetime"
+	CloseReasonBudget       = "budget_exceeded"
+)
+
+// ReaperConfig controls when abandoned sessions are garbage collected
+type ReaperConfig struct {
+	Interval          time.Duration // How often sessions are checked
+	IdleTTL           time.Duration // Max time without client activity, in any status
+	DisconnectedGrace time.Duration // How long a disconnected session may wait for a reconnect
+	MaxLifetime       time.Duration // Absolute session lifetime
+}
+
+// DefaultReaperConfig returns the default reaper configuration
+func DefaultReaperConfig() ReaperConfig {
+	return ReaperConfig{
+		Interval:          30 * time.Second,
+		IdleTTL:           15 * time.Minute,
+		DisconnectedGrace: 5 * time.Minute,
+		MaxLifetime:       2 * time.Hour,
+	}
+}
+
+// RunReaper periodically terminates idle, abandoned and expired sessions until
+// ctx is cancelled. A zero TTL disables that check
+func (im *InterviewManager) RunReaper(ctx context.Context, config ReaperConfig) {
+	if config.Interval <= 0 {
+		config.Interval = DefaultReaperConfig().Interval
+	}
+
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+
+	im.logger.Info("Session reaper started", "idle", config.IdleTTL.String(), "disconnected_grace", config.DisconnectedGrace.String(),
+		"max_lifetime", config.MaxLifetime.String())
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			im.reapSessions(config, now)
+		}
+	}
+}
+
+// reapSessions terminates every session that exceeded one of the TTLs
+func (im *InterviewManager) reapSessions(config ReaperConfig, now time.Time) {
+	im.mu.RLock()
+	sessions := make([]*InterviewSession, 0, len(im.sessions))
+	for _, session := range im.sessions {
+		sessions = append(sessions, session)
+	}
+	im.mu.RUnlock()
+
+	for _, session := range sessions {
+		reason := reapReason(session, config, now)
+		if reason == "" {
+			continue
+		}
+
+		// Another instance may serve the session now, in which case its
+		// record is left to that instance
+		takenOver, err := im.ownedElsewhere(session)
+		if err != nil {
+			session.log.Warn("Failed to check session ownership", logging.Err(err))
+		}
+		if takenOver {
+			session.log.Info("Evicting session served by another instance", "reason", reason)
+			im.evictSession(session)
+			continue
+		}
+
+		session.log.Info("Reaping session", "reason", reason)
+		if im.terminateSession(session, reason) {
+			metrics.SessionsReaped.WithLabelValues(reason).Inc()
+		}
+	}
+}
+
+// reapReason returns why a session should be reaped, or "" to keep it
+func reapReason(session *InterviewSession, config ReaperConfig, now time.Time) string {
+	session.mu.RLock()
+	status := session.Status
+	startTime := session.StartTime
+	disconnectedAt := session.DisconnectedAt
+	session.mu.RUnlock()
+
+	switch {
+	case config.MaxLifetime > 0 && now.Sub(startTime) > config.MaxLifetime:
+		return CloseReasonLifetime
+	case config.DisconnectedGrace > 0 && status == "disconnected" && now.Sub(disconnectedAt) > config.DisconnectedGrace:
+		return CloseReasonDisconnected
+	case config.IdleTTL > 0 && now.Sub(session.LastActivity()) > config.IdleTTL:
+		return CloseReasonIdle
+	}
+	return ""
+}
+
+// terminateSession closes a session's connection and recognizer, removes it from
+// this instance and schedules its analytics flush. It reports false if the
+// session had already been terminated
+func (im *InterviewManager) terminateSession(session *InterviewSession, reason string) bool {
+	if current, exists := im.localSession(session.ID); !exists || current != session {
+		return false
+	}
+
+	session.mu.Lock()
+	if session.Status == "closed" {
+		session.mu.Unlock()
+		return false
+	}
+	client := session.Client
+	session.Client = nil
+	session.Status = "closed"
+	recognizer := session.StreamingSTT
+	session.cancel()
+	session.mu.Unlock()
+
+	// The closed record is saved before the session leaves memory, so it
+	// cannot be rehydrated from the backend in between
+	im.persistSession(session)
+	im.removeSession(session)
+
+	if client != nil {
+		client.Close()
+	}
+	if recognizer != nil {
+		recognizer.Close()
+	}
+
+	im.recordEvent(session, sessionstate.EventSessionClosed, sessionstate.SessionClosedData{
+		Reason: reason,
+	})
+	session.trace.end(reason)
+
+	im.observers.closeSession(session.ID)
+
+	// Flush to analytics before the session state is deleted
+	im.scheduleFinalize(session.ID)
+	return true
+}
+
+// evictSession drops the copy of a session that another instance took over or
+// closed. Its backend record, timeline and analytics belong to that instance
+func (im *InterviewManager) evictSession(session *InterviewSession) {
+	session.stopPersisting()
+
+	session.mu.Lock()
+	client := session.Client
+	session.Client = nil
+	session.Status = "closed"
+	recognizer := session.StreamingSTT
+	session.cancel()
+	session.mu.Unlock()
+
+	im.removeSession(session)
+
+	if client != nil {
+		client.Close()
+	}
+	if recognizer != nil {
+		recognizer.Close()
+	}
+	session.trace.end("taken_over")
+	im.observers.closeSession(session.ID)
+}
+
+// removeSession removes a session from this instance, unless it was replaced
+func (im *InterviewManager) removeSession(session *InterviewSession) {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	if current, exists := im.sessions[session.ID]; exists && current == session {
+		delete(im.sessions, session.ID)
+		im.store.DeleteSession(session.ID)
+	}
+}
@@ -0,0 +1,226 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/torteous44/callservice/internal/contextbrain"
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/sessionstate"
+	"github.com/torteous44/callservice/pkg/transport"
+)
+
+// Grading decisions on an answer
+const (
+	DecisionComplete   = "complete"   // The answer covers the question
+	DecisionIncomplete = "incomplete" // The interviewer should probe further
+)
+
+// gradeAnswer is the JSON object the context brain grades an answer with
+type gradeAnswer struct {
+	Scores       map[string]float64 `json:"scores"`
+	OverallScore float64            `json:"overall_score"`
+	Decision     string             `json:"decision"`
+	Feedback     []string           `json:"feedback"`
+}
+
+// answerContext is what the interviewer knows when answering an utterance,
+// copied from the session
+type answerContext struct {
+	lesson        *LessonObject
+	persona       *PersonaObject
+	question      *QuestionObject
+	questionIndex int
+}
+
+// respond has the interviewer answer an utterance of the candidate: the
+// answer is graded, a follow-up is written and spoken back. Answers are
+// generated in the background, within the turn the utterance ended, and an
+// utterance made while the interviewer is still answering is not answered
+func (im *InterviewManager) respond(session *InterviewSession, utterance string) {
+	if im.brain == nil || im.speech == nil {
+		session.log.Debug("Interviewer answers are disabled", logging.Transcript(utterance))
+		return
+	}
+	if !session.answering.CompareAndSwap(false, true) {
+		session.log.Debug("Still answering, skipping utterance", logging.Transcript(utterance))
+		return
+	}
+
+	// The calls are traced as stages of the current turn, and stop with the
+	// session
+	session.mu.RLock()
+	sessionCtx := session.ctx
+	session.mu.RUnlock()
+	ctx, cancel := context.WithCancel(session.trace.context())
+	stop := context.AfterFunc(sessionCtx, cancel)
+
+	go func() {
+		defer session.answering.Store(false)
+		defer cancel()
+		defer stop()
+
+		im.answerUtterance(ctx, session, utterance)
+	}()
+}
+
+// answerUtterance grades an utterance, then writes and speaks the interviewer's
+// follow-up. Every call is charged to the session budget before the next is
+// made, and the session ends once the budget is spent
+func (im *InterviewManager) answerUtterance(ctx context.Context, session *InterviewSession, utterance string) {
+	defer im.persistSession(session)
+
+	state := session.SessionState.Snapshot()
+	session.mu.RLock()
+	answer := answerContext{
+		lesson:        session.Lesson,
+		persona:       session.Persona,
+		questionIndex: state.CurrentQuestion,
+	}
+	if state.CurrentQuestion >= 0 && state.CurrentQuestion < len(session.Questions) {
+		answer.question = session.Questions[state.CurrentQuestion]
+	}
+	session.mu.RUnlock()
+
+	grading, err := im.brain.QueryContext(ctx, contextbrain.Request{
+		Operation: contextbrain.OperationGrading,
+		System:    gradingInstructions(answer),
+		Prompt:    utterance,
+		JSON:      true,
+	})
+	if !im.enforceBudget(session, session.Spend.ChargeLLMTokens(grading.Tokens)) {
+		return
+	}
+	if err != nil {
+		session.log.Error("Failed to grade answer", logging.Err(err))
+		return
+	}
+
+	var grade gradeAnswer
+	if err := json.Unmarshal([]byte(grading.Text), &grade); err != nil {
+		session.log.Error("Failed to parse grade", logging.Err(err))
+		return
+	}
+	if grade.Decision != DecisionComplete {
+		grade.Decision = DecisionIncomplete
+	}
+	im.recordEvent(session, sessionstate.EventGradeProduced, sessionstate.GradeData{
+		QuestionIndex: answer.questionIndex,
+		Scores:        grade.Scores,
+		OverallScore:  grade.OverallScore,
+		Decision:      grade.Decision,
+		Feedback:      grade.Feedback,
+	})
+
+	followUp, err := im.brain.QueryContext(ctx, contextbrain.Request{
+		Operation: contextbrain.OperationFollowUp,
+		System:    followUpInstructions(answer, grade),
+		Prompt:    utterance,
+	})
+	if !im.enforceBudget(session, session.Spend.ChargeLLMTokens(followUp.Tokens)) {
+		return
+	}
+	if err != nil {
+		session.log.Error("Failed to write follow-up", logging.Err(err))
+		return
+	}
+	text := strings.TrimSpace(followUp.Text)
+	if text == "" {
+		return
+	}
+
+	// Synthesis is charged up front, so a session over budget does not pay
+	// for speech it will not hear
+	if !im.enforceBudget(session, session.Spend.ChargeTTSCharacters(len(text))) {
+		return
+	}
+	audio, err := im.speech.SynthesizeContext(ctx, text, im.speechOptions)
+	if err != nil {
+		session.log.Error("Failed to synthesize follow-up", logging.Err(err))
+		return
+	}
+
+	im.startSpeaking(session, text)
+	im.sendToClient(session, transport.TypeInterviewerAudio, transport.AudioMessage{
+		SessionID: session.ID,
+		Data:      audio,
+		Format:    string(im.speechOptions.Format),
+	})
+}
+
+// startSpeaking records the interviewer starting to speak text, interrupting
+// any speech still out
+func (im *InterviewManager) startSpeaking(session *InterviewSession, text string) {
+	im.stopSpeaking(session, true)
+
+	session.mu.Lock()
+	session.speaking = text
+	session.speechPlaying = false
+	session.mu.Unlock()
+	im.recordEvent(session, sessionstate.EventTTSStarted, sessionstate.UtteranceData{Text: text})
+}
+
+// stopSpeaking records the interviewer's speech ending, played out or
+// interrupted, if any is out
+func (im *InterviewManager) stopSpeaking(session *InterviewSession, interrupted bool) {
+	session.mu.Lock()
+	text := session.speaking
+	session.speaking = ""
+	session.speechPlaying = false
+	session.mu.Unlock()
+
+	if text != "" {
+		im.recordEvent(session, sessionstate.EventTTSStopped, sessionstate.UtteranceData{Text: text, Interrupted: interrupted})
+	}
+}
+
+// gradingInstructions asks the context brain to grade an answer to the current
+// question against its expected components
+func gradingInstructions(answer answerContext) string {
+	var b strings.Builder
+	b.WriteString("You grade a candidate's spoken answer in a case interview.\n")
+	writeCase(&b, answer)
+	if answer.question != nil {
+		for _, component := range answer.question.ExpectedComponents {
+			fmt.Fprintf(&b, "Expected component %q: %s\n", component.Title, component.Description)
+		}
+	}
+	fmt.Fprintf(&b, "Reply with a JSON object with \"scores\", a score from 0 to 1 for each expected component by title, "+
+		"\"overall_score\" from 0 to 1, \"decision\", %q if the answer covers the question or %q otherwise, "+
+		"and \"feedback\", a list of short remarks for the candidate.", DecisionComplete, DecisionIncomplete)
+	return b.String()
+}
+
+// followUpInstructions asks the context brain for the interviewer's spoken
+// reply to a graded answer
+func followUpInstructions(answer answerContext, grade gradeAnswer) string {
+	var b strings.Builder
+	b.WriteString("You are the interviewer in a spoken case interview.\n")
+	if persona := answer.persona; persona != nil {
+		fmt.Fprintf(&b, "You interview for %s. Tone: %s. %s\n",
+			persona.CaseInterviewCompany, persona.InterviewerTone, persona.GeneralPersona)
+	}
+	writeCase(&b, answer)
+	if grade.Decision == DecisionComplete {
+		b.WriteString("The candidate's answer covers the question. Acknowledge it briefly.\n")
+	} else {
+		b.WriteString("The candidate's answer is incomplete. Ask one follow-up question that probes what is missing, without giving it away.\n")
+		if answer.question != nil && len(answer.question.FollowUps) > 0 {
+			fmt.Fprintf(&b, "Follow-ups you may use: %s\n", strings.Join(answer.question.FollowUps, "; "))
+		}
+	}
+	b.WriteString("Reply with what you say, in two sentences at most.")
+	return b.String()
+}
+
+// writeCase writes the case and the current question of an answer
+func writeCase(b *strings.Builder, answer answerContext) {
+	if lesson := answer.lesson; lesson != nil {
+		fmt.Fprintf(b, "Case: %s\n%s\n", lesson.CasePrompt, lesson.CaseDescription)
+	}
+	if answer.question != nil {
+		fmt.Fprintf(b, "Question %d: %s\n", answer.questionIndex+1, answer.question.QuestionPrompt)
+	}
+}
@@ -0,0 +1,272 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/torteous44/callservice/internal/audio/stt"
+	"github.com/torteous44/callservice/internal/audio/tts"
+	"github.com/torteous44/callservice/internal/contextbrain"
+	"github.com/torteous44/callservice/internal/limits"
+	"github.com/torteous44/callservice/internal/sessionstate"
+	"github.com/torteous44/callservice/pkg/transport"
+)
+
+const (
+	testFollowUp     = "What would you look at first?"
+	testQueryTokens  = 100
+	testSpeechFormat = "mp3"
+)
+
+// fakeOpenAI serves grades, follow-ups and speech like the OpenAI API,
+// counting the requests it answers
+type fakeOpenAI struct {
+	*httptest.Server
+	queries  atomic.Int32
+	speeches atomic.Int32
+}
+
+func newFakeOpenAI(t *testing.T) *fakeOpenAI {
+	t.Helper()
+
+	fake := &fakeOpenAI{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		fake.queries.Add(1)
+		var req openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		answer := testFollowUp
+		if req.ResponseFormat != nil {
+			answer = `{"scores": {"Revenue": 0.5}, "overall_score": 0.5, "decision": "incomplete", "feedback": ["Mention costs"]}`
+		}
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: answer}}},
+			Usage:   openai.Usage{TotalTokens: testQueryTokens},
+		})
+	})
+	mux.HandleFunc("POST /v1/audio/speech", func(w http.ResponseWriter, r *http.Request) {
+		fake.speeches.Add(1)
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Write([]byte("speech"))
+	})
+	fake.Server = httptest.NewServer(mux)
+	t.Cleanup(fake.Close)
+	return fake
+}
+
+// newAnsweringManager returns a manager whose interviewer answers through
+// fake, within budget
+func newAnsweringManager(fake *fakeOpenAI, budget limits.Spend) *InterviewManager {
+	speechConfig := openai.DefaultConfig("test")
+	speechConfig.BaseURL = fake.URL + "/v1"
+	return NewInterviewManager(ManagerOptions{
+		STT:    stt.StreamingConfig{APIKey: "test"},
+		Limits: limits.Options{Budget: budget},
+		Brain: contextbrain.NewClient(contextbrain.Options{
+			APIKey:  "test",
+			BaseURL: fake.URL + "/v1",
+			Model:   "test",
+		}),
+		Speech:        tts.NewTTSWithConfig(speechConfig),
+		SpeechOptions: tts.SynthesizeOptions{Format: testSpeechFormat},
+	})
+}
+
+// newLessonSession creates a session on its first question
+func newLessonSession(t *testing.T, im *InterviewManager) *InterviewSession {
+	t.Helper()
+
+	session, _, err := im.createSession("", "", im.sttDefaults, &SessionInitializationRequest{
+		Lesson: LessonObject{LessonID: "lesson", CasePrompt: "A retailer's profits are falling."},
+		Questions: []QuestionObject{{
+			QuestionPrompt:     "How would you structure the problem?",
+			ExpectedComponents: []ExpectedComponent{{Title: "Revenue"}, {Title: "Costs"}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("createSession: %v", err)
+	}
+	return session
+}
+
+// eventTypes returns the types of the events on a session's timeline
+func eventTypes(t *testing.T, im *InterviewManager, session *InterviewSession) map[sessionstate.EventType]int {
+	t.Helper()
+
+	events, err := sessionstate.LoadEvents(context.Background(), im.backend, session.ID, 0)
+	if err != nil {
+		t.Fatalf("LoadEvents: %v", err)
+	}
+	types := make(map[sessionstate.EventType]int)
+	for _, event := range events {
+		types[event.Type]++
+	}
+	return types
+}
+
+func TestAnswerUtteranceChargesBudget(t *testing.T) {
+	fake := newFakeOpenAI(t)
+	im := newAnsweringManager(fake, limits.Spend{})
+	session := newLessonSession(t, im)
+
+	im.answerUtterance(context.Background(), session, "I would look at revenue.")
+
+	used := session.Spend.Used()
+	if used.LLMTokens != 2*testQueryTokens {
+		t.Errorf("LLM tokens = %d, want %d", used.LLMTokens, 2*testQueryTokens)
+	}
+	if used.TTSCharacters != len(testFollowUp) {
+		t.Errorf("TTS characters = %d, want %d", used.TTSCharacters, len(testFollowUp))
+	}
+
+	types := eventTypes(t, im, session)
+	if types[sessionstate.EventGradeProduced] != 1 || types[sessionstate.EventTTSStarted] != 1 {
+		t.Errorf("events = %v, want a grade and the interviewer speaking", types)
+	}
+	if fake.speeches.Load() != 1 || session.Outbox.LastSeq() == 0 {
+		t.Error("the follow-up was not spoken to the client")
+	}
+}
+
+func TestAnswerUtteranceOverBudget(t *testing.T) {
+	tests := []struct {
+		name        string
+		budget      limits.Spend
+		wantQueries int32
+		wantGrade   bool
+	}{
+		{"tokens spent grading", limits.Spend{LLMTokens: testQueryTokens / 2}, 1, false},
+		{"tokens spent on the follow-up", limits.Spend{LLMTokens: testQueryTokens * 3 / 2}, 2, true},
+		{"speech", limits.Spend{TTSCharacters: len(testFollowUp) - 1}, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeOpenAI(t)
+			im := newAnsweringManager(fake, tt.budget)
+			session := newLessonSession(t, im)
+
+			im.answerUtterance(context.Background(), session, "I would look at revenue.")
+
+			if got := fake.queries.Load(); got != tt.wantQueries {
+				t.Errorf("queries = %d, want %d", got, tt.wantQueries)
+			}
+			if got := fake.speeches.Load(); got != 0 {
+				t.Errorf("speech requests = %d, want none over budget", got)
+			}
+			session.mu.RLock()
+			status := session.Status
+			session.mu.RUnlock()
+			if status != "closed" {
+				t.Errorf("status = %q, want closed", status)
+			}
+
+			types := eventTypes(t, im, session)
+			if got := types[sessionstate.EventGradeProduced] == 1; got != tt.wantGrade {
+				t.Errorf("graded = %v, want %v", got, tt.wantGrade)
+			}
+			if types[sessionstate.EventTTSStarted] != 0 {
+				t.Error("the interviewer spoke over budget")
+			}
+		})
+	}
+}
+
+func TestRespondDisabled(t *testing.T) {
+	im := NewInterviewManager(ManagerOptions{STT: stt.StreamingConfig{APIKey: "test"}})
+	session := newLessonSession(t, im)
+
+	im.respond(session, "I would look at revenue.")
+
+	if session.answering.Load() {
+		t.Error("answering without an interviewer configured")
+	}
+	if used := session.Spend.Used(); used.LLMTokens != 0 || used.TTSCharacters != 0 {
+		t.Errorf("spend = %+v, want none", used)
+	}
+	if types := eventTypes(t, im, session); types[sessionstate.EventGradeProduced] != 0 {
+		t.Errorf("events = %v, want no grade", types)
+	}
+}
+
+func TestInterviewerSpeechStops(t *testing.T) {
+	heartbeat := func(playing bool) func(im *InterviewManager, session *InterviewSession) {
+		return func(im *InterviewManager, session *InterviewSession) {
+			msg, _ := transport.NewMessage(transport.TypeHeartbeat, transport.HeartbeatMessage{Playing: playing})
+			im.handleControl(session, stubClient{}, msg)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		steps []func(im *InterviewManager, session *InterviewSession)
+		want  []sessionstate.UtteranceData // tts_stopped events
+	}{
+		{
+			name:  "played out",
+			steps: []func(*InterviewManager, *InterviewSession){heartbeat(true), heartbeat(true), heartbeat(false)},
+			want:  []sessionstate.UtteranceData{{Text: testFollowUp}},
+		},
+		{
+			name:  "not played yet",
+			steps: []func(*InterviewManager, *InterviewSession){heartbeat(false)},
+		},
+		{
+			name: "interrupted by new speech",
+			steps: []func(*InterviewManager, *InterviewSession){func(im *InterviewManager, session *InterviewSession) {
+				im.startSpeaking(session, "Next question.")
+			}},
+			want: []sessionstate.UtteranceData{{Text: testFollowUp, Interrupted: true}},
+		},
+		{
+			name: "interrupted by the candidate",
+			steps: []func(*InterviewManager, *InterviewSession){heartbeat(true), func(im *InterviewManager, session *InterviewSession) {
+				records := make(chan segmentRecord, 1)
+				records <- segmentRecord{event: sessionstate.EventVADSpeechStart}
+				close(records)
+				im.runRecorderStage(session, records)
+			}},
+			want: []sessionstate.UtteranceData{{Text: testFollowUp, Interrupted: true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeOpenAI(t)
+			im := newAnsweringManager(fake, limits.Spend{})
+			session := newLessonSession(t, im)
+
+			im.answerUtterance(context.Background(), session, "I would look at revenue.")
+			for _, step := range tt.steps {
+				step(im, session)
+			}
+
+			events, err := sessionstate.LoadEvents(context.Background(), im.backend, session.ID, 0)
+			if err != nil {
+				t.Fatalf("LoadEvents: %v", err)
+			}
+			var stopped []sessionstate.UtteranceData
+			for _, event := range events {
+				if event.Type != sessionstate.EventTTSStopped {
+					continue
+				}
+				var data sessionstate.UtteranceData
+				if err := json.Unmarshal(event.Data, &data); err != nil {
+					t.Fatal(err)
+				}
+				stopped = append(stopped, data)
+			}
+			if !reflect.DeepEqual(stopped, tt.want) {
+				t.Errorf("tts_stopped = %+v, want %+v", stopped, tt.want)
+			}
+		})
+	}
+}
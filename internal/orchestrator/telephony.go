@@ -0,0 +1,290 @@
+package orchestrator
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/torteous44/callservice/internal/audio/mulaw"
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/metrics"
+	"github.com/torteous44/callservice/internal/sessionstate"
+	"github.com/torteous44/callservice/pkg/transport"
+)
+
+// Telephony audio
+const (
+	telephonyEncoding   = "pcm_mulaw"
+	telephonyMediaType  = "audio/x-mulaw"
+	telephonyChunkBytes = 800 // 100ms of 8kHz μ-law per outbound media event
+)
+
+// Interviewer audio formats that can be played to a caller
+const (
+	speechFormatMulaw = "pcm_mulaw" // 8kHz μ-law, sent as is
+	speechFormatPCM   = "pcm"       // 24kHz 16-bit little-endian PCM, OpenAI's raw speech output
+	speechPCMRate     = 24000
+)
+
+// Custom stream parameters a call can pass, e.g. with <Parameter> in TwiML
+const (
+	telephonyParamSession = "session_id"
+	telephonyParamResume  = "resume_token"
+	telephonyParamTicket  = "ticket"
+)
+
+// HandleTelephony bridges a phone call into an interview session over a
+// telephony provider's media stream WebSocket. The caller's 8kHz μ-law audio
+// feeds VAD and STT and interviewer speech is played back as media events.
+// The stream joins the session named by its session_id parameter, or starts a
+// new one. With authentication enabled, calls can only join a session and
+// need a ticket parameter issued to its owner
+func (im *InterviewManager) HandleTelephony(w http.ResponseWriter, r *http.Request) {
+	conn, err := im.ws.UpgradeMediaStream(w, r)
+	if err != nil {
+		im.logger.Error("Media stream upgrade failed", logging.Err(err))
+		return
+	}
+
+	start, err := conn.Start(handshakeTimeout)
+	if err != nil {
+		im.logger.Error("Media stream did not start", logging.Err(err))
+		conn.Close()
+		return
+	}
+	if start.MediaFormat.Encoding != telephonyMediaType || start.MediaFormat.SampleRate != mulaw.SampleRate {
+		im.logger.Error("Unsupported media stream format", "call", start.CallSID,
+			"encoding", start.MediaFormat.Encoding, "sample_rate", start.MediaFormat.SampleRate)
+		conn.Close()
+		return
+	}
+
+	session, err := im.telephonySession(start)
+	if err != nil {
+		im.logger.Error("Cannot start interview for call", "call", start.CallSID, logging.Err(err))
+		conn.Close()
+		return
+	}
+
+	client := &telephonyClient{conn: conn}
+
+	session.mu.Lock()
+	if status, message := im.claimSession(session, start.CustomParameters[telephonyParamResume]); status != 0 {
+		session.mu.Unlock()
+		session.log.Warn("Rejecting call", "call", start.CallSID, "reason", message)
+		conn.Close()
+		return
+	}
+	format := sessionAudioFormat(session.StreamingSTT.GetConfig())
+	session.Client = client
+	session.Status = "connected"
+	session.muted.Store(false)
+	session.touch()
+	session.mu.Unlock()
+	im.persistSession(session)
+
+	im.recordEvent(session, sessionstate.EventClientConnected, nil)
+	metrics.ClientConnects.WithLabelValues(transportTelephony).Inc()
+	session.log.Info("Call connected", "call", start.CallSID)
+
+	defer func() {
+		session.Outbox.Detach(client)
+		im.releaseSession(session)
+		metrics.ClientDisconnects.WithLabelValues(transportTelephony).Inc()
+		session.log.Info("Call disconnected", "call", start.CallSID)
+	}()
+
+	im.processSession(session)
+
+	// A caller cannot read missed transcripts, and replaying old interviewer
+	// speech would only confuse them
+	session.Outbox.Attach(client, session.Outbox.LastSeq())
+
+	im.sendToClient(session, transport.TypeStatus, transport.StatusMessage{
+		SessionID: session.ID,
+		Status:    "connected",
+	})
+
+	ingest, err := NewAudioIngest(IngestConfig{Format: format}, nil)
+	if err != nil {
+		session.log.Error("Cannot ingest audio", logging.Err(err))
+		return
+	}
+
+	im.runIngest(session, ingest, func() {
+		im.readMediaStream(session, client, ingest)
+	})
+}
+
+// telephonySession returns the session a media stream asked for, or creates
+// one streaming telephony audio
+func (im *InterviewManager) telephonySession(start *transport.MediaStart) (*InterviewSession, error) {
+	sessionID := start.CustomParameters[telephonyParamSession]
+	if sessionID == "" {
+		// Anonymous calls cannot own a session
+		if im.authEnabled() {
+			return nil, errors.New("session_id and ticket parameters are required")
+		}
+		// Media streams come from the provider, so the caller's address is
+		// unknown
+		session, _, err := im.createSession("", "", im.streamingConfig(mulaw.SampleRate, telephonyEncoding), nil)
+		return session, err
+	}
+	if err := im.verifyTicket(start.CustomParameters[telephonyParamTicket], sessionID); err != nil {
+		return nil, err
+	}
+
+	session, exists := im.acquireSession(sessionID)
+	if !exists {
+		return nil, fmt.Errorf("session %s not found", sessionID)
+	}
+
+	session.mu.RLock()
+	format := sessionAudioFormat(session.StreamingSTT.GetConfig())
+	session.mu.RUnlock()
+	if format.Encoding != telephonyEncoding || format.SampleRate != mulaw.SampleRate {
+		return nil, fmt.Errorf("session %s streams %s at %d Hz, not 8kHz μ-law", sessionID, format.Encoding, format.SampleRate)
+	}
+	return session, nil
+}
+
+// readMediaStream feeds the caller's audio into the ingest until the call
+// ends or the connection fails. Marks echoed by the provider report how much
+// interviewer speech the caller has heard
+func (im *InterviewManager) readMediaStream(session *InterviewSession, client *telephonyClient, ingest *AudioIngest) {
+	for {
+		event, err := client.conn.ReadEvent()
+		if err != nil {
+			if !transport.IsCloseError(err) {
+				session.log.Error("Error reading media stream", logging.Err(err))
+			}
+			return
+		}
+		session.touch()
+
+		switch event.Event {
+		case transport.MediaEventMedia:
+			if event.Media != nil && event.Media.Track != "" && event.Media.Track != "inbound" {
+				continue
+			}
+			if session.muted.Load() {
+				continue
+			}
+			audio, err := event.Audio()
+			if err != nil {
+				session.log.Warn("Invalid media payload", logging.Err(err))
+				continue
+			}
+			if err := ingest.ProcessAudio(session.ctx, audio); err != nil {
+				var audioErr *AudioError
+				if !errors.As(err, &audioErr) {
+					return
+				}
+				session.log.Warn("Rejected audio", logging.Err(err))
+			}
+
+		case transport.MediaEventMark:
+			if event.Mark == nil {
+				continue
+			}
+			position, err := strconv.ParseInt(event.Mark.Name, 10, 64)
+			if err != nil {
+				continue
+			}
+			msg, _ := transport.NewMessage(transport.TypeHeartbeat, transport.HeartbeatMessage{PlaybackPosition: position})
+			im.handleControl(session, client, msg)
+
+			// Every utterance ends with a mark, so the caller has heard it out
+			im.stopSpeaking(session, false)
+
+		case transport.MediaEventStop:
+			msg, _ := transport.NewMessage(transport.TypeStop, nil)
+			im.handleControl(session, client, msg)
+			return
+
+		case transport.MediaEventDTMF:
+			if event.DTMF != nil {
+				session.log.Info("Caller pressed a key", "digit", event.DTMF.Digit)
+			}
+		}
+	}
+}
+
+// telephonyClient is the candidate end of a phone call. A caller only hears
+// the interviewer: speech is converted to μ-law media events and every other
+// message is dropped
+type telephonyClient struct {
+	conn   *transport.MediaStreamConn
+	played time.Duration // Interviewer speech queued so far
+}
+
+// Send queues a message
+func (c *telephonyClient) Send(messageType string, payload interface{}) error {
+	msg, err := transport.NewMessage(messageType, payload)
+	if err != nil {
+		return err
+	}
+	return c.SendMessage(msg)
+}
+
+// SendMessage plays interviewer speech to the caller, followed by a mark
+// named after the playback position at its end
+func (c *telephonyClient) SendMessage(msg transport.Message) error {
+	if msg.Type != transport.TypeInterviewerAudio {
+		return nil
+	}
+
+	var speech transport.AudioMessage
+	if err := msg.Decode(&speech); err != nil {
+		return err
+	}
+
+	var audio []byte
+	switch speech.Format {
+	case speechFormatMulaw:
+		audio = speech.Data
+	case speechFormatPCM:
+		audio = mulaw.Encode(downsamplePCM(speech.Data, speechPCMRate, mulaw.SampleRate))
+	default:
+		return fmt.Errorf("%s interviewer audio cannot be played to a caller", speech.Format)
+	}
+
+	for len(audio) > 0 {
+		chunk := audio[:min(len(audio), telephonyChunkBytes)]
+		audio = audio[len(chunk):]
+		if err := c.conn.SendMedia(chunk); err != nil {
+			return err
+		}
+		c.played += time.Duration(len(chunk)) * time.Second / mulaw.SampleRate
+	}
+	return c.conn.SendMark(strconv.FormatInt(c.played.Milliseconds(), 10))
+}
+
+// SendError drops the error, a caller cannot see it
+func (c *telephonyClient) SendError(code, message string) error {
+	return nil
+}
+
+// Close hangs up the media stream
+func (c *telephonyClient) Close() error {
+	return c.conn.Close()
+}
+
+// downsamplePCM converts 16-bit little-endian PCM from one sample rate to a
+// lower one, averaging the samples that fold into each output sample
+func downsamplePCM(pcm []byte, from, to int) []byte {
+	samples := len(pcm) / 2
+	out := make([]byte, samples*to/from*2)
+	for i := range len(out) / 2 {
+		first, last := i*from/to, (i+1)*from/to
+		var sum int
+		for j := first; j < last; j++ {
+			sum += int(int16(binary.LittleEndian.Uint16(pcm[j*2:])))
+		}
+		binary.LittleEndian.PutUint16(out[i*2:], uint16(int16(sum/(last-first))))
+	}
+	return out
+}
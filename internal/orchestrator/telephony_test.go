@@ -0,0 +1,214 @@
+package orchestrator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/torteous44/callservice/internal/audio/stt"
+	"github.com/torteous44/callservice/internal/sessionstate"
+	"github.com/torteous44/callservice/pkg/transport"
+)
+
+const (
+	telephonyFixture    = "../../scripts/fixtures/telephony_stream.jsonl"
+	telephonyTranscript = "I would start with the customer segments."
+	telephonyTimeout    = 10 * time.Second
+)
+
+// fakeAssemblyAI is a streaming recognizer that transcribes the first few
+// audio messages it gets
+type fakeAssemblyAI struct {
+	*httptest.Server
+	query  atomic.Pointer[string] // Query of the last connection
+	audio  atomic.Int32           // Audio messages received
+	forced atomic.Int32           // ForceEndpoint messages received
+}
+
+func newFakeAssemblyAI(t *testing.T) *fakeAssemblyAI {
+	t.Helper()
+
+	fake := &fakeAssemblyAI{}
+	fake.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.RawQuery
+		fake.query.Store(&query)
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.CloseNow()
+
+		ctx := r.Context()
+		conn.Write(ctx, websocket.MessageText, []byte(`{"message_type":"SessionBegins","session_id":"assemblyai-session"}`))
+		for {
+			_, data, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			var msg struct {
+				MessageType string `json:"message_type"`
+			}
+			json.Unmarshal(data, &msg)
+
+			switch msg.MessageType {
+			case "AudioData":
+				if fake.audio.Add(1) == 10 {
+					final, _ := json.Marshal(stt.StreamingResult{MessageType: "FinalTranscript", Text: telephonyTranscript, Confidence: 0.9})
+					conn.Write(ctx, websocket.MessageText, final)
+				}
+			case "ForceEndpoint":
+				fake.forced.Add(1)
+			case "SessionTermination":
+				conn.Close(websocket.StatusNormalClosure, "")
+				return
+			}
+		}
+	}))
+	t.Cleanup(fake.Close)
+	return fake
+}
+
+// waitFor polls until done reports true
+func waitFor(t *testing.T, what string, done func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(telephonyTimeout)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// onlySession returns the only session of a manager, once there is one
+func onlySession(t *testing.T, im *InterviewManager) *InterviewSession {
+	t.Helper()
+
+	var session *InterviewSession
+	waitFor(t, "the call's session", func() bool {
+		im.mu.RLock()
+		defer im.mu.RUnlock()
+		for _, s := range im.sessions {
+			session = s
+		}
+		return session != nil
+	})
+	return session
+}
+
+func TestHandleTelephonyFixture(t *testing.T) {
+	recognizer := newFakeAssemblyAI(t)
+	im := NewInterviewManager(ManagerOptions{
+		STT: stt.StreamingConfig{APIKey: "test", URL: "ws" + strings.TrimPrefix(recognizer.URL, "http")},
+	})
+	server := httptest.NewServer(http.HandlerFunc(im.HandleTelephony))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), telephonyTimeout)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.CloseNow()
+
+	fixture, err := os.Open(telephonyFixture)
+	if err != nil {
+		t.Fatalf("Open fixture: %v", err)
+	}
+	defer fixture.Close()
+
+	// Replay the call up to its end, holding the stop event until the caller
+	// was transcribed
+	var stop []byte
+	scanner := bufio.NewScanner(fixture)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := append([]byte(nil), scanner.Bytes()...)
+		var event transport.MediaEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			t.Fatalf("fixture event: %v", err)
+		}
+		if event.Event == transport.MediaEventStop {
+			stop = line
+			continue
+		}
+		if err := conn.Write(ctx, websocket.MessageText, line); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	session := onlySession(t, im)
+	waitFor(t, "the caller's transcript", func() bool {
+		return eventTypes(t, im, session)[sessionstate.EventSTTFinal] > 0
+	})
+	if query := *recognizer.query.Load(); !strings.Contains(query, "encoding=pcm_mulaw") || !strings.Contains(query, "sample_rate=8000") {
+		t.Errorf("recognizer query = %q, want 8kHz μ-law", query)
+	}
+
+	// Interviewer speech is played to the caller as media events, followed
+	// by a mark at the end of it
+	im.sendToClient(session, transport.TypeInterviewerAudio, transport.AudioMessage{
+		SessionID: session.ID,
+		Data:      make([]byte, 2*telephonyChunkBytes),
+		Format:    speechFormatMulaw,
+	})
+	var media int
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		var event transport.MediaEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			t.Fatalf("server event: %v", err)
+		}
+		if event.Event == transport.MediaEventMedia {
+			media++
+			continue
+		}
+		if event.Event == transport.MediaEventMark {
+			if media != 2 || event.Mark == nil || event.Mark.Name != "200" {
+				t.Errorf("got %d media events and mark %+v, want 2 and a mark at 200ms", media, event.Mark)
+			}
+			break
+		}
+	}
+
+	if err := conn.Write(ctx, websocket.MessageText, stop); err != nil {
+		t.Fatalf("Write stop: %v", err)
+	}
+	waitFor(t, "the call to end", func() bool {
+		return eventTypes(t, im, session)[sessionstate.EventClientDisconnected] > 0
+	})
+	if recognizer.forced.Load() != 1 {
+		t.Errorf("ForceEndpoint messages = %d, want 1 when the call stops", recognizer.forced.Load())
+	}
+
+	session.mu.RLock()
+	transcript := session.Transcript
+	status := session.Status
+	client := session.Client
+	session.mu.RUnlock()
+	if len(transcript) == 0 || transcript[len(transcript)-1].Text != telephonyTranscript {
+		t.Errorf("transcript = %+v, want the caller's answer", transcript)
+	}
+	if status != "disconnected" || client != nil {
+		t.Errorf("status = %q with client %v, want disconnected", status, client)
+	}
+	if types := eventTypes(t, im, session); types[sessionstate.EventClientConnected] != 1 {
+		t.Errorf("events = %v, want the call connecting once", types)
+	}
+}
@@ -0,0 +1,182 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/metrics"
+	"github.com/torteous44/callservice/internal/sessionstate"
+	"github.com/torteous44/callservice/pkg/transport"
+)
+
+// TimelineResponse is returned by the timeline endpoint
+type TimelineResponse struct {
+	SessionID string               `json:"session_id"`
+	Events    []sessionstate.Event `json:"events"`
+}
+
+// TimelineStateResponse is the replayed state of a session at a point in time
+type TimelineStateResponse struct {
+	SessionID string             `json:"session_id"`
+	At        time.Time          `json:"at"`
+	LastSeq   int64              `json:"last_seq"`
+	State     SessionStateObject `json:"state"`
+}
+
+// recordEvent appends an event to the session timeline
+func (im *InterviewManager) recordEvent(session *InterviewSession, eventType sessionstate.EventType, data interface{}) {
+	if session.Events == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+	defer cancel()
+
+	event, err := session.Events.Append(ctx, eventType, data)
+	if err != nil {
+		session.log.Warn("Failed to record event", "event", eventType, logging.Err(err))
+		return
+	}
+	im.observers.broadcast(session.ID, transport.TypeEvent, event)
+
+	// Candidates see their grades as they are produced
+	if eventType == sessionstate.EventGradeProduced {
+		if grade, ok := data.(sessionstate.GradeData); ok {
+			metrics.GradingDecisions.WithLabelValues(grade.Decision).Inc()
+			session.trace.graded(grade.Decision)
+		}
+		im.sendToClient(session, transport.TypeGrade, event.Data)
+	}
+}
+
+// updateState commits a change to the interview state and records it on the
+// timeline so the state can be rebuilt by replay
+func (im *InterviewManager) updateState(session *InterviewSession, fn func(state *SessionStateObject)) sessionstate.StateChange {
+	change := session.SessionState.Update(fn)
+	im.recordStateChange(session, change)
+	return change
+}
+
+// recordStateChange records a committed state change on the timeline, if it
+// changed anything
+func (im *InterviewManager) recordStateChange(session *InterviewSession, change sessionstate.StateChange) {
+	if len(change.Fields) == 0 {
+		return
+	}
+
+	data, err := sessionstate.NewStateTransition(change)
+	if err != nil {
+		session.log.Error("Failed to build state transition", logging.Err(err))
+		return
+	}
+	im.recordEvent(session, sessionstate.EventStateTransition, data)
+}
+
+// GetTimeline returns the events of a session, optionally after a sequence number
+func (im *InterviewManager) GetTimeline(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	sessionID := r.URL.Query().Get("session_id")
+	if sessionID == "" {
+		http.Error(w, "session_id required", http.StatusBadRequest)
+		return
+	}
+	if !im.authorizeSession(w, r, sessionID) {
+		return
+	}
+
+	var afterSeq int64
+	if after := r.URL.Query().Get("after"); after != "" {
+		parsed, err := strconv.ParseInt(after, 10, 64)
+		if err != nil || parsed < 0 {
+			http.Error(w, "after must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		afterSeq = parsed
+	}
+
+	events, ok := im.loadTimeline(w, sessionID, afterSeq)
+	if !ok {
+		return
+	}
+
+	json.NewEncoder(w).Encode(TimelineResponse{
+		SessionID: sessionID,
+		Events:    events,
+	})
+}
+
+// GetTimelineState replays the timeline to rebuild the interview state at a
+// point in time, given as an RFC 3339 "at" parameter (defaults to now)
+func (im *InterviewManager) GetTimelineState(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	sessionID := r.URL.Query().Get("session_id")
+	if sessionID == "" {
+		http.Error(w, "session_id required", http.StatusBadRequest)
+		return
+	}
+	if !im.authorizeSession(w, r, sessionID) {
+		return
+	}
+
+	at := time.Now()
+	if value := r.URL.Query().Get("at"); value != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			http.Error(w, "at must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		at = parsed
+	}
+
+	events, ok := im.loadTimeline(w, sessionID, 0)
+	if !ok {
+		return
+	}
+
+	state, err := sessionstate.Replay(events, at)
+	if err != nil {
+		im.sessionLogger(sessionID).Error("Failed to replay timeline", logging.Err(err))
+		http.Error(w, "Failed to replay timeline", http.StatusInternalServerError)
+		return
+	}
+
+	var lastSeq int64
+	for _, event := range events {
+		if event.Timestamp.After(at) {
+			break
+		}
+		lastSeq = event.Seq
+	}
+
+	json.NewEncoder(w).Encode(TimelineStateResponse{
+		SessionID: sessionID,
+		At:        at,
+		LastSeq:   lastSeq,
+		State:     state,
+	})
+}
+
+// loadTimeline reads a session's events, writing an error response on failure
+func (im *InterviewManager) loadTimeline(w http.ResponseWriter, sessionID string, afterSeq int64) ([]sessionstate.Event, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+	defer cancel()
+
+	if _, err := im.backend.LoadSession(ctx, sessionID); err != nil {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	events, err := sessionstate.LoadEvents(ctx, im.backend, sessionID, afterSeq)
+	if err != nil {
+		im.sessionLogger(sessionID).Error("Failed to load timeline", logging.Err(err))
+		http.Error(w, "Failed to load timeline", http.StatusInternalServerError)
+		return nil, false
+	}
+	return events, true
+}
@@ -0,0 +1,104 @@
+package orchestrator
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Session token errors
+var (
+	ErrInvalidToken = errors.New("invalid session token")
+	ErrExpiredToken = errors.New("session token expired")
+)
+
+// Token scopes. A token only grants access to the scope it was issued for
+const (
+	ScopeResume  = "resume"  // Reconnect the candidate to a disconnected session
+	ScopeObserve = "observe" // Watch a session read-only
+	ScopeCoach   = "coach"   // Watch a session and whisper to it
+	ScopeTicket  = "ticket"  // Open the candidate's connection, shortly after it was issued
+)
+
+// tokenClaims is the signed content of a session token
+type tokenClaims struct {
+	SessionID string `json:"sid"`
+	Scope     string `json:"scope"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// TokenSigner issues and verifies the tokens that grant access to a session:
+// resume tokens and tickets for the candidate and observer tokens for
+// coaches. Tokens are HMAC-SHA256 signed, so every instance sharing the secret
+// can verify them
+type TokenSigner struct {
+	secret []byte
+}
+
+// NewTokenSigner creates a signer. With an empty secret a random one is
+// generated, which only works when a single instance serves all connections
+func NewTokenSigner(secret []byte) *TokenSigner {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		rand.Read(secret)
+	}
+	return &TokenSigner{secret: secret}
+}
+
+// Issue returns a token for sessionID and scope valid for ttl
+func (s *TokenSigner) Issue(sessionID, scope string, ttl time.Duration) (string, error) {
+	payload, err := json.Marshal(tokenClaims{
+		SessionID: sessionID,
+		Scope:     scope,
+		ExpiresAt: time.Now().Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.sign(encoded)), nil
+}
+
+// Verify checks that token is a valid, unexpired token for sessionID issued
+// for one of scopes, and returns its scope
+func (s *TokenSigner) Verify(token, sessionID string, scopes ...string) (string, error) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found {
+		return "", ErrInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, s.sign(encoded)) {
+		return "", ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	var claims tokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.SessionID != sessionID {
+		return "", ErrInvalidToken
+	}
+	if !slices.Contains(scopes, claims.Scope) {
+		return "", ErrInvalidToken
+	}
+	if time.Now().Unix() > claims.ExpiresAt {
+		return "", ErrExpiredToken
+	}
+	return claims.Scope, nil
+}
+
+// sign returns the HMAC of an encoded payload
+func (s *TokenSigner) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
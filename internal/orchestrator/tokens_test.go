@@ -0,0 +1,124 @@
+package orchestrator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenSignerVerify(t *testing.T) {
+	signer := NewTokenSigner([]byte("test-secret"))
+
+	issue := func(t *testing.T, sessionID, scope string, ttl time.Duration) string {
+		t.Helper()
+		token, err := signer.Issue(sessionID, scope, ttl)
+		if err != nil {
+			t.Fatalf("Issue: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name      string
+		token     func(t *testing.T) string
+		sessionID string
+		scopes    []string
+		wantScope string
+		wantErr   error
+	}{
+		{
+			name:      "ticket",
+			token:     func(t *testing.T) string { return issue(t, "session", ScopeTicket, time.Minute) },
+			sessionID: "session",
+			scopes:    []string{ScopeTicket},
+			wantScope: ScopeTicket,
+		},
+		{
+			name:      "one of several scopes",
+			token:     func(t *testing.T) string { return issue(t, "session", ScopeCoach, time.Minute) },
+			sessionID: "session",
+			scopes:    []string{ScopeObserve, ScopeCoach},
+			wantScope: ScopeCoach,
+		},
+		{
+			name:      "expired ticket",
+			token:     func(t *testing.T) string { return issue(t, "session", ScopeTicket, -2*time.Second) },
+			sessionID: "session",
+			scopes:    []string{ScopeTicket},
+			wantErr:   ErrExpiredToken,
+		},
+		{
+			name:      "ticket for another session",
+			token:     func(t *testing.T) string { return issue(t, "other", ScopeTicket, time.Minute) },
+			sessionID: "session",
+			scopes:    []string{ScopeTicket},
+			wantErr:   ErrInvalidToken,
+		},
+		{
+			name:      "resume token used as a ticket",
+			token:     func(t *testing.T) string { return issue(t, "session", ScopeResume, time.Minute) },
+			sessionID: "session",
+			scopes:    []string{ScopeTicket},
+			wantErr:   ErrInvalidToken,
+		},
+		{
+			name: "ticket moved to another session",
+			token: func(t *testing.T) string {
+				_, signature, _ := strings.Cut(issue(t, "other", ScopeTicket, time.Minute), ".")
+				payload, err := json.Marshal(tokenClaims{SessionID: "session", Scope: ScopeTicket, ExpiresAt: time.Now().Add(time.Minute).Unix()})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return base64.RawURLEncoding.EncodeToString(payload) + "." + signature
+			},
+			sessionID: "session",
+			scopes:    []string{ScopeTicket},
+			wantErr:   ErrInvalidToken,
+		},
+		{
+			name: "tampered signature",
+			token: func(t *testing.T) string {
+				payload, signature, _ := strings.Cut(issue(t, "session", ScopeTicket, time.Minute), ".")
+				first := "A"
+				if strings.HasPrefix(signature, first) {
+					first = "B"
+				}
+				return payload + "." + first + signature[1:]
+			},
+			sessionID: "session",
+			scopes:    []string{ScopeTicket},
+			wantErr:   ErrInvalidToken,
+		},
+		{
+			name: "signed with another secret",
+			token: func(t *testing.T) string {
+				token, err := NewTokenSigner([]byte("other-secret")).Issue("session", ScopeTicket, time.Minute)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+			sessionID: "session",
+			scopes:    []string{ScopeTicket},
+			wantErr:   ErrInvalidToken,
+		},
+		{
+			name:      "malformed",
+			token:     func(t *testing.T) string { return "not-a-token" },
+			sessionID: "session",
+			scopes:    []string{ScopeTicket},
+			wantErr:   ErrInvalidToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope, err := signer.Verify(tt.token(t), tt.sessionID, tt.scopes...)
+			if !errors.Is(err, tt.wantErr) || scope != tt.wantScope {
+				t.Errorf("Verify = %q, %v, want %q, %v", scope, err, tt.wantScope, tt.wantErr)
+			}
+		})
+	}
+}
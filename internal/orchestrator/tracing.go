@@ -0,0 +1,157 @@
+package orchestrator
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/torteous44/callservice/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Spans of a session. A turn runs from the end of the candidate's speech to
+// the first interviewer audio sent back, with the stages in between as
+// children: speech recognition here, grading, follow-up generation and speech
+// synthesis by the calls made with the turn's context
+const (
+	spanSession = "interview.session"
+	spanTurn    = "interview.turn"
+	spanSTT     = "turn.stt_final"
+)
+
+// Turn span events and attributes
+const (
+	eventFirstAudio    = "first_audio_frame"
+	eventGradeProduced = "grade_produced"
+
+	attrTurn       = attribute.Key("turn.index")
+	attrTurnEnd    = attribute.Key("turn.end_reason") // Why the candidate's speech ended
+	attrTurnResult = attribute.Key("turn.result")     // How the turn ended: answered, superseded or closed
+	attrRestored   = attribute.Key("session.restored")
+	attrCloseCause = attribute.Key("session.close_reason")
+	attrDecision   = attribute.Key("grade.decision")
+)
+
+// sessionTrace holds the spans of a session: its root span, and the span of
+// the turn awaiting the interviewer's answer
+type sessionTrace struct {
+	mu    sync.Mutex
+	root  trace.Span
+	ctx   context.Context // Carries the root span
+	turns int
+
+	turn        trace.Span      // Current turn, if one is open
+	turnCtx     context.Context // Carries the turn span
+	stt         trace.Span      // Recognition of the current turn, until its final transcript
+	lastFinalAt time.Time       // Last final transcript, which may arrive before the turn starts
+}
+
+// startTrace starts the root span of a session. Sessions must have their
+// lesson set first
+func startTrace(session *InterviewSession, restored bool) *sessionTrace {
+	attrs := []attribute.KeyValue{tracing.AttrSession.String(session.ID), attrRestored.Bool(restored)}
+	if session.Lesson != nil {
+		attrs = append(attrs, tracing.AttrLesson.String(session.Lesson.LessonID))
+	}
+	ctx, root := tracing.Tracer().Start(context.Background(), spanSession,
+		trace.WithNewRoot(), trace.WithAttributes(attrs...))
+	return &sessionTrace{root: root, ctx: ctx}
+}
+
+// startTurn opens a turn whose candidate speech ended at for reason. A turn
+// still awaiting an answer is superseded
+func (t *sessionTrace) startTurn(at time.Time, reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.endTurn(at, "superseded")
+	t.turns++
+	t.turnCtx, t.turn = tracing.Tracer().Start(t.ctx, spanTurn, trace.WithTimestamp(at),
+		trace.WithAttributes(attrTurn.Int(t.turns), attrTurnEnd.String(reason)))
+
+	_, t.stt = tracing.Tracer().Start(t.turnCtx, spanSTT, trace.WithTimestamp(at))
+	if t.lastFinalAt.After(at) {
+		// Recognition finished before the speech end was detected
+		t.endSTT(t.lastFinalAt)
+	}
+}
+
+// transcribed records a final transcript of the candidate's speech, with the
+// AssemblyAI session that produced it
+func (t *sessionTrace) transcribed(at time.Time, assemblyAIID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lastFinalAt = at
+	if t.stt != nil {
+		t.stt.SetAttributes(tracing.AttrAssemblyAI.String(assemblyAIID))
+		t.endSTT(at)
+	}
+}
+
+// endSTT ends the recognition span of the current turn. The caller must hold t.mu
+func (t *sessionTrace) endSTT(at time.Time) {
+	t.stt.End(trace.WithTimestamp(at))
+	t.stt = nil
+}
+
+// graded records a grade produced for the current turn
+func (t *sessionTrace) graded(decision string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.turn != nil {
+		t.turn.AddEvent(eventGradeProduced, trace.WithAttributes(attrDecision.String(decision)))
+	}
+}
+
+// answered records the first interviewer audio sent back, which ends the turn
+func (t *sessionTrace) answered(at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.turn != nil {
+		t.turn.AddEvent(eventFirstAudio, trace.WithTimestamp(at))
+		t.endTurn(at, "answered")
+	}
+}
+
+// endTurn ends the current turn, if any, with result. The caller must hold t.mu
+func (t *sessionTrace) endTurn(at time.Time, result string) {
+	if t.turn == nil {
+		return
+	}
+	if t.stt != nil {
+		t.stt.SetStatus(codes.Error, "no final transcript")
+		t.endSTT(at)
+	}
+	t.turn.SetAttributes(attrTurnResult.String(result))
+	t.turn.End(trace.WithTimestamp(at))
+	t.turn, t.turnCtx = nil, nil
+}
+
+// context returns a context carrying the current turn span, or the session's
+// root span between turns. Grading, follow-up generation and speech synthesis
+// calls made with it are traced as stages of the turn
+func (t *sessionTrace) context() context.Context {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.turnCtx != nil {
+		return t.turnCtx
+	}
+	return t.ctx
+}
+
+// end ends the session's spans once it is closed for reason
+func (t *sessionTrace) end(reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.endTurn(now, "closed")
+	t.root.SetAttributes(attrCloseCause.String(reason))
+	t.root.End(trace.WithTimestamp(now))
+}
@@ -0,0 +1,80 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/torteous44/callservice/internal/limits"
+	"github.com/torteous44/callservice/internal/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans records the spans started during a test in memory
+func recordSpans(t *testing.T) (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewProvider(sdktrace.NewSimpleSpanProcessor(exporter), tracing.Options{SampleRatio: 1})
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		provider.Shutdown(context.Background())
+	})
+	return provider, exporter
+}
+
+func TestAnswerSpansAreChildrenOfTurn(t *testing.T) {
+	provider, exporter := recordSpans(t)
+	fake := newFakeOpenAI(t)
+	im := newAnsweringManager(fake, limits.Spend{})
+	session := newLessonSession(t, im)
+
+	session.trace.startTurn(time.Now(), "vad")
+	im.respond(session, "I would look at revenue.")
+	deadline := time.Now().Add(5 * time.Second)
+	for session.answering.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("the interviewer did not answer")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	provider.ForceFlush(context.Background())
+
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+	turn, ok := spans[spanTurn]
+	if !ok {
+		t.Fatalf("no %s span ended, got %v", spanTurn, spanNames(exporter))
+	}
+	for _, attr := range turn.Attributes {
+		if attr.Key == attrTurnResult && attr.Value.AsString() != "answered" {
+			t.Errorf("turn result = %q, want answered", attr.Value.AsString())
+		}
+	}
+
+	for _, name := range []string{"contextbrain.grading", "contextbrain.follow_up", "tts.synthesize"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("no %s span", name)
+			continue
+		}
+		if span.Parent.SpanID() != turn.SpanContext.SpanID() {
+			t.Errorf("%s span is not a child of the turn", name)
+		}
+	}
+}
+
+// spanNames returns the names of the spans recorded by exporter
+func spanNames(exporter *tracetest.InMemoryExporter) []string {
+	var names []string
+	for _, span := range exporter.GetSpans() {
+		names = append(names, span.Name)
+	}
+	return names
+}
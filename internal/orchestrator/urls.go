@@ -0,0 +1,186 @@
+package orchestrator
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"github.com/torteous44/callservice/internal/audio/opus"
+)
+
+// URLOptions configures the URLs handed to clients
+type URLOptions struct {
+	// PublicURL is the base URL clients reach the service at, e.g.
+	// https://interview.example.com/calls. If nil, URLs are derived from
+	// each request
+	PublicURL *url.URL
+
+	// TrustedProxies may set X-Forwarded-Proto and X-Forwarded-Host. The
+	// headers of any other peer are ignored
+	TrustedProxies []netip.Prefix
+
+	// AdvertiseTransports adds the HTTP fallback and WebRTC signaling URLs to
+	// new session responses
+	AdvertiseTransports bool
+}
+
+// TransportURLs are the alternatives to a session's WebSocket
+type TransportURLs struct {
+	Events      string `json:"events"`           // Server-Sent Events stream
+	AudioUpload string `json:"audio_upload"`     // Chunked audio upload
+	WebRTC      string `json:"webrtc,omitempty"` // SDP offer, if this server can decode Opus
+}
+
+// ParsePublicURL parses a public base URL, which must be an absolute http or
+// https URL without a query
+func ParsePublicURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%q is not an absolute http or https URL", raw)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("%q must not have a query or fragment", raw)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return u, nil
+}
+
+// ParseTrustedProxies parses proxy addresses given as IPs or CIDR prefixes
+func ParseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		if addr, err := netip.ParseAddr(proxy); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR prefix", proxy)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// baseURL returns the URL the client reached the service at: the public URL
+// if configured, otherwise the request's scheme and host as forwarded by a
+// trusted proxy
+func (im *InterviewManager) baseURL(r *http.Request) *url.URL {
+	if im.urls.PublicURL != nil {
+		base := *im.urls.PublicURL
+		return &base
+	}
+
+	base := &url.URL{Scheme: "http", Host: r.Host}
+	if r.TLS != nil {
+		base.Scheme = "https"
+	}
+	if !im.fromTrustedProxy(r) {
+		return base
+	}
+	if proto := firstHeaderValue(r, "X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		base.Scheme = proto
+	}
+	if host := firstHeaderValue(r, "X-Forwarded-Host"); host != "" {
+		base.Host = host
+	}
+	return base
+}
+
+// httpURL returns the URL of a path on the service
+func (im *InterviewManager) httpURL(r *http.Request, path string) string {
+	u := im.baseURL(r)
+	u.Path += path
+	return u.String()
+}
+
+// websocketURL returns the WebSocket URL of a path on the service, wss://
+// when the client reached it over TLS
+func (im *InterviewManager) websocketURL(r *http.Request, path string) string {
+	u := im.baseURL(r)
+	u.Path += path
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+	return u.String()
+}
+
+// transportURLs returns the alternative transport URLs of a session, or nil
+// if they are not advertised
+func (im *InterviewManager) transportURLs(r *http.Request, sessionID string) *TransportURLs {
+	if !im.urls.AdvertiseTransports {
+		return nil
+	}
+
+	urls := &TransportURLs{
+		Events:      im.httpURL(r, "/api/interview/"+sessionID+"/events"),
+		AudioUpload: im.httpURL(r, "/api/interview/"+sessionID+"/audio"),
+	}
+	if opus.Supported() {
+		urls.WebRTC = im.httpURL(r, "/api/interview/"+sessionID+"/rtc")
+	}
+	return urls
+}
+
+// fromTrustedProxy reports whether the request came straight from a trusted
+// proxy
+func (im *InterviewManager) fromTrustedProxy(r *http.Request) bool {
+	addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	return im.trustedProxy(addrPort.Addr())
+}
+
+// trustedProxy reports whether addr is a trusted proxy
+func (im *InterviewManager) trustedProxy(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range im.urls.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the address a request came from. Behind trusted proxies it
+// is the last X-Forwarded-For hop that is not one of them, since the hops
+// before it are whatever the client claimed. It returns "" if the address
+// cannot be parsed
+func (im *InterviewManager) clientIP(r *http.Request) string {
+	addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return ""
+	}
+	addr := addrPort.Addr().Unmap()
+	if !im.trustedProxy(addr) {
+		return addr.String()
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop.Unmap()
+		if !im.trustedProxy(addr) {
+			break
+		}
+	}
+	return addr.String()
+}
+
+// firstHeaderValue returns the first of a header's comma-separated values,
+// the one set by the proxy closest to the client
+func firstHeaderValue(r *http.Request, name string) string {
+	value, _, _ := strings.Cut(r.Header.Get(name), ",")
+	return strings.ToLower(strings.TrimSpace(value))
+}
@@ -0,0 +1,264 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/pion/webrtc/v4"
+	"github.com/torteous44/callservice/internal/audio/opus"
+	"github.com/torteous44/callservice/internal/logging"
+	"github.com/torteous44/callservice/internal/metrics"
+	"github.com/torteous44/callservice/internal/sessionstate"
+	"github.com/torteous44/callservice/pkg/transport"
+)
+
+// rtcAudioFormat is the interviewer audio format WebRTC clients can play:
+// Ogg Opus, as synthesized with the opus response format
+const rtcAudioFormat = "opus"
+
+// HandleRTCOffer answers a WebRTC offer for a session. The client streams
+// Opus on an audio track, which is decoded into the session format for VAD
+// and STT, and exchanges protocol messages on a data channel it opens before
+// creating the offer. The interviewer plays on an Opus track in the answer.
+// The offer claims the session's client slot like a WebSocket connection does
+func (im *InterviewManager) HandleRTCOffer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	lastSeq, err := lastSeqParam(r)
+	if err != nil {
+		http.Error(w, "Invalid last_seq", http.StatusBadRequest)
+		return
+	}
+
+	sessionID := r.PathValue("id")
+	if !im.authorizeSession(w, r, sessionID) {
+		return
+	}
+	session, exists := im.acquireSession(sessionID)
+	if !exists {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	session.mu.RLock()
+	format := sessionAudioFormat(session.StreamingSTT.GetConfig())
+	session.mu.RUnlock()
+
+	// Opus decodes to 16-bit PCM at a handful of rates
+	if format.Encoding != "pcm_s16le" {
+		http.Error(w, "WebRTC requires a pcm_s16le session", http.StatusBadRequest)
+		return
+	}
+	if err := opus.CheckSampleRate(format.SampleRate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	decoder, err := opus.NewDecoder(format.SampleRate)
+	if errors.Is(err, opus.ErrUnsupported) {
+		http.Error(w, "WebRTC is not supported by this server", http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		session.log.Error("Cannot decode opus", logging.Err(err))
+		http.Error(w, "Failed to create audio decoder", http.StatusInternalServerError)
+		return
+	}
+
+	var offer webrtc.SessionDescription
+	if err := json.NewDecoder(r.Body).Decode(&offer); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	peer, err := transport.NewRTCPeer(im.rtcOptions, offer)
+	if err != nil {
+		session.log.Warn("Rejected WebRTC offer", logging.Err(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	client := &rtcClient{RTCPeer: peer}
+
+	session.mu.Lock()
+	if status, message := im.claimSession(session, r.URL.Query().Get("resume_token")); status != 0 {
+		session.mu.Unlock()
+		peer.Close()
+		http.Error(w, message, status)
+		return
+	}
+	session.Client = client
+	session.Status = "connected"
+	session.muted.Store(false)
+	session.touch()
+	session.mu.Unlock()
+	im.persistSession(session)
+
+	im.recordEvent(session, sessionstate.EventClientConnected, nil)
+	metrics.ClientConnects.WithLabelValues(transportWebRTC).Inc()
+	session.log.Info("WebRTC peer connected")
+
+	json.NewEncoder(w).Encode(peer.LocalDescription())
+
+	// The peer outlives the signaling request
+	go im.handleRTC(session, client, decoder, format, lastSeq)
+}
+
+// handleRTC runs a session over a WebRTC peer until the client stops or the
+// connection fails. Messages the client missed after lastSeq are replayed
+func (im *InterviewManager) handleRTC(session *InterviewSession, client *rtcClient, decoder *opus.Decoder,
+	format transport.AudioFormat, lastSeq int64) {
+
+	defer func() {
+		session.Outbox.Detach(client)
+		im.releaseSession(session)
+		metrics.ClientDisconnects.WithLabelValues(transportWebRTC).Inc()
+		client.Wait()
+
+		jitter := client.JitterStats()
+		session.log.Info("WebRTC peer disconnected", "packets", jitter.Packets, "lost", jitter.Lost, "late", jitter.Late)
+	}()
+
+	im.processSession(session)
+
+	client.Send(transport.TypeReady, transport.ReadyMessage{
+		SessionID: session.ID,
+		Version:   transport.ProtocolVersion,
+		Format:    format,
+	})
+
+	// Catch the client up before any new message reaches it
+	replayed, complete := session.Outbox.Attach(client, lastSeq)
+	if replayed > 0 || !complete {
+		session.log.Info("Replayed missed messages", "replayed", replayed, "last_seq", lastSeq, "complete", complete)
+	}
+	if !complete {
+		client.Send(transport.TypeStatus, transport.StatusMessage{
+			SessionID: session.ID,
+			Status:    "resync",
+			Details:   "some missed messages are no longer available, reload the session state and timeline",
+		})
+	}
+
+	im.sendToClient(session, transport.TypeStatus, transport.StatusMessage{
+		SessionID: session.ID,
+		Status:    "connected",
+	})
+
+	ingest, err := NewAudioIngest(IngestConfig{Format: format}, nil)
+	if err != nil {
+		session.log.Error("Cannot ingest audio", logging.Err(err))
+		client.SendError(transport.ErrorCodeAudio, err.Error())
+		return
+	}
+
+	im.runIngest(session, ingest, func() {
+		im.readRTC(session, client, decoder, ingest)
+	})
+}
+
+// readRTC decodes client audio into the ingest and applies control messages
+// until the client stops or the connection fails
+func (im *InterviewManager) readRTC(session *InterviewSession, client *rtcClient, decoder *opus.Decoder,
+	ingest *AudioIngest) {
+
+	for {
+		frame, err := client.ReadFrame()
+		if err != nil {
+			if !errors.Is(err, transport.ErrConnClosed) {
+				session.log.Error("WebRTC peer failed", logging.Err(err))
+			}
+			return
+		}
+		session.touch()
+
+		var pcm []byte
+		switch frame.Kind {
+		case transport.FrameControl:
+			if im.handleControl(session, client, frame.Message) {
+				return
+			}
+			continue
+		case transport.FrameAudio:
+			pcm, err = decoder.Decode(frame.Audio)
+		case transport.FrameAudioLoss:
+			pcm, err = decoder.Conceal()
+		}
+		if err != nil {
+			session.log.Warn("Dropped client audio", logging.Err(err))
+			continue
+		}
+		if session.muted.Load() {
+			continue
+		}
+
+		if err := ingest.ProcessAudio(session.ctx, pcm); err != nil {
+			var audioErr *AudioError
+			if !errors.As(err, &audioErr) {
+				return
+			}
+			session.log.Warn("Rejected audio", logging.Err(err))
+			client.SendError(transport.ErrorCodeAudio, audioErr.Message)
+		}
+	}
+}
+
+// rtcClient is the candidate end of a WebRTC peer. Interviewer audio is played
+// on the peer's audio track; every other message goes over the data channel
+type rtcClient struct {
+	*transport.RTCPeer
+}
+
+// Send queues a message
+func (c *rtcClient) Send(messageType string, payload interface{}) error {
+	msg, err := transport.NewMessage(messageType, payload)
+	if err != nil {
+		return err
+	}
+	return c.SendMessage(msg)
+}
+
+// ReplayMessage queues a replayed message, waiting for room in the data
+// channel queue. Interviewer audio goes to the track's queue, which holds a
+// minute of packets
+func (c *rtcClient) ReplayMessage(msg transport.Message) error {
+	if msg.Type != transport.TypeInterviewerAudio {
+		return c.RTCPeer.ReplayMessage(msg)
+	}
+	return c.SendMessage(msg)
+}
+
+// SendMessage queues a message, or the packets of an interviewer utterance
+func (c *rtcClient) SendMessage(msg transport.Message) error {
+	if msg.Type != transport.TypeInterviewerAudio {
+		return c.RTCPeer.SendMessage(msg)
+	}
+
+	var audio transport.AudioMessage
+	if err := msg.Decode(&audio); err != nil {
+		return err
+	}
+	if audio.Format != rtcAudioFormat {
+		return fmt.Errorf("%s interviewer audio cannot be played over WebRTC", audio.Format)
+	}
+
+	packets, err := opus.ReadOggPackets(audio.Data)
+	if err != nil {
+		return err
+	}
+	for _, packet := range packets {
+		duration, err := opus.PacketDuration(packet)
+		if err != nil {
+			return err
+		}
+		if err := c.WriteAudio(packet, duration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
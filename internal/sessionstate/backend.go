@@ -0,0 +1,63 @@
+package sessionstate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned when a session does not exist in the backend
+var ErrNotFound = errors.New("session not found")
+
+// DefaultSessionTTL is how long an idle session is kept in the backend
+const DefaultSessionTTL = 2 * time.Hour
+
+// Record is the serialized form of an interview session that any instance
+// can use to rehydrate the session after a restart or load-balancer hop
+type Record struct {
+	ID               string          `json:"id"`
+	Status           string          `json:"status"`
+	OwnerID          string          `json:"owner_id,omitempty"` // User that created the session, if authenticated
+	StartTime        time.Time       `json:"start_time"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+	TranscriptCount  int             `json:"transcript_count"`
+	UtteranceCount   int             `json:"utterance_count"`
+	AssemblyAIID     string          `json:"assemblyai_id,omitempty"`
+	OutboxSeq        int64           `json:"outbox_seq,omitempty"`           // Last sequence number sent to the client
+	PlaybackPosition int64           `json:"playback_position_ms,omitempty"` // Interviewer audio played by the client
+	Usage            Usage           `json:"usage"`                          // Spend on paid services, checked against the session budget
+	StreamingConfig  json.RawMessage `json:"streaming_config,omitempty"`
+	Lesson           json.RawMessage `json:"lesson,omitempty"`
+	State            *InterviewState `json:"state,omitempty"`
+}
+
+// Usage is what a session has spent on paid services
+type Usage struct {
+	AudioMS       int64 `json:"audio_ms,omitempty"`
+	LLMTokens     int   `json:"llm_tokens,omitempty"`
+	TTSCharacters int   `json:"tts_characters,omitempty"`
+}
+
+// Backend persists session records and their transcripts
+type Backend interface {
+	// SaveSession writes the session record and refreshes its TTL
+	SaveSession(ctx context.Context, record *Record, ttl time.Duration) error
+	// LoadSession reads a session record, returning ErrNotFound if it is missing or expired
+	LoadSession(ctx context.Context, id string) (*Record, error)
+	// AppendTranscript appends a serialized transcript entry and refreshes its TTL
+	AppendTranscript(ctx context.Context, id string, entry []byte, ttl time.Duration) error
+	// LoadTranscript returns all serialized transcript entries in order
+	LoadTranscript(ctx context.Context, id string) ([][]byte, error)
+	// AppendEvent appends a serialized event to the session log and returns its
+	// sequence number, starting at 1
+	AppendEvent(ctx context.Context, id string, event []byte, ttl time.Duration) (int64, error)
+	// LoadEvents returns the serialized events with a sequence number above afterSeq
+	LoadEvents(ctx context.Context, id string, afterSeq int64) ([][]byte, error)
+	// DeleteSession removes the session record, transcript and event log
+	DeleteSession(ctx context.Context, id string) error
+	// Ping checks that the backend is reachable
+	Ping(ctx context.Context) error
+	// Close releases backend resources
+	Close() error
+}
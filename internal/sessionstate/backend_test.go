@@ -0,0 +1,209 @@
+package sessionstate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// backendCase creates a backend under test, with a way to move its clock
+// past TTLs
+type backendCase struct {
+	name string
+	new  func(t *testing.T) (Backend, func(time.Duration))
+}
+
+var backendCases = []backendCase{
+	{
+		name: "memory",
+		new: func(t *testing.T) (Backend, func(time.Duration)) {
+			backend := NewMemoryBackend()
+			now := time.Now()
+			backend.now = func() time.Time { return now }
+			return backend, func(d time.Duration) { now = now.Add(d) }
+		},
+	},
+	{
+		name: "redis",
+		new: func(t *testing.T) (Backend, func(time.Duration)) {
+			server := miniredis.RunT(t)
+			backend, err := NewRedisBackend("redis://" + server.Addr())
+			if err != nil {
+				t.Fatalf("NewRedisBackend: %v", err)
+			}
+			t.Cleanup(func() { backend.Close() })
+			return backend, server.FastForward
+		},
+	},
+}
+
+// forEachBackend runs test against every backend
+func forEachBackend(t *testing.T, test func(t *testing.T, backend Backend, fastForward func(time.Duration))) {
+	for _, bc := range backendCases {
+		t.Run(bc.name, func(t *testing.T) {
+			backend, fastForward := bc.new(t)
+			test(t, backend, fastForward)
+		})
+	}
+}
+
+func TestBackendSessionRoundTrip(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, backend Backend, _ func(time.Duration)) {
+		ctx := context.Background()
+		state := DefaultInterviewState()
+		state.CurrentQuestion = 2
+		state.ComponentsHit = []string{"Revenue"}
+		record := &Record{
+			ID:              "session",
+			Status:          "active",
+			OwnerID:         "user",
+			StartTime:       time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			UpdatedAt:       time.Date(2025, 1, 2, 3, 5, 0, 0, time.UTC),
+			TranscriptCount: 4,
+			UtteranceCount:  1,
+			AssemblyAIID:    "assemblyai",
+			OutboxSeq:       7,
+			Usage:           Usage{AudioMS: 1500, LLMTokens: 200, TTSCharacters: 30},
+			StreamingConfig: json.RawMessage(`{"sample_rate":16000}`),
+			Lesson:          json.RawMessage(`{"lesson":{"lesson_id":"lesson"}}`),
+			State:           &state,
+		}
+
+		if _, err := backend.LoadSession(ctx, record.ID); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("LoadSession before saving: err = %v, want ErrNotFound", err)
+		}
+		if err := backend.SaveSession(ctx, record, time.Hour); err != nil {
+			t.Fatalf("SaveSession: %v", err)
+		}
+		loaded, err := backend.LoadSession(ctx, record.ID)
+		if err != nil {
+			t.Fatalf("LoadSession: %v", err)
+		}
+		if !reflect.DeepEqual(loaded, record) {
+			t.Errorf("LoadSession = %+v, want %+v", loaded, record)
+		}
+
+		record.Status = "disconnected"
+		if err := backend.SaveSession(ctx, record, time.Hour); err != nil {
+			t.Fatalf("SaveSession again: %v", err)
+		}
+		if loaded, err := backend.LoadSession(ctx, record.ID); err != nil || loaded.Status != "disconnected" {
+			t.Errorf("LoadSession after update = %+v, %v, want the disconnected record", loaded, err)
+		}
+	})
+}
+
+func TestBackendTranscriptAndEvents(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, backend Backend, _ func(time.Duration)) {
+		ctx := context.Background()
+		if err := backend.SaveSession(ctx, &Record{ID: "session"}, time.Hour); err != nil {
+			t.Fatalf("SaveSession: %v", err)
+		}
+
+		var want [][]byte
+		for i := range 3 {
+			entry := []byte(fmt.Sprintf(`{"text":"entry %d"}`, i))
+			want = append(want, entry)
+			if err := backend.AppendTranscript(ctx, "session", entry, time.Hour); err != nil {
+				t.Fatalf("AppendTranscript: %v", err)
+			}
+		}
+		transcript, err := backend.LoadTranscript(ctx, "session")
+		if err != nil {
+			t.Fatalf("LoadTranscript: %v", err)
+		}
+		if !reflect.DeepEqual(transcript, want) {
+			t.Errorf("LoadTranscript = %q, want %q", transcript, want)
+		}
+
+		for i := range 3 {
+			seq, err := backend.AppendEvent(ctx, "session", []byte(fmt.Sprintf(`{"n":%d}`, i)), time.Hour)
+			if err != nil {
+				t.Fatalf("AppendEvent: %v", err)
+			}
+			if seq != int64(i+1) {
+				t.Errorf("AppendEvent seq = %d, want %d", seq, i+1)
+			}
+		}
+		events, err := backend.LoadEvents(ctx, "session", 1)
+		if err != nil {
+			t.Fatalf("LoadEvents: %v", err)
+		}
+		if want := [][]byte{[]byte(`{"n":1}`), []byte(`{"n":2}`)}; !reflect.DeepEqual(events, want) {
+			t.Errorf("LoadEvents after 1 = %q, want %q", events, want)
+		}
+
+		if err := backend.DeleteSession(ctx, "session"); err != nil {
+			t.Fatalf("DeleteSession: %v", err)
+		}
+		if _, err := backend.LoadSession(ctx, "session"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("LoadSession after delete: err = %v, want ErrNotFound", err)
+		}
+		if transcript, _ := backend.LoadTranscript(ctx, "session"); len(transcript) != 0 {
+			t.Errorf("LoadTranscript after delete = %q, want none", transcript)
+		}
+		if events, _ := backend.LoadEvents(ctx, "session", 0); len(events) != 0 {
+			t.Errorf("LoadEvents after delete = %q, want none", events)
+		}
+	})
+}
+
+func TestBackendExpiry(t *testing.T) {
+	appends := []struct {
+		name   string
+		append func(ctx context.Context, backend Backend, id string) error
+	}{
+		{"transcript", func(ctx context.Context, backend Backend, id string) error {
+			return backend.AppendTranscript(ctx, id, []byte(`{}`), time.Minute)
+		}},
+		{"event", func(ctx context.Context, backend Backend, id string) error {
+			_, err := backend.AppendEvent(ctx, id, []byte(`{}`), time.Minute)
+			return err
+		}},
+	}
+
+	forEachBackend(t, func(t *testing.T, backend Backend, fastForward func(time.Duration)) {
+		for _, ap := range appends {
+			t.Run(ap.name, func(t *testing.T) {
+				ctx := context.Background()
+				id := "session-" + ap.name
+				if err := backend.SaveSession(ctx, &Record{ID: id}, time.Minute); err != nil {
+					t.Fatalf("SaveSession: %v", err)
+				}
+				if err := backend.AppendTranscript(ctx, id, []byte(`{}`), time.Minute); err != nil {
+					t.Fatalf("AppendTranscript: %v", err)
+				}
+				if _, err := backend.AppendEvent(ctx, id, []byte(`{}`), time.Minute); err != nil {
+					t.Fatalf("AppendEvent: %v", err)
+				}
+
+				// Appending keeps the whole session alive
+				fastForward(45 * time.Second)
+				if err := ap.append(ctx, backend, id); err != nil {
+					t.Fatalf("append: %v", err)
+				}
+				fastForward(45 * time.Second)
+				if _, err := backend.LoadSession(ctx, id); err != nil {
+					t.Fatalf("LoadSession within the refreshed TTL: %v", err)
+				}
+				if transcript, _ := backend.LoadTranscript(ctx, id); len(transcript) == 0 {
+					t.Error("transcript expired within the refreshed TTL")
+				}
+				if events, _ := backend.LoadEvents(ctx, id, 0); len(events) == 0 {
+					t.Error("events expired within the refreshed TTL")
+				}
+
+				fastForward(time.Minute)
+				if _, err := backend.LoadSession(ctx, id); !errors.Is(err, ErrNotFound) {
+					t.Errorf("LoadSession after the TTL: err = %v, want ErrNotFound", err)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package sessionstate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EventType identifies a kind of session timeline event
+type EventType string
+
+// Session timeline event types
+const (
+	EventSessionCreated     EventType = "session_created"
+	EventClientConnected    EventType = "client_connected"
+	EventClientDisconnected EventType = "client_disconnected"
+	EventSessionClosed      EventType = "session_closed"
+	EventAudioSegment       EventType = "audio_segment_received"
+	EventVADSpeechStart     EventType = "vad_speech_start"
+	EventVADSpeechEnd       EventType = "vad_speech_end"
+	EventSTTFinal           EventType = "stt_final"
+	EventSTTTurn            EventType = "stt_turn"
+	EventGradeProduced      EventType = "grade_produced"
+	EventStateTransition    EventType = "state_transition"
+	EventTTSStarted         EventType = "tts_started"
+	EventTTSStopped         EventType = "tts_stopped"
+	EventHintGiven          EventType = "hint_given"
+)
+
+// Event is a single timestamped entry in a session's append-only timeline
+type Event struct {
+	Seq       int64           `json:"seq"`
+	SessionID string          `json:"session_id"`
+	Type      EventType       `json:"type"`
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// SessionCreatedData is the payload of session_created events
+type SessionCreatedData struct {
+	LessonID string         `json:"lesson_id,omitempty"`
+	State    InterviewState `json:"state"`
+}
+
+// SessionClosedData is the payload of session_closed events
+type SessionClosedData struct {
+	Reason string `json:"reason"` // "client_closed", "idle", "disconnected" or "lifetime"
+}
+
+// StateTransitionData is the payload of state_transition events
+type StateTransitionData struct {
+	Version uint64                     `json:"version"`
+	Fields  []string                   `json:"fields"`
+	Changes map[string]json.RawMessage `json:"changes"` // New values of the changed fields
+}
+
+// AudioSegmentData is the payload of audio_segment_received events
+type AudioSegmentData struct {
+	Bytes    int   `json:"bytes"`
+	Chunks   int   `json:"chunks"`
+	Duration int64 `json:"duration_ms"`
+}
+
+// SpeechData is the payload of vad_speech_start and vad_speech_end events
+type SpeechData struct {
+	Reason   string `json:"reason,omitempty"` // "silence" or "max_duration" for speech end
+	Duration int64  `json:"duration_ms,omitempty"`
+}
+
+// TranscriptData is the payload of stt_final and stt_turn events
+type TranscriptData struct {
+	Text         string  `json:"text"`
+	Confidence   float64 `json:"confidence"`
+	AssemblyAIID string  `json:"assemblyai_id,omitempty"`
+}
+
+// GradeData is the payload of grade_produced events
+type GradeData struct {
+	QuestionIndex int                `json:"question_index"`
+	Scores        map[string]float64 `json:"scores,omitempty"`
+	OverallScore  float64            `json:"overall_score"`
+	Decision      string             `json:"decision"`
+	Feedback      []string           `json:"feedback,omitempty"`
+}
+
+// UtteranceData is the payload of tts_started and tts_stopped events
+type UtteranceData struct {
+	Text        string `json:"text,omitempty"`
+	Interrupted bool   `json:"interrupted,omitempty"`
+}
+
+// HintData is the payload of hint_given events
+type HintData struct {
+	QuestionIndex int    `json:"question_index"`
+	HintIndex     int    `json:"hint_index"` // -1 for free text
+	Text          string `json:"text"`
+	Source        string `json:"source,omitempty"` // "silence", "request" or "coach"
+}
+
+// EventLog appends events to a session's timeline in the backend
+type EventLog struct {
+	sessionID string
+	backend   Backend
+	ttl       time.Duration
+}
+
+// NewEventLog creates an event log for a session
+func NewEventLog(sessionID string, backend Backend, ttl time.Duration) *EventLog {
+	return &EventLog{
+		sessionID: sessionID,
+		backend:   backend,
+		ttl:       ttl,
+	}
+}
+
+// Append records an event with the given payload and returns it with its sequence number
+func (l *EventLog) Append(ctx context.Context, eventType EventType, data interface{}) (Event, error) {
+	event := Event{
+		SessionID: l.sessionID,
+		Type:      eventType,
+		Timestamp: time.Now(),
+	}
+
+	if data != nil {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return Event{}, fmt.Errorf("failed to marshal %s event: %w", eventType, err)
+		}
+		event.Data = payload
+	}
+
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to marshal %s event: %w", eventType, err)
+	}
+
+	seq, err := l.backend.AppendEvent(ctx, l.sessionID, raw, l.ttl)
+	if err != nil {
+		return Event{}, err
+	}
+	event.Seq = seq
+	return event, nil
+}
+
+// Events returns the events with a sequence number above afterSeq
+func (l *EventLog) Events(ctx context.Context, afterSeq int64) ([]Event, error) {
+	return LoadEvents(ctx, l.backend, l.sessionID, afterSeq)
+}
+
+// LoadEvents reads a session's events with a sequence number above afterSeq
+func LoadEvents(ctx context.Context, backend Backend, sessionID string, afterSeq int64) ([]Event, error) {
+	if afterSeq < 0 {
+		afterSeq = 0
+	}
+
+	raw, err := backend.LoadEvents(ctx, sessionID, afterSeq)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(raw))
+	for i, data := range raw {
+		var event Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+		// Sequence numbers are positions in the append-only log
+		event.Seq = afterSeq + int64(i) + 1
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// NewStateTransition builds the event payload for a committed state change
+func NewStateTransition(change StateChange) (StateTransitionData, error) {
+	full, err := json.Marshal(change.State)
+	if err != nil {
+		return StateTransitionData{}, fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(full, &values); err != nil {
+		return StateTransitionData{}, fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+
+	changes := make(map[string]json.RawMessage, len(change.Fields))
+	for _, field := range change.Fields {
+		changes[field] = values[field]
+	}
+
+	return StateTransitionData{
+		Version: change.Version,
+		Fields:  change.Fields,
+		Changes: changes,
+	}, nil
+}
+
+// Replay rebuilds the interview state as it was at the given time by applying
+// the timeline in order. A zero time replays every event
+func Replay(events []Event, at time.Time) (InterviewState, error) {
+	state := DefaultInterviewState()
+
+	for _, event := range events {
+		if !at.IsZero() && event.Timestamp.After(at) {
+			break
+		}
+
+		switch event.Type {
+		case EventSessionCreated:
+			var data SessionCreatedData
+			if err := json.Unmarshal(event.Data, &data); err != nil {
+				return state, fmt.Errorf("failed to replay event %d: %w", event.Seq, err)
+			}
+			state = data.State.Clone()
+
+		case EventStateTransition:
+			var data StateTransitionData
+			if err := json.Unmarshal(event.Data, &data); err != nil {
+				return state, fmt.Errorf("failed to replay event %d: %w", event.Seq, err)
+			}
+			changes, err := json.Marshal(data.Changes)
+			if err != nil {
+				return state, fmt.Errorf("failed to replay event %d: %w", event.Seq, err)
+			}
+			// Unmarshalling a partial object only overwrites the changed fields
+			if err := json.Unmarshal(changes, &state); err != nil {
+				return state, fmt.Errorf("failed to replay event %d: %w", event.Seq, err)
+			}
+			state.Version = data.Version
+			state.UpdatedAt = event.Timestamp
+		}
+	}
+
+	return state, nil
+}
@@ -0,0 +1,182 @@
+package sessionstate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval is how often expired entries are purged on write
+const memorySweepInterval = time.Minute
+
+// MemoryBackend is an in-process Backend used for single-instance deployments
+type MemoryBackend struct {
+	entries   map[string]*memoryEntry
+	nextSweep time.Time
+	mu        sync.Mutex
+	now       func() time.Time
+}
+
+// memoryEntry holds the serialized record, transcript and events of a session
+type memoryEntry struct {
+	record     []byte
+	transcript [][]byte
+	events     [][]byte
+	expiresAt  time.Time
+}
+
+// NewMemoryBackend creates a new in-memory backend
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		entries: make(map[string]*memoryEntry),
+		now:     time.Now,
+	}
+}
+
+// SaveSession writes the session record and refreshes its TTL
+func (m *MemoryBackend) SaveSession(ctx context.Context, record *Record, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session record: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep()
+	entry := m.liveEntry(record.ID)
+	if entry == nil {
+		entry = &memoryEntry{}
+		m.entries[record.ID] = entry
+	}
+	entry.record = data
+	entry.expiresAt = m.now().Add(ttl)
+	return nil
+}
+
+// LoadSession reads a session record
+func (m *MemoryBackend) LoadSession(ctx context.Context, id string) (*Record, error) {
+	m.mu.Lock()
+	entry := m.liveEntry(id)
+	var data []byte
+	if entry != nil {
+		data = entry.record
+	}
+	m.mu.Unlock()
+
+	if data == nil {
+		return nil, ErrNotFound
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session record: %w", err)
+	}
+	return &record, nil
+}
+
+// AppendTranscript appends a serialized transcript entry and refreshes its TTL
+func (m *MemoryBackend) AppendTranscript(ctx context.Context, id string, entry []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.liveEntry(id)
+	if e == nil {
+		e = &memoryEntry{}
+		m.entries[id] = e
+	}
+	e.transcript = append(e.transcript, append([]byte(nil), entry...))
+	e.expiresAt = m.now().Add(ttl)
+	return nil
+}
+
+// LoadTranscript returns all serialized transcript entries in order
+func (m *MemoryBackend) LoadTranscript(ctx context.Context, id string) ([][]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.liveEntry(id)
+	if entry == nil {
+		return nil, nil
+	}
+	return append([][]byte(nil), entry.transcript...), nil
+}
+
+// AppendEvent appends a serialized event to the session log
+func (m *MemoryBackend) AppendEvent(ctx context.Context, id string, event []byte, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.liveEntry(id)
+	if e == nil {
+		e = &memoryEntry{}
+		m.entries[id] = e
+	}
+	e.events = append(e.events, append([]byte(nil), event...))
+	e.expiresAt = m.now().Add(ttl)
+	return int64(len(e.events)), nil
+}
+
+// LoadEvents returns the serialized events with a sequence number above afterSeq
+func (m *MemoryBackend) LoadEvents(ctx context.Context, id string, afterSeq int64) ([][]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.liveEntry(id)
+	if entry == nil || afterSeq >= int64(len(entry.events)) {
+		return nil, nil
+	}
+	if afterSeq < 0 {
+		afterSeq = 0
+	}
+	return append([][]byte(nil), entry.events[afterSeq:]...), nil
+}
+
+// DeleteSession removes the session record, transcript and event log
+func (m *MemoryBackend) DeleteSession(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, id)
+	return nil
+}
+
+// Ping always succeeds for the in-memory backend
+func (m *MemoryBackend) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close releases backend resources
+func (m *MemoryBackend) Close() error {
+	return nil
+}
+
+// sweep purges expired entries at most once per interval. Caller must hold m.mu
+func (m *MemoryBackend) sweep() {
+	now := m.now()
+	if now.Before(m.nextSweep) {
+		return
+	}
+	m.nextSweep = now.Add(memorySweepInterval)
+
+	for id, entry := range m.entries {
+		if now.After(entry.expiresAt) {
+			delete(m.entries, id)
+		}
+	}
+}
+
+// liveEntry returns the entry for id, evicting it if expired. Caller must hold m.mu
+func (m *MemoryBackend) liveEntry(id string) *memoryEntry {
+	entry, exists := m.entries[id]
+	if !exists {
+		return nil
+	}
+	if m.now().After(entry.expiresAt) {
+		delete(m.entries, id)
+		return nil
+	}
+	return entry
+}
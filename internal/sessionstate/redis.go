@@ -0,0 +1,171 @@
+package sessionstate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultKeyPrefix namespaces all keys written by the call service
+const defaultKeyPrefix = "callservice:session:"
+
+// RedisBackend stores session records in Redis so any instance can rehydrate them
+type RedisBackend struct {
+	client    *redis.Client
+	keyPrefix string
+}
+
+// NewRedisBackend creates a Redis backend from a redis:// or rediss:// URL
+func NewRedisBackend(redisURL string) (*RedisBackend, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis URL: %w", err)
+	}
+
+	return &RedisBackend{
+		client:    redis.NewClient(opts),
+		keyPrefix: defaultKeyPrefix,
+	}, nil
+}
+
+// SaveSession writes the session record and refreshes its TTL
+func (r *RedisBackend) SaveSession(ctx context.Context, record *Record, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session record: %w", err)
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, r.recordKey(record.ID), data, ttl)
+		r.expire(ctx, pipe, record.ID, ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save session %s: %w", record.ID, err)
+	}
+	return nil
+}
+
+// LoadSession reads a session record
+func (r *RedisBackend) LoadSession(ctx context.Context, id string) (*Record, error) {
+	data, err := r.client.Get(ctx, r.recordKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session %s: %w", id, err)
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session record: %w", err)
+	}
+	return &record, nil
+}
+
+// AppendTranscript appends a serialized transcript entry and refreshes the
+// session's TTL
+func (r *RedisBackend) AppendTranscript(ctx context.Context, id string, entry []byte, ttl time.Duration) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, r.transcriptKey(id), entry)
+		r.expire(ctx, pipe, id, ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to append transcript for session %s: %w", id, err)
+	}
+	return nil
+}
+
+// LoadTranscript returns all serialized transcript entries in order
+func (r *RedisBackend) LoadTranscript(ctx context.Context, id string) ([][]byte, error) {
+	values, err := r.client.LRange(ctx, r.transcriptKey(id), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load transcript for session %s: %w", id, err)
+	}
+
+	entries := make([][]byte, len(values))
+	for i, value := range values {
+		entries[i] = []byte(value)
+	}
+	return entries, nil
+}
+
+// AppendEvent appends a serialized event to the session log and refreshes the
+// session's TTL. The list length after the push is the event's sequence
+// number, which keeps numbering consistent across instances
+func (r *RedisBackend) AppendEvent(ctx context.Context, id string, event []byte, ttl time.Duration) (int64, error) {
+	var push *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		push = pipe.RPush(ctx, r.eventsKey(id), event)
+		r.expire(ctx, pipe, id, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to append event for session %s: %w", id, err)
+	}
+	return push.Val(), nil
+}
+
+// LoadEvents returns the serialized events with a sequence number above afterSeq
+func (r *RedisBackend) LoadEvents(ctx context.Context, id string, afterSeq int64) ([][]byte, error) {
+	if afterSeq < 0 {
+		afterSeq = 0
+	}
+
+	values, err := r.client.LRange(ctx, r.eventsKey(id), afterSeq, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load events for session %s: %w", id, err)
+	}
+
+	events := make([][]byte, len(values))
+	for i, value := range values {
+		events[i] = []byte(value)
+	}
+	return events, nil
+}
+
+// DeleteSession removes the session record, transcript and event log
+func (r *RedisBackend) DeleteSession(ctx context.Context, id string) error {
+	if err := r.client.Del(ctx, r.recordKey(id), r.transcriptKey(id), r.eventsKey(id)).Err(); err != nil {
+		return fmt.Errorf("failed to delete session %s: %w", id, err)
+	}
+	return nil
+}
+
+// Ping checks that Redis is reachable
+func (r *RedisBackend) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// Close closes the Redis client
+func (r *RedisBackend) Close() error {
+	return r.client.Close()
+}
+
+// expire refreshes the TTL of a session's record and lists in pipe. Expiring a
+// key that does not exist yet does nothing
+func (r *RedisBackend) expire(ctx context.Context, pipe redis.Pipeliner, id string, ttl time.Duration) {
+	pipe.Expire(ctx, r.recordKey(id), ttl)
+	pipe.Expire(ctx, r.transcriptKey(id), ttl)
+	pipe.Expire(ctx, r.eventsKey(id), ttl)
+}
+
+// recordKey returns the key holding the session record
+func (r *RedisBackend) recordKey(id string) string {
+	return r.keyPrefix + id
+}
+
+// transcriptKey returns the key holding the session transcript list
+func (r *RedisBackend) transcriptKey(id string) string {
+	return r.keyPrefix + id + ":transcript"
+}
+
+// eventsKey returns the key holding the session event log
+func (r *RedisBackend) eventsKey(id string) string {
+	return r.keyPrefix + id + ":events"
+}
@@ -0,0 +1,64 @@
+package sessionstate
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// jsonSchemaDialect is the JSON Schema draft the exported schema conforms to
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema returns the JSON schema of InterviewState so HTTP clients can validate it
+func Schema() ([]byte, error) {
+	schema := typeSchema(reflect.TypeOf(InterviewState{}))
+	schema["$schema"] = jsonSchemaDialect
+	schema["title"] = "InterviewState"
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// typeSchema builds the schema of a Go type
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		required := make([]string, 0, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := jsonFieldName(field)
+			properties[name] = typeSchema(field.Type)
+			required = append(required, name)
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	default:
+		return map[string]interface{}{}
+	}
+}
@@ -1,29 +1,238 @@
 package sessionstate
 
-// SessionState represents the current state of a call session
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrVersionConflict is returned when a compare-and-swap update loses a race
+var ErrVersionConflict = errors.New("session state version conflict")
+
+// DefaultUserReadyQuestion is asked at the end of every question
+const DefaultUserReadyQuestion = "Are you ready to move on to the next question?"
+
+// subscriberBuffer is the number of changes buffered per subscriber
+const subscriberBuffer = 16
+
+// InterviewState is the typed runtime state of an interview session
+type InterviewState struct {
+	Version           uint64    `json:"version"`
+	UpdatedAt         time.Time `json:"updated_at"`
+	CurrentQuestion   int       `json:"current_question"`
+	SilenceTimer      int       `json:"silence_timer"`
+	HintsUsed         int       `json:"hints_used"`
+	ComponentsHit     []string  `json:"components_hit"`
+	StepsHit          []string  `json:"steps_hit"`
+	FollowUpsUsed     []int     `json:"follow_ups_used"`
+	UserReady         bool      `json:"user_ready"`
+	UserReadyQuestion string    `json:"user_ready_question"`
+	Completed         bool      `json:"completed"`
+
+	// Latest speech recognition results
+	LastTranscript          string    `json:"last_transcript"`
+	LastConfidence          float64   `json:"last_confidence"`
+	LastTranscriptAt        time.Time `json:"last_transcript_at"`
+	UtteranceCount          int       `json:"utterance_count"`
+	LastUtterance           string    `json:"last_utterance"`
+	LastUtteranceConfidence float64   `json:"last_utterance_confidence"`
+}
+
+// DefaultInterviewState returns the initial state of a new interview
+func DefaultInterviewState() InterviewState {
+	return InterviewState{
+		ComponentsHit:     make([]string, 0),
+		StepsHit:          make([]string, 0),
+		FollowUpsUsed:     make([]int, 0),
+		UserReadyQuestion: DefaultUserReadyQuestion,
+	}
+}
+
+// Clone returns a deep copy of the state
+func (s InterviewState) Clone() InterviewState {
+	s.ComponentsHit = append(make([]string, 0, len(s.ComponentsHit)), s.ComponentsHit...)
+	s.StepsHit = append(make([]string, 0, len(s.StepsHit)), s.StepsHit...)
+	s.FollowUpsUsed = append(make([]int, 0, len(s.FollowUpsUsed)), s.FollowUpsUsed...)
+	return s
+}
+
+// StateChange describes a committed update to a session state
+type StateChange struct {
+	SessionID string         `json:"session_id"`
+	Version   uint64         `json:"version"`
+	Fields    []string       `json:"fields"` // JSON names of the fields that changed
+	State     InterviewState `json:"state"`  // State after the change
+}
+
+// subscriber receives changes for a set of fields
+type subscriber struct {
+	fields map[string]bool
+	ch     chan StateChange
+}
+
+// SessionState is a concurrency-safe, versioned container for an interview's state
 type SessionState struct {
-	ID     string                 `json:"id"`
-	Status string                 `json:"status"`
-	Data   map[string]interface{} `json:"data"`
-	// TODO: Add more state fields
+	ID          string
+	state       InterviewState
+	subscribers map[int]*subscriber
+	nextSubID   int
+	mu          sync.RWMutex
 }
 
-// NewSessionState creates a new session state
+// NewSessionState creates a new session state with default values
 func NewSessionState(id string) *SessionState {
+	return RestoreSessionState(id, DefaultInterviewState())
+}
+
+// RestoreSessionState creates a session state from a previously saved snapshot
+func RestoreSessionState(id string, state InterviewState) *SessionState {
 	return &SessionState{
-		ID:     id,
-		Status: "initialized",
-		Data:   make(map[string]interface{}),
+		ID:          id,
+		state:       state.Clone(),
+		subscribers: make(map[int]*subscriber),
+	}
+}
+
+// Snapshot returns a copy of the current state
+func (s *SessionState) Snapshot() InterviewState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state.Clone()
+}
+
+// Version returns the current state version
+func (s *SessionState) Version() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state.Version
+}
+
+// Update applies fn to the state and commits it. The version is only bumped,
+// and subscribers only notified, if a field actually changed
+func (s *SessionState) Update(fn func(state *InterviewState)) StateChange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.apply(fn)
+}
+
+// CompareAndSwap applies fn only if the state is still at expectedVersion
+func (s *SessionState) CompareAndSwap(expectedVersion uint64, fn func(state *InterviewState)) (StateChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state.Version != expectedVersion {
+		return StateChange{}, ErrVersionConflict
 	}
+	return s.apply(fn), nil
 }
 
-// UpdateState updates the session state
-func (s *SessionState) UpdateState(key string, value interface{}) {
-	s.Data[key] = value
+// Subscribe returns a channel of changes touching any of the given fields (all
+// fields if none are given) and a function to cancel the subscription. Changes
+// are dropped for subscribers that fall more than a few updates behind
+func (s *SessionState) Subscribe(fields ...string) (<-chan StateChange, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub := &subscriber{
+		fields: make(map[string]bool, len(fields)),
+		ch:     make(chan StateChange, subscriberBuffer),
+	}
+	for _, field := range fields {
+		sub.fields[field] = true
+	}
+
+	id := s.nextSubID
+	s.nextSubID++
+	s.subscribers[id] = sub
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.subscribers, id)
+			s.mu.Unlock()
+			close(sub.ch)
+		})
+	}
+	return sub.ch, cancel
 }
 
-// GetState retrieves a value from the session state
-func (s *SessionState) GetState(key string) (interface{}, bool) {
-	value, exists := s.Data[key]
-	return value, exists
+// apply runs fn against a copy of the state and commits it. Caller must hold s.mu
+func (s *SessionState) apply(fn func(state *InterviewState)) StateChange {
+	next := s.state.Clone()
+	fn(&next)
+
+	// Version and timestamp are owned by the container
+	next.Version = s.state.Version
+	next.UpdatedAt = s.state.UpdatedAt
+
+	fields := changedFields(&s.state, &next)
+	if len(fields) == 0 {
+		return StateChange{SessionID: s.ID, Version: s.state.Version, State: s.state.Clone()}
+	}
+
+	next.Version++
+	next.UpdatedAt = time.Now()
+	s.state = next
+
+	change := StateChange{
+		SessionID: s.ID,
+		Version:   next.Version,
+		Fields:    fields,
+		State:     next.Clone(),
+	}
+	s.notify(change)
+	return change
+}
+
+// notify delivers a change to interested subscribers without blocking. Caller must hold s.mu
+func (s *SessionState) notify(change StateChange) {
+	for _, sub := range s.subscribers {
+		if !sub.wants(change.Fields) {
+			continue
+		}
+		select {
+		case sub.ch <- change:
+		default:
+		}
+	}
+}
+
+// wants reports whether the subscriber is interested in any of the fields
+func (sub *subscriber) wants(fields []string) bool {
+	if len(sub.fields) == 0 {
+		return true
+	}
+	for _, field := range fields {
+		if sub.fields[field] {
+			return true
+		}
+	}
+	return false
+}
+
+// changedFields returns the JSON names of the fields that differ between two states
+func changedFields(before, after *InterviewState) []string {
+	b := reflect.ValueOf(before).Elem()
+	a := reflect.ValueOf(after).Elem()
+	t := b.Type()
+
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		if !reflect.DeepEqual(b.Field(i).Interface(), a.Field(i).Interface()) {
+			fields = append(fields, jsonFieldName(t.Field(i)))
+		}
+	}
+	return fields
+}
+
+// jsonFieldName returns the JSON name of a struct field
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
 }
@@ -2,7 +2,7 @@ package sessionstate
 
 import "sync"
 
-// Store manages session states
+// Store is the registry of live session states shared by the orchestrator and HTTP readers
 type Store struct {
 	sessions map[string]*SessionState
 	mu       sync.RWMutex
@@ -17,10 +17,15 @@ func NewStore() *Store {
 
 // CreateSession creates a new session
 func (s *Store) CreateSession(id string) *SessionState {
+	return s.RestoreSession(id, DefaultInterviewState())
+}
+
+// RestoreSession registers a session from a previously saved snapshot
+func (s *Store) RestoreSession(id string, state InterviewState) *SessionState {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	session := NewSessionState(id)
+	session := RestoreSessionState(id, state)
 	s.sessions[id] = session
 	return session
 }
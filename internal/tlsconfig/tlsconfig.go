@@ -0,0 +1,265 @@
+// Package tlsconfig builds the service's TLS configuration. Certificates come
+// from a cert/key file pair or a directory holding tls.crt and tls.key (as
+// mounted from a Kubernetes secret) and are reloaded when the files change.
+// A reload only affects new handshakes, so live connections are not dropped
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/torteous44/callservice/internal/logging"
+)
+
+// Files read from a certificate directory
+const (
+	DirCertFile = "tls.crt"
+	DirKeyFile  = "tls.key"
+)
+
+// DefaultReloadInterval is how often certificate files are checked for changes
+const DefaultReloadInterval = 30 * time.Second
+
+// Options configures TLS
+type Options struct {
+	CertFile string // PEM certificate chain, with KeyFile
+	KeyFile  string
+	CertDir  string // Directory holding tls.crt and tls.key, instead of the files
+
+	MinVersion   string   // "1.2" (default) or "1.3"
+	CipherSuites []string // TLS 1.2 cipher suite names; empty uses Go's defaults
+
+	// ClientCAFile enables mutual TLS: client certificates signed by these CAs
+	// are verified. Clients without one can still connect, see RequireClientCert
+	ClientCAFile string
+
+	ReloadInterval time.Duration // Zero uses DefaultReloadInterval
+}
+
+// Enabled reports whether TLS is configured
+func (o Options) Enabled() bool {
+	return o.CertFile != "" || o.KeyFile != "" || o.CertDir != ""
+}
+
+// files returns the certificate and key paths
+func (o Options) files() (string, string) {
+	if o.CertDir != "" {
+		return filepath.Join(o.CertDir, DirCertFile), filepath.Join(o.CertDir, DirKeyFile)
+	}
+	return o.CertFile, o.KeyFile
+}
+
+// Validate checks the options without loading the files
+func (o Options) Validate() error {
+	if !o.Enabled() {
+		if o.ClientCAFile != "" {
+			return errors.New("client CA requires TLS to be enabled")
+		}
+		return nil
+	}
+	if o.CertDir != "" && (o.CertFile != "" || o.KeyFile != "") {
+		return errors.New("set either a certificate directory or cert and key files, not both")
+	}
+	if o.CertDir == "" && (o.CertFile == "" || o.KeyFile == "") {
+		return errors.New("both cert and key files are required")
+	}
+	if _, err := ParseVersion(o.MinVersion); err != nil {
+		return err
+	}
+	if _, err := ParseCipherSuites(o.CipherSuites); err != nil {
+		return err
+	}
+	if o.ReloadInterval < 0 {
+		return errors.New("reload interval must not be negative")
+	}
+	return nil
+}
+
+// ParseVersion parses a minimum TLS version. Empty means TLS 1.2
+func ParseVersion(version string) (uint16, error) {
+	switch version {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported minimum TLS version %q, use 1.2 or 1.3", version)
+	}
+}
+
+// ParseCipherSuites parses cipher suite names such as
+// TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Insecure suites are rejected. TLS 1.3
+// suites are not configurable and always enabled
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Reloader serves the current certificate and reloads it when its files
+// change. A certificate that fails to load is logged and the previous one is
+// kept
+type Reloader struct {
+	opts     Options
+	cert     atomic.Pointer[tls.Certificate]
+	clientCA *x509.CertPool
+	modTimes [2]time.Time
+}
+
+// NewReloader loads the certificate and client CAs
+func NewReloader(opts Options) (*Reloader, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.ReloadInterval == 0 {
+		opts.ReloadInterval = DefaultReloadInterval
+	}
+
+	r := &Reloader{opts: opts}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	if opts.ClientCAFile != "" {
+		pem, err := os.ReadFile(opts.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		r.clientCA = x509.NewCertPool()
+		if !r.clientCA.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in client CA %s", opts.ClientCAFile)
+		}
+	}
+	return r, nil
+}
+
+// reload loads the certificate files
+func (r *Reloader) reload() error {
+	certFile, keyFile := r.opts.files()
+	modTimes, err := fileModTimes(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+	if cert.Leaf != nil {
+		slog.Info("Loaded TLS certificate", "names", strings.Join(cert.Leaf.DNSNames, ", "),
+			"expires", cert.Leaf.NotAfter.Format(time.RFC3339))
+	}
+
+	r.cert.Store(&cert)
+	r.modTimes = modTimes
+	return nil
+}
+
+// Run reloads the certificate whenever its files change, until ctx is done
+func (r *Reloader) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.ReloadInterval)
+	defer ticker.Stop()
+
+	certFile, keyFile := r.opts.files()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		modTimes, err := fileModTimes(certFile, keyFile)
+		if err != nil {
+			slog.Warn("Cannot check TLS certificate for changes", logging.Err(err))
+			continue
+		}
+		if modTimes == r.modTimes {
+			continue
+		}
+		// Files may be caught mid-rotation; a failed load is retried on the
+		// next change
+		if err := r.reload(); err != nil {
+			slog.Error("Failed to reload TLS certificate, keeping the current one", logging.Err(err))
+			r.modTimes = modTimes
+		}
+	}
+}
+
+// ServerConfig returns a TLS config serving the current certificate. With a
+// client CA, client certificates are verified if given, or always when
+// requireClientCert is set
+func (r *Reloader) ServerConfig(requireClientCert bool) *tls.Config {
+	minVersion, _ := ParseVersion(r.opts.MinVersion)
+	cipherSuites, _ := ParseCipherSuites(r.opts.CipherSuites)
+
+	config := &tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: cipherSuites,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return r.cert.Load(), nil
+		},
+	}
+	if r.clientCA != nil {
+		config.ClientCAs = r.clientCA
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		if requireClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return config
+}
+
+// MutualTLS reports whether client certificates are verified
+func (r *Reloader) MutualTLS() bool {
+	return r.clientCA != nil
+}
+
+// RequireClientCert only lets requests with a verified client certificate
+// through to next
+func RequireClientCert(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			http.Error(w, "Client certificate required", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// fileModTimes returns the modification times of the certificate and key
+func fileModTimes(certFile, keyFile string) ([2]time.Time, error) {
+	var modTimes [2]time.Time
+	for i, file := range []string{certFile, keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return modTimes, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
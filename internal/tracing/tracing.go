@@ -0,0 +1,73 @@
+// Package tracing exports OpenTelemetry spans of the interview pipeline over
+// OTLP. Each session has a root span, and each candidate turn a child span
+// covering the time from the end of speech to the interviewer's answer
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans of this service
+const tracerName = "github.com/torteous44/callservice"
+
+// Span attribute keys shared by all components
+const (
+	AttrSession    = attribute.Key("session.id")
+	AttrLesson     = attribute.Key("lesson.id")
+	AttrAssemblyAI = attribute.Key("assemblyai.id")
+)
+
+// Options configures span export
+type Options struct {
+	// Endpoint is the OTLP/gRPC collector URL, e.g. http://collector:4317.
+	// Empty disables tracing
+	Endpoint string
+
+	ServiceName string  // service.name of the spans (defaults to callservice)
+	SampleRatio float64 // Share of sessions traced, from 0 to 1
+}
+
+// Enabled reports whether spans are exported
+func (o Options) Enabled() bool {
+	return o.Endpoint != ""
+}
+
+// Setup exports spans to the configured endpoint through the global tracer
+// provider. The returned function flushes pending spans and stops the export
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(opts.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	provider := NewProvider(sdktrace.NewBatchSpanProcessor(exporter), opts)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// NewProvider creates a tracer provider sending spans to processor, such as
+// the in-memory exporter the orchestrator tests check turn spans with. Turns
+// follow the sampling decision of their session
+func NewProvider(processor sdktrace.SpanProcessor, opts Options) *sdktrace.TracerProvider {
+	if opts.ServiceName == "" {
+		opts.ServiceName = "callservice"
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", opts.ServiceName))),
+	)
+}
+
+// Tracer returns the tracer of the service, from the global tracer provider.
+// Without Setup its spans are not recorded
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
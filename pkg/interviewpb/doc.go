@@ -0,0 +1,5 @@
+// Package interviewpb is the generated Go client and server code for the
+// InterviewService gRPC API defined in proto/interview/v1/interview.proto.
+package interviewpb
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=github.com/torteous44/callservice --go-grpc_out=../.. --go-grpc_opt=module=github.com/torteous44/callservice interview/v1/interview.proto
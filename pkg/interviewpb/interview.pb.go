@@ -0,0 +1,1685 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: interview/v1/interview.proto
+
+package interviewpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// AudioFormat describes raw audio.
+type AudioFormat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SampleRate    int32                  `protobuf:"varint,1,opt,name=sample_rate,json=sampleRate,proto3" json:"sample_rate,omitempty"`
+	Encoding      string                 `protobuf:"bytes,2,opt,name=encoding,proto3" json:"encoding,omitempty"` // "pcm_s16le" or "pcm_mulaw"
+	Channels      int32                  `protobuf:"varint,3,opt,name=channels,proto3" json:"channels,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AudioFormat) Reset() {
+	*x = AudioFormat{}
+	mi := &file_interview_v1_interview_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AudioFormat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AudioFormat) ProtoMessage() {}
+
+func (x *AudioFormat) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AudioFormat.ProtoReflect.Descriptor instead.
+func (*AudioFormat) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AudioFormat) GetSampleRate() int32 {
+	if x != nil {
+		return x.SampleRate
+	}
+	return 0
+}
+
+func (x *AudioFormat) GetEncoding() string {
+	if x != nil {
+		return x.Encoding
+	}
+	return ""
+}
+
+func (x *AudioFormat) GetChannels() int32 {
+	if x != nil {
+		return x.Channels
+	}
+	return 0
+}
+
+type CreateSessionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Audio the candidate will send. Defaults to 16kHz pcm_s16le.
+	Format *AudioFormat `protobuf:"bytes,1,opt,name=format,proto3" json:"format,omitempty"`
+	// Lesson data in the JSON format accepted by
+	// POST /api/interview/init-with-lesson. Optional.
+	LessonJson    []byte `protobuf:"bytes,2,opt,name=lesson_json,json=lessonJson,proto3" json:"lesson_json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSessionRequest) Reset() {
+	*x = CreateSessionRequest{}
+	mi := &file_interview_v1_interview_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSessionRequest) ProtoMessage() {}
+
+func (x *CreateSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSessionRequest.ProtoReflect.Descriptor instead.
+func (*CreateSessionRequest) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateSessionRequest) GetFormat() *AudioFormat {
+	if x != nil {
+		return x.Format
+	}
+	return nil
+}
+
+func (x *CreateSessionRequest) GetLessonJson() []byte {
+	if x != nil {
+		return x.LessonJson
+	}
+	return nil
+}
+
+type CreateSessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	ResumeToken   string                 `protobuf:"bytes,3,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"` // Required to rejoin the session once disconnected
+	Format        *AudioFormat           `protobuf:"bytes,4,opt,name=format,proto3" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSessionResponse) Reset() {
+	*x = CreateSessionResponse{}
+	mi := &file_interview_v1_interview_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSessionResponse) ProtoMessage() {}
+
+func (x *CreateSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSessionResponse.ProtoReflect.Descriptor instead.
+func (*CreateSessionResponse) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateSessionResponse) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *CreateSessionResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *CreateSessionResponse) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+func (x *CreateSessionResponse) GetFormat() *AudioFormat {
+	if x != nil {
+		return x.Format
+	}
+	return nil
+}
+
+type GetStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusRequest) Reset() {
+	*x = GetStatusRequest{}
+	mi := &file_interview_v1_interview_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusRequest) ProtoMessage() {}
+
+func (x *GetStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetStatusRequest) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetStatusRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type SessionStatus struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	SessionId          string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Status             string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	StartTime          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	TranscriptCount    int32                  `protobuf:"varint,4,opt,name=transcript_count,json=transcriptCount,proto3" json:"transcript_count,omitempty"`
+	UtteranceCount     int32                  `protobuf:"varint,5,opt,name=utterance_count,json=utteranceCount,proto3" json:"utterance_count,omitempty"`
+	PlaybackPositionMs int64                  `protobuf:"varint,6,opt,name=playback_position_ms,json=playbackPositionMs,proto3" json:"playback_position_ms,omitempty"`
+	Observers          int32                  `protobuf:"varint,7,opt,name=observers,proto3" json:"observers,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *SessionStatus) Reset() {
+	*x = SessionStatus{}
+	mi := &file_interview_v1_interview_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionStatus) ProtoMessage() {}
+
+func (x *SessionStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionStatus.ProtoReflect.Descriptor instead.
+func (*SessionStatus) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SessionStatus) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *SessionStatus) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *SessionStatus) GetStartTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartTime
+	}
+	return nil
+}
+
+func (x *SessionStatus) GetTranscriptCount() int32 {
+	if x != nil {
+		return x.TranscriptCount
+	}
+	return 0
+}
+
+func (x *SessionStatus) GetUtteranceCount() int32 {
+	if x != nil {
+		return x.UtteranceCount
+	}
+	return 0
+}
+
+func (x *SessionStatus) GetPlaybackPositionMs() int64 {
+	if x != nil {
+		return x.PlaybackPositionMs
+	}
+	return 0
+}
+
+func (x *SessionStatus) GetObservers() int32 {
+	if x != nil {
+		return x.Observers
+	}
+	return 0
+}
+
+type CloseSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CloseSessionRequest) Reset() {
+	*x = CloseSessionRequest{}
+	mi := &file_interview_v1_interview_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CloseSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseSessionRequest) ProtoMessage() {}
+
+func (x *CloseSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloseSessionRequest.ProtoReflect.Descriptor instead.
+func (*CloseSessionRequest) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CloseSessionRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type CloseSessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CloseSessionResponse) Reset() {
+	*x = CloseSessionResponse{}
+	mi := &file_interview_v1_interview_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CloseSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseSessionResponse) ProtoMessage() {}
+
+func (x *CloseSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloseSessionResponse.ProtoReflect.Descriptor instead.
+func (*CloseSessionResponse) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CloseSessionResponse) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *CloseSessionResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type ConverseRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Message:
+	//
+	//	*ConverseRequest_Start
+	//	*ConverseRequest_Audio
+	//	*ConverseRequest_Mute
+	//	*ConverseRequest_Stop
+	//	*ConverseRequest_Config
+	//	*ConverseRequest_Heartbeat
+	Message       isConverseRequest_Message `protobuf_oneof:"message"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConverseRequest) Reset() {
+	*x = ConverseRequest{}
+	mi := &file_interview_v1_interview_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConverseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConverseRequest) ProtoMessage() {}
+
+func (x *ConverseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConverseRequest.ProtoReflect.Descriptor instead.
+func (*ConverseRequest) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ConverseRequest) GetMessage() isConverseRequest_Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *ConverseRequest) GetStart() *ConverseStart {
+	if x != nil {
+		if x, ok := x.Message.(*ConverseRequest_Start); ok {
+			return x.Start
+		}
+	}
+	return nil
+}
+
+func (x *ConverseRequest) GetAudio() []byte {
+	if x != nil {
+		if x, ok := x.Message.(*ConverseRequest_Audio); ok {
+			return x.Audio
+		}
+	}
+	return nil
+}
+
+func (x *ConverseRequest) GetMute() *Mute {
+	if x != nil {
+		if x, ok := x.Message.(*ConverseRequest_Mute); ok {
+			return x.Mute
+		}
+	}
+	return nil
+}
+
+func (x *ConverseRequest) GetStop() *Stop {
+	if x != nil {
+		if x, ok := x.Message.(*ConverseRequest_Stop); ok {
+			return x.Stop
+		}
+	}
+	return nil
+}
+
+func (x *ConverseRequest) GetConfig() *ConfigUpdate {
+	if x != nil {
+		if x, ok := x.Message.(*ConverseRequest_Config); ok {
+			return x.Config
+		}
+	}
+	return nil
+}
+
+func (x *ConverseRequest) GetHeartbeat() *Heartbeat {
+	if x != nil {
+		if x, ok := x.Message.(*ConverseRequest_Heartbeat); ok {
+			return x.Heartbeat
+		}
+	}
+	return nil
+}
+
+type isConverseRequest_Message interface {
+	isConverseRequest_Message()
+}
+
+type ConverseRequest_Start struct {
+	Start *ConverseStart `protobuf:"bytes,1,opt,name=start,proto3,oneof"`
+}
+
+type ConverseRequest_Audio struct {
+	// Raw audio in the session format, between 10ms and 1s per chunk.
+	Audio []byte `protobuf:"bytes,2,opt,name=audio,proto3,oneof"`
+}
+
+type ConverseRequest_Mute struct {
+	Mute *Mute `protobuf:"bytes,3,opt,name=mute,proto3,oneof"`
+}
+
+type ConverseRequest_Stop struct {
+	Stop *Stop `protobuf:"bytes,4,opt,name=stop,proto3,oneof"`
+}
+
+type ConverseRequest_Config struct {
+	Config *ConfigUpdate `protobuf:"bytes,5,opt,name=config,proto3,oneof"`
+}
+
+type ConverseRequest_Heartbeat struct {
+	Heartbeat *Heartbeat `protobuf:"bytes,6,opt,name=heartbeat,proto3,oneof"`
+}
+
+func (*ConverseRequest_Start) isConverseRequest_Message() {}
+
+func (*ConverseRequest_Audio) isConverseRequest_Message() {}
+
+func (*ConverseRequest_Mute) isConverseRequest_Message() {}
+
+func (*ConverseRequest_Stop) isConverseRequest_Message() {}
+
+func (*ConverseRequest_Config) isConverseRequest_Message() {}
+
+func (*ConverseRequest_Heartbeat) isConverseRequest_Message() {}
+
+// ConverseStart joins a session. Rejoining a disconnected session requires
+// its resume token; messages after last_seq are replayed.
+type ConverseStart struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	ResumeToken   string                 `protobuf:"bytes,2,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	LastSeq       int64                  `protobuf:"varint,3,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConverseStart) Reset() {
+	*x = ConverseStart{}
+	mi := &file_interview_v1_interview_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConverseStart) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConverseStart) ProtoMessage() {}
+
+func (x *ConverseStart) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConverseStart.ProtoReflect.Descriptor instead.
+func (*ConverseStart) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ConverseStart) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ConverseStart) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+func (x *ConverseStart) GetLastSeq() int64 {
+	if x != nil {
+		return x.LastSeq
+	}
+	return 0
+}
+
+type Mute struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Muted         bool                   `protobuf:"varint,1,opt,name=muted,proto3" json:"muted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Mute) Reset() {
+	*x = Mute{}
+	mi := &file_interview_v1_interview_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Mute) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Mute) ProtoMessage() {}
+
+func (x *Mute) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Mute.ProtoReflect.Descriptor instead.
+func (*Mute) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Mute) GetMuted() bool {
+	if x != nil {
+		return x.Muted
+	}
+	return false
+}
+
+// Stop flushes the recognizer and ends the audio stream.
+type Stop struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Stop) Reset() {
+	*x = Stop{}
+	mi := &file_interview_v1_interview_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Stop) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Stop) ProtoMessage() {}
+
+func (x *Stop) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Stop.ProtoReflect.Descriptor instead.
+func (*Stop) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{10}
+}
+
+// ConfigUpdate changes recognition settings. Unset fields are left unchanged.
+type ConfigUpdate struct {
+	state                            protoimpl.MessageState `protogen:"open.v1"`
+	EndOfTurnConfidenceThreshold     *float64               `protobuf:"fixed64,1,opt,name=end_of_turn_confidence_threshold,json=endOfTurnConfidenceThreshold,proto3,oneof" json:"end_of_turn_confidence_threshold,omitempty"`
+	MinEndOfTurnSilenceWhenConfident *int32                 `protobuf:"varint,2,opt,name=min_end_of_turn_silence_when_confident,json=minEndOfTurnSilenceWhenConfident,proto3,oneof" json:"min_end_of_turn_silence_when_confident,omitempty"`
+	MaxTurnSilence                   *int32                 `protobuf:"varint,3,opt,name=max_turn_silence,json=maxTurnSilence,proto3,oneof" json:"max_turn_silence,omitempty"`
+	unknownFields                    protoimpl.UnknownFields
+	sizeCache                        protoimpl.SizeCache
+}
+
+func (x *ConfigUpdate) Reset() {
+	*x = ConfigUpdate{}
+	mi := &file_interview_v1_interview_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigUpdate) ProtoMessage() {}
+
+func (x *ConfigUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigUpdate.ProtoReflect.Descriptor instead.
+func (*ConfigUpdate) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ConfigUpdate) GetEndOfTurnConfidenceThreshold() float64 {
+	if x != nil && x.EndOfTurnConfidenceThreshold != nil {
+		return *x.EndOfTurnConfidenceThreshold
+	}
+	return 0
+}
+
+func (x *ConfigUpdate) GetMinEndOfTurnSilenceWhenConfident() int32 {
+	if x != nil && x.MinEndOfTurnSilenceWhenConfident != nil {
+		return *x.MinEndOfTurnSilenceWhenConfident
+	}
+	return 0
+}
+
+func (x *ConfigUpdate) GetMaxTurnSilence() int32 {
+	if x != nil && x.MaxTurnSilence != nil {
+		return *x.MaxTurnSilence
+	}
+	return 0
+}
+
+type Heartbeat struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	PlaybackPositionMs int64                  `protobuf:"varint,1,opt,name=playback_position_ms,json=playbackPositionMs,proto3" json:"playback_position_ms,omitempty"`
+	Playing            bool                   `protobuf:"varint,2,opt,name=playing,proto3" json:"playing,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Heartbeat) Reset() {
+	*x = Heartbeat{}
+	mi := &file_interview_v1_interview_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Heartbeat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Heartbeat) ProtoMessage() {}
+
+func (x *Heartbeat) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Heartbeat.ProtoReflect.Descriptor instead.
+func (*Heartbeat) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Heartbeat) GetPlaybackPositionMs() int64 {
+	if x != nil {
+		return x.PlaybackPositionMs
+	}
+	return 0
+}
+
+func (x *Heartbeat) GetPlaying() bool {
+	if x != nil {
+		return x.Playing
+	}
+	return false
+}
+
+type ConverseResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Position in the session outbox, set on replayable messages.
+	Seq       int64                  `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Types that are valid to be assigned to Message:
+	//
+	//	*ConverseResponse_Ready
+	//	*ConverseResponse_Transcript
+	//	*ConverseResponse_Status
+	//	*ConverseResponse_Grade
+	//	*ConverseResponse_Hint
+	//	*ConverseResponse_InterviewerAudio
+	//	*ConverseResponse_Error
+	Message       isConverseResponse_Message `protobuf_oneof:"message"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConverseResponse) Reset() {
+	*x = ConverseResponse{}
+	mi := &file_interview_v1_interview_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConverseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConverseResponse) ProtoMessage() {}
+
+func (x *ConverseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConverseResponse.ProtoReflect.Descriptor instead.
+func (*ConverseResponse) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ConverseResponse) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *ConverseResponse) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *ConverseResponse) GetMessage() isConverseResponse_Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *ConverseResponse) GetReady() *Ready {
+	if x != nil {
+		if x, ok := x.Message.(*ConverseResponse_Ready); ok {
+			return x.Ready
+		}
+	}
+	return nil
+}
+
+func (x *ConverseResponse) GetTranscript() *Transcript {
+	if x != nil {
+		if x, ok := x.Message.(*ConverseResponse_Transcript); ok {
+			return x.Transcript
+		}
+	}
+	return nil
+}
+
+func (x *ConverseResponse) GetStatus() *Status {
+	if x != nil {
+		if x, ok := x.Message.(*ConverseResponse_Status); ok {
+			return x.Status
+		}
+	}
+	return nil
+}
+
+func (x *ConverseResponse) GetGrade() *Grade {
+	if x != nil {
+		if x, ok := x.Message.(*ConverseResponse_Grade); ok {
+			return x.Grade
+		}
+	}
+	return nil
+}
+
+func (x *ConverseResponse) GetHint() *Hint {
+	if x != nil {
+		if x, ok := x.Message.(*ConverseResponse_Hint); ok {
+			return x.Hint
+		}
+	}
+	return nil
+}
+
+func (x *ConverseResponse) GetInterviewerAudio() *InterviewerAudio {
+	if x != nil {
+		if x, ok := x.Message.(*ConverseResponse_InterviewerAudio); ok {
+			return x.InterviewerAudio
+		}
+	}
+	return nil
+}
+
+func (x *ConverseResponse) GetError() *Error {
+	if x != nil {
+		if x, ok := x.Message.(*ConverseResponse_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isConverseResponse_Message interface {
+	isConverseResponse_Message()
+}
+
+type ConverseResponse_Ready struct {
+	Ready *Ready `protobuf:"bytes,3,opt,name=ready,proto3,oneof"`
+}
+
+type ConverseResponse_Transcript struct {
+	Transcript *Transcript `protobuf:"bytes,4,opt,name=transcript,proto3,oneof"`
+}
+
+type ConverseResponse_Status struct {
+	Status *Status `protobuf:"bytes,5,opt,name=status,proto3,oneof"`
+}
+
+type ConverseResponse_Grade struct {
+	Grade *Grade `protobuf:"bytes,6,opt,name=grade,proto3,oneof"`
+}
+
+type ConverseResponse_Hint struct {
+	Hint *Hint `protobuf:"bytes,7,opt,name=hint,proto3,oneof"`
+}
+
+type ConverseResponse_InterviewerAudio struct {
+	InterviewerAudio *InterviewerAudio `protobuf:"bytes,8,opt,name=interviewer_audio,json=interviewerAudio,proto3,oneof"`
+}
+
+type ConverseResponse_Error struct {
+	Error *Error `protobuf:"bytes,9,opt,name=error,proto3,oneof"`
+}
+
+func (*ConverseResponse_Ready) isConverseResponse_Message() {}
+
+func (*ConverseResponse_Transcript) isConverseResponse_Message() {}
+
+func (*ConverseResponse_Status) isConverseResponse_Message() {}
+
+func (*ConverseResponse_Grade) isConverseResponse_Message() {}
+
+func (*ConverseResponse_Hint) isConverseResponse_Message() {}
+
+func (*ConverseResponse_InterviewerAudio) isConverseResponse_Message() {}
+
+func (*ConverseResponse_Error) isConverseResponse_Message() {}
+
+type Ready struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Format        *AudioFormat           `protobuf:"bytes,2,opt,name=format,proto3" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ready) Reset() {
+	*x = Ready{}
+	mi := &file_interview_v1_interview_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ready) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ready) ProtoMessage() {}
+
+func (x *Ready) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ready.ProtoReflect.Descriptor instead.
+func (*Ready) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Ready) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *Ready) GetFormat() *AudioFormat {
+	if x != nil {
+		return x.Format
+	}
+	return nil
+}
+
+type Transcript struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	MessageType         string                 `protobuf:"bytes,1,opt,name=message_type,json=messageType,proto3" json:"message_type,omitempty"` // "PartialTranscript", "FinalTranscript" or "Turn"
+	Text                string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Confidence          float64                `protobuf:"fixed64,3,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	IsFinal             bool                   `protobuf:"varint,4,opt,name=is_final,json=isFinal,proto3" json:"is_final,omitempty"`
+	AssemblyaiSessionId string                 `protobuf:"bytes,5,opt,name=assemblyai_session_id,json=assemblyaiSessionId,proto3" json:"assemblyai_session_id,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Transcript) Reset() {
+	*x = Transcript{}
+	mi := &file_interview_v1_interview_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transcript) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transcript) ProtoMessage() {}
+
+func (x *Transcript) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transcript.ProtoReflect.Descriptor instead.
+func (*Transcript) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Transcript) GetMessageType() string {
+	if x != nil {
+		return x.MessageType
+	}
+	return ""
+}
+
+func (x *Transcript) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Transcript) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *Transcript) GetIsFinal() bool {
+	if x != nil {
+		return x.IsFinal
+	}
+	return false
+}
+
+func (x *Transcript) GetAssemblyaiSessionId() string {
+	if x != nil {
+		return x.AssemblyaiSessionId
+	}
+	return ""
+}
+
+type Status struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Details       string                 `protobuf:"bytes,3,opt,name=details,proto3" json:"details,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Status) Reset() {
+	*x = Status{}
+	mi := &file_interview_v1_interview_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Status) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Status) ProtoMessage() {}
+
+func (x *Status) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Status.ProtoReflect.Descriptor instead.
+func (*Status) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Status) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *Status) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Status) GetDetails() string {
+	if x != nil {
+		return x.Details
+	}
+	return ""
+}
+
+type Grade struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	QuestionIndex int32                  `protobuf:"varint,1,opt,name=question_index,json=questionIndex,proto3" json:"question_index,omitempty"`
+	Scores        map[string]float64     `protobuf:"bytes,2,rep,name=scores,proto3" json:"scores,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	OverallScore  float64                `protobuf:"fixed64,3,opt,name=overall_score,json=overallScore,proto3" json:"overall_score,omitempty"`
+	Decision      string                 `protobuf:"bytes,4,opt,name=decision,proto3" json:"decision,omitempty"`
+	Feedback      []string               `protobuf:"bytes,5,rep,name=feedback,proto3" json:"feedback,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Grade) Reset() {
+	*x = Grade{}
+	mi := &file_interview_v1_interview_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Grade) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Grade) ProtoMessage() {}
+
+func (x *Grade) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Grade.ProtoReflect.Descriptor instead.
+func (*Grade) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Grade) GetQuestionIndex() int32 {
+	if x != nil {
+		return x.QuestionIndex
+	}
+	return 0
+}
+
+func (x *Grade) GetScores() map[string]float64 {
+	if x != nil {
+		return x.Scores
+	}
+	return nil
+}
+
+func (x *Grade) GetOverallScore() float64 {
+	if x != nil {
+		return x.OverallScore
+	}
+	return 0
+}
+
+func (x *Grade) GetDecision() string {
+	if x != nil {
+		return x.Decision
+	}
+	return ""
+}
+
+func (x *Grade) GetFeedback() []string {
+	if x != nil {
+		return x.Feedback
+	}
+	return nil
+}
+
+type Hint struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	QuestionIndex int32                  `protobuf:"varint,1,opt,name=question_index,json=questionIndex,proto3" json:"question_index,omitempty"`
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Source        string                 `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Hint) Reset() {
+	*x = Hint{}
+	mi := &file_interview_v1_interview_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hint) ProtoMessage() {}
+
+func (x *Hint) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hint.ProtoReflect.Descriptor instead.
+func (*Hint) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *Hint) GetQuestionIndex() int32 {
+	if x != nil {
+		return x.QuestionIndex
+	}
+	return 0
+}
+
+func (x *Hint) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Hint) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+type InterviewerAudio struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Format        string                 `protobuf:"bytes,2,opt,name=format,proto3" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InterviewerAudio) Reset() {
+	*x = InterviewerAudio{}
+	mi := &file_interview_v1_interview_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InterviewerAudio) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InterviewerAudio) ProtoMessage() {}
+
+func (x *InterviewerAudio) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InterviewerAudio.ProtoReflect.Descriptor instead.
+func (*InterviewerAudio) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *InterviewerAudio) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *InterviewerAudio) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+type Error struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_interview_v1_interview_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_interview_v1_interview_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_interview_v1_interview_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Error) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Error) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+var File_interview_v1_interview_proto protoreflect.FileDescriptor
+
+const file_interview_v1_interview_proto_rawDesc = "" +
+	"\n" +
+	"\x1cinterview/v1/interview.proto\x12\x18callservice.interview.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"f\n" +
+	"\vAudioFormat\x12\x1f\n" +
+	"\vsample_rate\x18\x01 \x01(\x05R\n" +
+	"sampleRate\x12\x1a\n" +
+	"\bencoding\x18\x02 \x01(\tR\bencoding\x12\x1a\n" +
+	"\bchannels\x18\x03 \x01(\x05R\bchannels\"v\n" +
+	"\x14CreateSessionRequest\x12=\n" +
+	"\x06format\x18\x01 \x01(\v2%.callservice.interview.v1.AudioFormatR\x06format\x12\x1f\n" +
+	"\vlesson_json\x18\x02 \x01(\fR\n" +
+	"lessonJson\"\xb0\x01\n" +
+	"\x15CreateSessionResponse\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12!\n" +
+	"\fresume_token\x18\x03 \x01(\tR\vresumeToken\x12=\n" +
+	"\x06format\x18\x04 \x01(\v2%.callservice.interview.v1.AudioFormatR\x06format\"1\n" +
+	"\x10GetStatusRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"\xa5\x02\n" +
+	"\rSessionStatus\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x129\n" +
+	"\n" +
+	"start_time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tstartTime\x12)\n" +
+	"\x10transcript_count\x18\x04 \x01(\x05R\x0ftranscriptCount\x12'\n" +
+	"\x0futterance_count\x18\x05 \x01(\x05R\x0eutteranceCount\x120\n" +
+	"\x14playback_position_ms\x18\x06 \x01(\x03R\x12playbackPositionMs\x12\x1c\n" +
+	"\tobservers\x18\a \x01(\x05R\tobservers\"4\n" +
+	"\x13CloseSessionRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"M\n" +
+	"\x14CloseSessionResponse\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\"\xe8\x02\n" +
+	"\x0fConverseRequest\x12?\n" +
+	"\x05start\x18\x01 \x01(\v2'.callservice.interview.v1.ConverseStartH\x00R\x05start\x12\x16\n" +
+	"\x05audio\x18\x02 \x01(\fH\x00R\x05audio\x124\n" +
+	"\x04mute\x18\x03 \x01(\v2\x1e.callservice.interview.v1.MuteH\x00R\x04mute\x124\n" +
+	"\x04stop\x18\x04 \x01(\v2\x1e.callservice.interview.v1.StopH\x00R\x04stop\x12@\n" +
+	"\x06config\x18\x05 \x01(\v2&.callservice.interview.v1.ConfigUpdateH\x00R\x06config\x12C\n" +
+	"\theartbeat\x18\x06 \x01(\v2#.callservice.interview.v1.HeartbeatH\x00R\theartbeatB\t\n" +
+	"\amessage\"l\n" +
+	"\rConverseStart\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12!\n" +
+	"\fresume_token\x18\x02 \x01(\tR\vresumeToken\x12\x19\n" +
+	"\blast_seq\x18\x03 \x01(\x03R\alastSeq\"\x1c\n" +
+	"\x04Mute\x12\x14\n" +
+	"\x05muted\x18\x01 \x01(\bR\x05muted\"\x06\n" +
+	"\x04Stop\"\xc6\x02\n" +
+	"\fConfigUpdate\x12K\n" +
+	" end_of_turn_confidence_threshold\x18\x01 \x01(\x01H\x00R\x1cendOfTurnConfidenceThreshold\x88\x01\x01\x12U\n" +
+	"&min_end_of_turn_silence_when_confident\x18\x02 \x01(\x05H\x01R minEndOfTurnSilenceWhenConfident\x88\x01\x01\x12-\n" +
+	"\x10max_turn_silence\x18\x03 \x01(\x05H\x02R\x0emaxTurnSilence\x88\x01\x01B#\n" +
+	"!_end_of_turn_confidence_thresholdB)\n" +
+	"'_min_end_of_turn_silence_when_confidentB\x13\n" +
+	"\x11_max_turn_silence\"W\n" +
+	"\tHeartbeat\x120\n" +
+	"\x14playback_position_ms\x18\x01 \x01(\x03R\x12playbackPositionMs\x12\x18\n" +
+	"\aplaying\x18\x02 \x01(\bR\aplaying\"\xa9\x04\n" +
+	"\x10ConverseResponse\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x03R\x03seq\x128\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x127\n" +
+	"\x05ready\x18\x03 \x01(\v2\x1f.callservice.interview.v1.ReadyH\x00R\x05ready\x12F\n" +
+	"\n" +
+	"transcript\x18\x04 \x01(\v2$.callservice.interview.v1.TranscriptH\x00R\n" +
+	"transcript\x12:\n" +
+	"\x06status\x18\x05 \x01(\v2 .callservice.interview.v1.StatusH\x00R\x06status\x127\n" +
+	"\x05grade\x18\x06 \x01(\v2\x1f.callservice.interview.v1.GradeH\x00R\x05grade\x124\n" +
+	"\x04hint\x18\a \x01(\v2\x1e.callservice.interview.v1.HintH\x00R\x04hint\x12Y\n" +
+	"\x11interviewer_audio\x18\b \x01(\v2*.callservice.interview.v1.InterviewerAudioH\x00R\x10interviewerAudio\x127\n" +
+	"\x05error\x18\t \x01(\v2\x1f.callservice.interview.v1.ErrorH\x00R\x05errorB\t\n" +
+	"\amessage\"e\n" +
+	"\x05Ready\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12=\n" +
+	"\x06format\x18\x02 \x01(\v2%.callservice.interview.v1.AudioFormatR\x06format\"\xb2\x01\n" +
+	"\n" +
+	"Transcript\x12!\n" +
+	"\fmessage_type\x18\x01 \x01(\tR\vmessageType\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x03 \x01(\x01R\n" +
+	"confidence\x12\x19\n" +
+	"\bis_final\x18\x04 \x01(\bR\aisFinal\x122\n" +
+	"\x15assemblyai_session_id\x18\x05 \x01(\tR\x13assemblyaiSessionId\"Y\n" +
+	"\x06Status\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x18\n" +
+	"\adetails\x18\x03 \x01(\tR\adetails\"\x8b\x02\n" +
+	"\x05Grade\x12%\n" +
+	"\x0equestion_index\x18\x01 \x01(\x05R\rquestionIndex\x12C\n" +
+	"\x06scores\x18\x02 \x03(\v2+.callservice.interview.v1.Grade.ScoresEntryR\x06scores\x12#\n" +
+	"\roverall_score\x18\x03 \x01(\x01R\foverallScore\x12\x1a\n" +
+	"\bdecision\x18\x04 \x01(\tR\bdecision\x12\x1a\n" +
+	"\bfeedback\x18\x05 \x03(\tR\bfeedback\x1a9\n" +
+	"\vScoresEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\"Y\n" +
+	"\x04Hint\x12%\n" +
+	"\x0equestion_index\x18\x01 \x01(\x05R\rquestionIndex\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\">\n" +
+	"\x10InterviewerAudio\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12\x16\n" +
+	"\x06format\x18\x02 \x01(\tR\x06format\"5\n" +
+	"\x05Error\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage2\xbc\x03\n" +
+	"\x10InterviewService\x12p\n" +
+	"\rCreateSession\x12..callservice.interview.v1.CreateSessionRequest\x1a/.callservice.interview.v1.CreateSessionResponse\x12`\n" +
+	"\tGetStatus\x12*.callservice.interview.v1.GetStatusRequest\x1a'.callservice.interview.v1.SessionStatus\x12m\n" +
+	"\fCloseSession\x12-.callservice.interview.v1.CloseSessionRequest\x1a..callservice.interview.v1.CloseSessionResponse\x12e\n" +
+	"\bConverse\x12).callservice.interview.v1.ConverseRequest\x1a*.callservice.interview.v1.ConverseResponse(\x010\x01B3Z1github.com/torteous44/callservice/pkg/interviewpbb\x06proto3"
+
+var (
+	file_interview_v1_interview_proto_rawDescOnce sync.Once
+	file_interview_v1_interview_proto_rawDescData []byte
+)
+
+func file_interview_v1_interview_proto_rawDescGZIP() []byte {
+	file_interview_v1_interview_proto_rawDescOnce.Do(func() {
+		file_interview_v1_interview_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_interview_v1_interview_proto_rawDesc), len(file_interview_v1_interview_proto_rawDesc)))
+	})
+	return file_interview_v1_interview_proto_rawDescData
+}
+
+var file_interview_v1_interview_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
+var file_interview_v1_interview_proto_goTypes = []any{
+	(*AudioFormat)(nil),           // 0: callservice.interview.v1.AudioFormat
+	(*CreateSessionRequest)(nil),  // 1: callservice.interview.v1.CreateSessionRequest
+	(*CreateSessionResponse)(nil), // 2: callservice.interview.v1.CreateSessionResponse
+	(*GetStatusRequest)(nil),      // 3: callservice.interview.v1.GetStatusRequest
+	(*SessionStatus)(nil),         // 4: callservice.interview.v1.SessionStatus
+	(*CloseSessionRequest)(nil),   // 5: callservice.interview.v1.CloseSessionRequest
+	(*CloseSessionResponse)(nil),  // 6: callservice.interview.v1.CloseSessionResponse
+	(*ConverseRequest)(nil),       // 7: callservice.interview.v1.ConverseRequest
+	(*ConverseStart)(nil),         // 8: callservice.interview.v1.ConverseStart
+	(*Mute)(nil),                  // 9: callservice.interview.v1.Mute
+	(*Stop)(nil),                  // 10: callservice.interview.v1.Stop
+	(*ConfigUpdate)(nil),          // 11: callservice.interview.v1.ConfigUpdate
+	(*Heartbeat)(nil),             // 12: callservice.interview.v1.Heartbeat
+	(*ConverseResponse)(nil),      // 13: callservice.interview.v1.ConverseResponse
+	(*Ready)(nil),                 // 14: callservice.interview.v1.Ready
+	(*Transcript)(nil),            // 15: callservice.interview.v1.Transcript
+	(*Status)(nil),                // 16: callservice.interview.v1.Status
+	(*Grade)(nil),                 // 17: callservice.interview.v1.Grade
+	(*Hint)(nil),                  // 18: callservice.interview.v1.Hint
+	(*InterviewerAudio)(nil),      // 19: callservice.interview.v1.InterviewerAudio
+	(*Error)(nil),                 // 20: callservice.interview.v1.Error
+	nil,                           // 21: callservice.interview.v1.Grade.ScoresEntry
+	(*timestamppb.Timestamp)(nil), // 22: google.protobuf.Timestamp
+}
+var file_interview_v1_interview_proto_depIdxs = []int32{
+	0,  // 0: callservice.interview.v1.CreateSessionRequest.format:type_name -> callservice.interview.v1.AudioFormat
+	0,  // 1: callservice.interview.v1.CreateSessionResponse.format:type_name -> callservice.interview.v1.AudioFormat
+	22, // 2: callservice.interview.v1.SessionStatus.start_time:type_name -> google.protobuf.Timestamp
+	8,  // 3: callservice.interview.v1.ConverseRequest.start:type_name -> callservice.interview.v1.ConverseStart
+	9,  // 4: callservice.interview.v1.ConverseRequest.mute:type_name -> callservice.interview.v1.Mute
+	10, // 5: callservice.interview.v1.ConverseRequest.stop:type_name -> callservice.interview.v1.Stop
+	11, // 6: callservice.interview.v1.ConverseRequest.config:type_name -> callservice.interview.v1.ConfigUpdate
+	12, // 7: callservice.interview.v1.ConverseRequest.heartbeat:type_name -> callservice.interview.v1.Heartbeat
+	22, // 8: callservice.interview.v1.ConverseResponse.timestamp:type_name -> google.protobuf.Timestamp
+	14, // 9: callservice.interview.v1.ConverseResponse.ready:type_name -> callservice.interview.v1.Ready
+	15, // 10: callservice.interview.v1.ConverseResponse.transcript:type_name -> callservice.interview.v1.Transcript
+	16, // 11: callservice.interview.v1.ConverseResponse.status:type_name -> callservice.interview.v1.Status
+	17, // 12: callservice.interview.v1.ConverseResponse.grade:type_name -> callservice.interview.v1.Grade
+	18, // 13: callservice.interview.v1.ConverseResponse.hint:type_name -> callservice.interview.v1.Hint
+	19, // 14: callservice.interview.v1.ConverseResponse.interviewer_audio:type_name -> callservice.interview.v1.InterviewerAudio
+	20, // 15: callservice.interview.v1.ConverseResponse.error:type_name -> callservice.interview.v1.Error
+	0,  // 16: callservice.interview.v1.Ready.format:type_name -> callservice.interview.v1.AudioFormat
+	21, // 17: callservice.interview.v1.Grade.scores:type_name -> callservice.interview.v1.Grade.ScoresEntry
+	1,  // 18: callservice.interview.v1.InterviewService.CreateSession:input_type -> callservice.interview.v1.CreateSessionRequest
+	3,  // 19: callservice.interview.v1.InterviewService.GetStatus:input_type -> callservice.interview.v1.GetStatusRequest
+	5,  // 20: callservice.interview.v1.InterviewService.CloseSession:input_type -> callservice.interview.v1.CloseSessionRequest
+	7,  // 21: callservice.interview.v1.InterviewService.Converse:input_type -> callservice.interview.v1.ConverseRequest
+	2,  // 22: callservice.interview.v1.InterviewService.CreateSession:output_type -> callservice.interview.v1.CreateSessionResponse
+	4,  // 23: callservice.interview.v1.InterviewService.GetStatus:output_type -> callservice.interview.v1.SessionStatus
+	6,  // 24: callservice.interview.v1.InterviewService.CloseSession:output_type -> callservice.interview.v1.CloseSessionResponse
+	13, // 25: callservice.interview.v1.InterviewService.Converse:output_type -> callservice.interview.v1.ConverseResponse
+	22, // [22:26] is the sub-list for method output_type
+	18, // [18:22] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
+}
+
+func init() { file_interview_v1_interview_proto_init() }
+func file_interview_v1_interview_proto_init() {
+	if File_interview_v1_interview_proto != nil {
+		return
+	}
+	file_interview_v1_interview_proto_msgTypes[7].OneofWrappers = []any{
+		(*ConverseRequest_Start)(nil),
+		(*ConverseRequest_Audio)(nil),
+		(*ConverseRequest_Mute)(nil),
+		(*ConverseRequest_Stop)(nil),
+		(*ConverseRequest_Config)(nil),
+		(*ConverseRequest_Heartbeat)(nil),
+	}
+	file_interview_v1_interview_proto_msgTypes[11].OneofWrappers = []any{}
+	file_interview_v1_interview_proto_msgTypes[13].OneofWrappers = []any{
+		(*ConverseResponse_Ready)(nil),
+		(*ConverseResponse_Transcript)(nil),
+		(*ConverseResponse_Status)(nil),
+		(*ConverseResponse_Grade)(nil),
+		(*ConverseResponse_Hint)(nil),
+		(*ConverseResponse_InterviewerAudio)(nil),
+		(*ConverseResponse_Error)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_interview_v1_interview_proto_rawDesc), len(file_interview_v1_interview_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   22,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_interview_v1_interview_proto_goTypes,
+		DependencyIndexes: file_interview_v1_interview_proto_depIdxs,
+		MessageInfos:      file_interview_v1_interview_proto_msgTypes,
+	}.Build()
+	File_interview_v1_interview_proto = out.File
+	file_interview_v1_interview_proto_goTypes = nil
+	file_interview_v1_interview_proto_depIdxs = nil
+}
@@ -0,0 +1,250 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: interview/v1/interview.proto
+
+package interviewpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	InterviewService_CreateSession_FullMethodName = "/callservice.interview.v1.InterviewService/CreateSession"
+	InterviewService_GetStatus_FullMethodName     = "/callservice.interview.v1.InterviewService/GetStatus"
+	InterviewService_CloseSession_FullMethodName  = "/callservice.interview.v1.InterviewService/CloseSession"
+	InterviewService_Converse_FullMethodName      = "/callservice.interview.v1.InterviewService/Converse"
+)
+
+// InterviewServiceClient is the client API for InterviewService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// InterviewService runs interview sessions for backend callers. It is served
+// by the same orchestrator as the REST and WebSocket API, so a session
+// created here can also be joined over WebSocket and the other way round.
+type InterviewServiceClient interface {
+	// CreateSession creates a session, optionally with lesson data.
+	CreateSession(ctx context.Context, in *CreateSessionRequest, opts ...grpc.CallOption) (*CreateSessionResponse, error)
+	// GetStatus returns the status of a session.
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*SessionStatus, error)
+	// CloseSession terminates a session.
+	CloseSession(ctx context.Context, in *CloseSessionRequest, opts ...grpc.CallOption) (*CloseSessionResponse, error)
+	// Converse streams candidate audio and control in and transcripts,
+	// grades, hints and interviewer audio out. The first request must be a
+	// ConverseStart; ending the request stream ends the conversation.
+	Converse(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ConverseRequest, ConverseResponse], error)
+}
+
+type interviewServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewInterviewServiceClient(cc grpc.ClientConnInterface) InterviewServiceClient {
+	return &interviewServiceClient{cc}
+}
+
+func (c *interviewServiceClient) CreateSession(ctx context.Context, in *CreateSessionRequest, opts ...grpc.CallOption) (*CreateSessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateSessionResponse)
+	err := c.cc.Invoke(ctx, InterviewService_CreateSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *interviewServiceClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*SessionStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SessionStatus)
+	err := c.cc.Invoke(ctx, InterviewService_GetStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *interviewServiceClient) CloseSession(ctx context.Context, in *CloseSessionRequest, opts ...grpc.CallOption) (*CloseSessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CloseSessionResponse)
+	err := c.cc.Invoke(ctx, InterviewService_CloseSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *interviewServiceClient) Converse(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ConverseRequest, ConverseResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &InterviewService_ServiceDesc.Streams[0], InterviewService_Converse_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ConverseRequest, ConverseResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type InterviewService_ConverseClient = grpc.BidiStreamingClient[ConverseRequest, ConverseResponse]
+
+// InterviewServiceServer is the server API for InterviewService service.
+// All implementations must embed UnimplementedInterviewServiceServer
+// for forward compatibility.
+//
+// InterviewService runs interview sessions for backend callers. It is served
+// by the same orchestrator as the REST and WebSocket API, so a session
+// created here can also be joined over WebSocket and the other way round.
+type InterviewServiceServer interface {
+	// CreateSession creates a session, optionally with lesson data.
+	CreateSession(context.Context, *CreateSessionRequest) (*CreateSessionResponse, error)
+	// GetStatus returns the status of a session.
+	GetStatus(context.Context, *GetStatusRequest) (*SessionStatus, error)
+	// CloseSession terminates a session.
+	CloseSession(context.Context, *CloseSessionRequest) (*CloseSessionResponse, error)
+	// Converse streams candidate audio and control in and transcripts,
+	// grades, hints and interviewer audio out. The first request must be a
+	// ConverseStart; ending the request stream ends the conversation.
+	Converse(grpc.BidiStreamingServer[ConverseRequest, ConverseResponse]) error
+	mustEmbedUnimplementedInterviewServiceServer()
+}
+
+// UnimplementedInterviewServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedInterviewServiceServer struct{}
+
+func (UnimplementedInterviewServiceServer) CreateSession(context.Context, *CreateSessionRequest) (*CreateSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSession not implemented")
+}
+func (UnimplementedInterviewServiceServer) GetStatus(context.Context, *GetStatusRequest) (*SessionStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedInterviewServiceServer) CloseSession(context.Context, *CloseSessionRequest) (*CloseSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseSession not implemented")
+}
+func (UnimplementedInterviewServiceServer) Converse(grpc.BidiStreamingServer[ConverseRequest, ConverseResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Converse not implemented")
+}
+func (UnimplementedInterviewServiceServer) mustEmbedUnimplementedInterviewServiceServer() {}
+func (UnimplementedInterviewServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeInterviewServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to InterviewServiceServer will
+// result in compilation errors.
+type UnsafeInterviewServiceServer interface {
+	mustEmbedUnimplementedInterviewServiceServer()
+}
+
+func RegisterInterviewServiceServer(s grpc.ServiceRegistrar, srv InterviewServiceServer) {
+	// If the following call pancis, it indicates UnimplementedInterviewServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&InterviewService_ServiceDesc, srv)
+}
+
+func _InterviewService_CreateSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InterviewServiceServer).CreateSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InterviewService_CreateSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InterviewServiceServer).CreateSession(ctx, req.(*CreateSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InterviewService_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InterviewServiceServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InterviewService_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InterviewServiceServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InterviewService_CloseSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloseSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InterviewServiceServer).CloseSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InterviewService_CloseSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InterviewServiceServer).CloseSession(ctx, req.(*CloseSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InterviewService_Converse_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(InterviewServiceServer).Converse(&grpc.GenericServerStream[ConverseRequest, ConverseResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type InterviewService_ConverseServer = grpc.BidiStreamingServer[ConverseRequest, ConverseResponse]
+
+// InterviewService_ServiceDesc is the grpc.ServiceDesc for InterviewService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var InterviewService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "callservice.interview.v1.InterviewService",
+	HandlerType: (*InterviewServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateSession",
+			Handler:    _InterviewService_CreateSession_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _InterviewService_GetStatus_Handler,
+		},
+		{
+			MethodName: "CloseSession",
+			Handler:    _InterviewService_CloseSession_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Converse",
+			Handler:       _InterviewService_Converse_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "interview/v1/interview.proto",
+}
@@ -0,0 +1,118 @@
+package transport
+
+import (
+	"github.com/pion/rtp"
+)
+
+// defaultJitterDepth is how many packets may wait for a missing one, about
+// 60ms of 20ms Opus packets
+const defaultJitterDepth = 3
+
+// maxJitterWindow bounds how far ahead of the next expected packet one may
+// arrive before the buffer restarts from it
+const maxJitterWindow = 1000
+
+// JitterStats counts what happened to packets passing through a JitterBuffer
+type JitterStats struct {
+	Packets uint64 `json:"packets"`
+	Lost    uint64 `json:"lost"`   // Never arrived in time
+	Late    uint64 `json:"late"`   // Arrived after being given up on, or duplicated
+	Resets  uint64 `json:"resets"` // Sequence jumps that restarted the buffer
+}
+
+// JitterBuffer puts RTP packets back in sequence order. A missing packet is
+// waited for until depth later packets have arrived, then reported lost so
+// the decoder can conceal it
+type JitterBuffer struct {
+	depth   int
+	packets map[uint16]*rtp.Packet
+	next    uint16
+	started bool
+	stats   JitterStats
+}
+
+// NewJitterBuffer creates a jitter buffer holding up to depth packets
+func NewJitterBuffer(depth int) *JitterBuffer {
+	if depth <= 0 {
+		depth = defaultJitterDepth
+	}
+	return &JitterBuffer{
+		depth:   depth,
+		packets: make(map[uint16]*rtp.Packet),
+	}
+}
+
+// Push adds a received packet
+func (j *JitterBuffer) Push(packet *rtp.Packet) {
+	seq := packet.SequenceNumber
+	if !j.started {
+		j.next = seq
+		j.started = true
+	}
+
+	// Sequence numbers wrap, so compare them as a signed distance
+	ahead := int16(seq - j.next)
+	switch {
+	case ahead < 0:
+		j.stats.Late++
+		return
+	case int(ahead) > maxJitterWindow:
+		// The sender restarted its sequence, there is nothing to wait for
+		j.stats.Resets++
+		clear(j.packets)
+		j.next = seq
+	}
+
+	if _, exists := j.packets[seq]; exists {
+		j.stats.Late++
+		return
+	}
+	j.packets[seq] = packet
+	j.stats.Packets++
+}
+
+// Pop returns the next packet in sequence. It returns lost when the next
+// packet was given up on, and ok false when it is still worth waiting for
+func (j *JitterBuffer) Pop() (packet *rtp.Packet, lost bool, ok bool) {
+	if !j.started {
+		return nil, false, false
+	}
+
+	if packet, exists := j.packets[j.next]; exists {
+		delete(j.packets, j.next)
+		j.next++
+		return packet, false, true
+	}
+
+	if len(j.packets) < j.depth {
+		return nil, false, false
+	}
+
+	// Conceal short gaps packet by packet; after a long one, concealing
+	// every lost packet would only add delay, so skip to what arrived
+	gap := j.gap()
+	if gap > j.depth {
+		j.stats.Lost += uint64(gap)
+		j.next += uint16(gap)
+		return j.Pop()
+	}
+	j.next++
+	j.stats.Lost++
+	return nil, true, true
+}
+
+// gap returns how many packets are missing before the earliest buffered one
+func (j *JitterBuffer) gap() int {
+	gap := -1
+	for seq := range j.packets {
+		if ahead := int(uint16(seq - j.next)); gap < 0 || ahead < gap {
+			gap = ahead
+		}
+	}
+	return gap
+}
+
+// Stats returns the buffer counters
+func (j *JitterBuffer) Stats() JitterStats {
+	return j.stats
+}
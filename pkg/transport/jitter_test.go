@@ -0,0 +1,101 @@
+package transport
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pion/rtp"
+)
+
+// popped is a packet or a loss returned by the jitter buffer
+type popped struct {
+	seq  uint16
+	lost bool
+}
+
+func TestJitterBuffer(t *testing.T) {
+	tests := []struct {
+		name      string
+		depth     int
+		arrivals  []uint16
+		want      []popped
+		wantStats JitterStats
+	}{
+		{
+			name:      "in order",
+			depth:     3,
+			arrivals:  []uint16{10, 11, 12},
+			want:      []popped{{seq: 10}, {seq: 11}, {seq: 12}},
+			wantStats: JitterStats{Packets: 3},
+		},
+		{
+			name:      "reordered",
+			depth:     3,
+			arrivals:  []uint16{10, 12, 11, 13},
+			want:      []popped{{seq: 10}, {seq: 11}, {seq: 12}, {seq: 13}},
+			wantStats: JitterStats{Packets: 4},
+		},
+		{
+			name:      "lost packet concealed",
+			depth:     2,
+			arrivals:  []uint16{10, 12, 13},
+			want:      []popped{{seq: 10}, {lost: true}, {seq: 12}, {seq: 13}},
+			wantStats: JitterStats{Packets: 3, Lost: 1},
+		},
+		{
+			name:      "late and duplicate packets dropped",
+			depth:     2,
+			arrivals:  []uint16{10, 12, 13, 11, 13},
+			want:      []popped{{seq: 10}, {lost: true}, {seq: 12}, {seq: 13}},
+			wantStats: JitterStats{Packets: 3, Lost: 1, Late: 2},
+		},
+		{
+			name:      "long gap skipped",
+			depth:     2,
+			arrivals:  []uint16{10, 20, 21},
+			want:      []popped{{seq: 10}, {seq: 20}, {seq: 21}},
+			wantStats: JitterStats{Packets: 3, Lost: 9},
+		},
+		{
+			name:      "sequence wraps",
+			depth:     3,
+			arrivals:  []uint16{65534, 65535, 0, 1},
+			want:      []popped{{seq: 65534}, {seq: 65535}, {seq: 0}, {seq: 1}},
+			wantStats: JitterStats{Packets: 4},
+		},
+		{
+			name:      "sender restart",
+			depth:     3,
+			arrivals:  []uint16{10, 5000, 5001},
+			want:      []popped{{seq: 10}, {seq: 5000}, {seq: 5001}},
+			wantStats: JitterStats{Packets: 3, Resets: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jitter := NewJitterBuffer(tt.depth)
+			var got []popped
+			for _, seq := range tt.arrivals {
+				jitter.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: seq}})
+				for {
+					packet, lost, ok := jitter.Pop()
+					if !ok {
+						break
+					}
+					if lost {
+						got = append(got, popped{lost: true})
+						continue
+					}
+					got = append(got, popped{seq: packet.SequenceNumber})
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("popped %+v, want %+v", got, tt.want)
+			}
+			if stats := jitter.Stats(); stats != tt.wantStats {
+				t.Errorf("stats = %+v, want %+v", stats, tt.wantStats)
+			}
+		})
+	}
+}
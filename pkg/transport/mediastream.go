@@ -0,0 +1,276 @@
+package transport
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Telephony media stream events, as sent by telephony providers that bridge
+// a call's audio to a WebSocket
+const (
+	MediaEventConnected = "connected" // provider → server: socket opened
+	MediaEventStart     = "start"     // provider → server: stream metadata
+	MediaEventMedia     = "media"     // both: a chunk of base64 audio
+	MediaEventMark      = "mark"      // both: playback reached a point sent by the server
+	MediaEventStop      = "stop"      // provider → server: the call ended
+	MediaEventDTMF      = "dtmf"      // provider → server: a key was pressed
+	MediaEventClear     = "clear"     // server → provider: drop audio not played yet
+)
+
+// mediaStreamQueueSize bounds the events waiting to be written to the provider
+const mediaStreamQueueSize = 1024
+
+// MediaEvent is a single media stream message
+type MediaEvent struct {
+	Event          string        `json:"event"`
+	SequenceNumber string        `json:"sequenceNumber,omitempty"`
+	StreamSID      string        `json:"streamSid,omitempty"`
+	Start          *MediaStart   `json:"start,omitempty"`
+	Media          *MediaPayload `json:"media,omitempty"`
+	Mark           *MediaMark    `json:"mark,omitempty"`
+	Stop           *MediaStop    `json:"stop,omitempty"`
+	DTMF           *MediaDTMF    `json:"dtmf,omitempty"`
+}
+
+// MediaStart describes the stream and the call it belongs to
+type MediaStart struct {
+	StreamSID        string            `json:"streamSid"`
+	AccountSID       string            `json:"accountSid,omitempty"`
+	CallSID          string            `json:"callSid,omitempty"`
+	Tracks           []string          `json:"tracks,omitempty"`
+	CustomParameters map[string]string `json:"customParameters,omitempty"`
+	MediaFormat      MediaFormat       `json:"mediaFormat"`
+}
+
+// MediaFormat is the audio format of a media stream
+type MediaFormat struct {
+	Encoding   string `json:"encoding"` // "audio/x-mulaw"
+	SampleRate int    `json:"sampleRate"`
+	Channels   int    `json:"channels"`
+}
+
+// MediaPayload carries base64 audio
+type MediaPayload struct {
+	Track     string `json:"track,omitempty"` // "inbound" is the caller
+	Chunk     string `json:"chunk,omitempty"`
+	Timestamp string `json:"timestamp,omitempty"` // Milliseconds since the stream started
+	Payload   string `json:"payload"`
+}
+
+// MediaMark names a point in the outbound audio
+type MediaMark struct {
+	Name string `json:"name"`
+}
+
+// MediaStop ends the stream
+type MediaStop struct {
+	AccountSID string `json:"accountSid,omitempty"`
+	CallSID    string `json:"callSid,omitempty"`
+}
+
+// MediaDTMF is a key pressed by the caller
+type MediaDTMF struct {
+	Track string `json:"track,omitempty"`
+	Digit string `json:"digit"`
+}
+
+// Audio decodes the audio of a media event
+func (e MediaEvent) Audio() ([]byte, error) {
+	if e.Media == nil {
+		return nil, errors.New("media event has no payload")
+	}
+	return base64.StdEncoding.DecodeString(e.Media.Payload)
+}
+
+// MediaStreamConn is a telephony provider's media stream connection. The
+// provider sends the caller's audio as media events and plays back media
+// events the server sends. Outbound events are written by a single writer
+// goroutine
+type MediaStreamConn struct {
+	options   WSOptions
+	ws        *websocket.Conn
+	streamSID string
+	events    chan []byte
+	closing   chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	failure   atomic.Pointer[error]
+}
+
+// UpgradeMediaStream upgrades an HTTP request to a media stream connection.
+// The caller must run Start before reading events
+func (h *WSHandler) UpgradeMediaStream(w http.ResponseWriter, r *http.Request) (*MediaStreamConn, error) {
+	ws, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &MediaStreamConn{
+		options: h.options,
+		ws:      ws,
+		events:  make(chan []byte, mediaStreamQueueSize),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go conn.writeLoop()
+	return conn, nil
+}
+
+// Start waits for the start event, skipping the connected event before it
+func (c *MediaStreamConn) Start(timeout time.Duration) (*MediaStart, error) {
+	c.ws.SetReadDeadline(time.Now().Add(timeout))
+	for {
+		event, err := c.readEvent()
+		if err != nil {
+			return nil, err
+		}
+
+		switch event.Event {
+		case MediaEventConnected:
+			continue
+		case MediaEventStart:
+			if event.Start == nil {
+				return nil, errors.New("start event has no metadata")
+			}
+			c.streamSID = event.Start.StreamSID
+			if c.streamSID == "" {
+				c.streamSID = event.StreamSID
+			}
+			return event.Start, nil
+		default:
+			return nil, fmt.Errorf("expected a start event, got %q", event.Event)
+		}
+	}
+}
+
+// ReadEvent reads the next event from the provider
+func (c *MediaStreamConn) ReadEvent() (MediaEvent, error) {
+	c.ws.SetReadDeadline(time.Now().Add(c.options.ReadTimeout))
+	return c.readEvent()
+}
+
+// readEvent reads and decodes one event
+func (c *MediaStreamConn) readEvent() (MediaEvent, error) {
+	var event MediaEvent
+	_, data, err := c.ws.ReadMessage()
+	if err != nil {
+		if failure := c.failure.Load(); failure != nil {
+			return event, *failure
+		}
+		return event, err
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return event, fmt.Errorf("invalid media stream event: %w", err)
+	}
+	return event, nil
+}
+
+// SendMedia queues audio in the stream format for playback
+func (c *MediaStreamConn) SendMedia(audio []byte) error {
+	return c.send(MediaEvent{
+		Event: MediaEventMedia,
+		Media: &MediaPayload{Payload: base64.StdEncoding.EncodeToString(audio)},
+	})
+}
+
+// SendMark queues a mark. The provider echoes it back once the audio queued
+// before it has been played
+func (c *MediaStreamConn) SendMark(name string) error {
+	return c.send(MediaEvent{Event: MediaEventMark, Mark: &MediaMark{Name: name}})
+}
+
+// SendClear asks the provider to drop audio it has not played yet
+func (c *MediaStreamConn) SendClear() error {
+	return c.send(MediaEvent{Event: MediaEventClear})
+}
+
+// send queues an event without blocking. A provider that cannot keep up is
+// disconnected
+func (c *MediaStreamConn) send(event MediaEvent) error {
+	event.StreamSID = c.streamSID
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-c.closing:
+		return ErrConnClosed
+	default:
+	}
+
+	select {
+	case c.events <- data:
+		return nil
+	default:
+		c.fail(errors.New("slow client: media queue full"))
+		return ErrConnClosed
+	}
+}
+
+// writeLoop is the only goroutine that writes to the socket
+func (c *MediaStreamConn) writeLoop() {
+	defer close(c.done)
+
+	for {
+		select {
+		case data := <-c.events:
+			c.ws.SetWriteDeadline(time.Now().Add(c.options.WriteTimeout))
+			if err := c.ws.WriteMessage(websocket.TextMessage, data); err != nil {
+				c.fail(err)
+				return
+			}
+		case <-c.closing:
+			c.drain()
+			return
+		}
+	}
+}
+
+// drain flushes queued events within closeDrainTimeout, then closes the socket
+func (c *MediaStreamConn) drain() {
+	deadline := time.Now().Add(closeDrainTimeout)
+	c.ws.SetWriteDeadline(deadline)
+
+	for {
+		select {
+		case data := <-c.events:
+			if err := c.ws.WriteMessage(websocket.TextMessage, data); err != nil {
+				c.ws.Close()
+				return
+			}
+		default:
+			c.ws.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), deadline)
+			c.ws.Close()
+			return
+		}
+	}
+}
+
+// fail closes a connection that can no longer be used. The reader sees err on
+// its next read
+func (c *MediaStreamConn) fail(err error) {
+	c.failure.CompareAndSwap(nil, &err)
+	c.closeOnce.Do(func() {
+		close(c.closing)
+	})
+	c.ws.Close()
+}
+
+// Close flushes queued events and closes the connection
+func (c *MediaStreamConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closing)
+	})
+	<-c.done
+	return nil
+}
@@ -0,0 +1,162 @@
+package transport
+
+import (
+	"errors"
+	"sync"
+)
+
+// Outbox defaults
+const (
+	DefaultOutboxMessages = 128
+	DefaultOutboxBytes    = 4 << 20
+)
+
+// Replayable reports whether a message type is kept in the outbox and replayed
+// to a client that resumes its session
+func Replayable(messageType string) bool {
+	switch messageType {
+	case TypeTranscript, TypeStatus, TypeInterviewerAudio, TypeHint, TypeGrade:
+		return true
+	default:
+		return false
+	}
+}
+
+// Sink receives the messages of an outbox. Both WebSocket connections and
+// event streams are sinks. SendMessage must not block
+type Sink interface {
+	SendMessage(msg Message) error
+}
+
+// Replayer is a sink whose queue may be smaller than a replay. ReplayMessage
+// waits for room in the queue instead of dropping the message or the client,
+// and fails once the sink is closed. Sinks that are not Replayers must queue a
+// full outbox without a writer running
+type Replayer interface {
+	ReplayMessage(msg Message) error
+}
+
+// Outbox numbers the messages of a session and keeps the most recent
+// replayable ones, bounded by count and size, so a client that reconnects can
+// catch up on what it missed. At most one sink is attached at a time
+type Outbox struct {
+	maxMessages int
+	maxBytes    int
+	messages    []Message
+	bytes       int
+	lastSeq     int64
+	sink        Sink
+	mu          sync.Mutex
+}
+
+// NewOutbox creates an outbox whose sequence numbers continue after lastSeq
+func NewOutbox(maxMessages, maxBytes int, lastSeq int64) *Outbox {
+	if maxMessages <= 0 {
+		maxMessages = DefaultOutboxMessages
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultOutboxBytes
+	}
+	return &Outbox{
+		maxMessages: maxMessages,
+		maxBytes:    maxBytes,
+		lastSeq:     lastSeq,
+	}
+}
+
+// Publish sends a message to the attached sink, if any. Replayable
+// messages are numbered and kept; others are sent without a sequence number
+func (o *Outbox) Publish(messageType string, payload interface{}) error {
+	msg, err := NewMessage(messageType, payload)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if Replayable(messageType) {
+		o.lastSeq++
+		msg.Seq = o.lastSeq
+
+		o.messages = append(o.messages, msg)
+		o.bytes += len(msg.Payload)
+		for len(o.messages) > o.maxMessages || (o.bytes > o.maxBytes && len(o.messages) > 1) {
+			o.bytes -= len(o.messages[0].Payload)
+			o.messages = o.messages[1:]
+		}
+	}
+
+	if o.sink == nil {
+		return nil
+	}
+	return o.sink.SendMessage(msg)
+}
+
+// Attach replays every kept message after lastSeq to sink and then routes new
+// messages to it, replacing any sink attached before. It reports how many
+// messages were replayed and whether the replay is complete, which it is not
+// if some of the missed messages were already evicted. The replay waits for
+// the sink without holding up Publish; messages published meanwhile are
+// replayed too, except unnumbered ones, which go to the sink attached before
+func (o *Outbox) Attach(sink Sink, lastSeq int64) (int, bool) {
+	send := sink.SendMessage
+	if replayer, ok := sink.(Replayer); ok {
+		send = replayer.ReplayMessage
+	}
+
+	complete := true
+	replayed := 0
+	for {
+		o.mu.Lock()
+		pending := o.messagesAfter(lastSeq)
+		if lastSeq < o.lastSeq && (len(pending) == 0 || pending[0].Seq > lastSeq+1) {
+			complete = false
+		}
+		if len(pending) == 0 {
+			o.sink = sink
+			o.mu.Unlock()
+			return replayed, complete
+		}
+		o.mu.Unlock()
+
+		for _, msg := range pending {
+			if err := send(msg); errors.Is(err, ErrConnClosed) {
+				o.mu.Lock()
+				o.sink = sink
+				o.mu.Unlock()
+				return replayed, complete
+			}
+			replayed++
+			lastSeq = msg.Seq
+		}
+	}
+}
+
+// messagesAfter returns a copy of the kept messages numbered after seq
+func (o *Outbox) messagesAfter(seq int64) []Message {
+	var messages []Message
+	for _, msg := range o.messages {
+		if msg.Seq > seq {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// Detach stops routing messages to sink if it is still attached
+func (o *Outbox) Detach(sink Sink) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.sink == sink {
+		o.sink = nil
+	}
+}
+
+// LastSeq returns the sequence number of the last replayable message
+func (o *Outbox) LastSeq() int64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.lastSeq
+}
@@ -0,0 +1,106 @@
+package transport
+
+import (
+	"testing"
+	"time"
+)
+
+// slowReplayer is a sink whose replays wait until they are released
+type slowReplayer struct {
+	release  chan struct{}
+	waiting  chan int64 // Replays waiting to be released
+	replayed chan int64
+	sent     chan int64
+}
+
+func newSlowReplayer() *slowReplayer {
+	return &slowReplayer{
+		release:  make(chan struct{}),
+		waiting:  make(chan int64, 8),
+		replayed: make(chan int64, 8),
+		sent:     make(chan int64, 8),
+	}
+}
+
+func (s *slowReplayer) SendMessage(msg Message) error {
+	s.sent <- msg.Seq
+	return nil
+}
+
+func (s *slowReplayer) ReplayMessage(msg Message) error {
+	s.waiting <- msg.Seq
+	<-s.release
+	s.replayed <- msg.Seq
+	return nil
+}
+
+func TestOutboxAttach(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxMessages  int
+		published    int
+		lastSeq      int64
+		wantReplayed int
+		wantComplete bool
+	}{
+		{"nothing missed", 8, 3, 3, 0, true},
+		{"missed messages", 8, 3, 1, 2, true},
+		{"evicted messages", 2, 5, 1, 2, false},
+		{"all missed messages evicted", 2, 5, 0, 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := NewOutbox(tt.maxMessages, 0, 0)
+			for range tt.published {
+				outbox.Publish(TypeStatus, StatusMessage{Status: "active"})
+			}
+
+			sink := newSlowReplayer()
+			close(sink.release)
+			replayed, complete := outbox.Attach(sink, tt.lastSeq)
+			if replayed != tt.wantReplayed || complete != tt.wantComplete {
+				t.Errorf("Attach = %d, %v, want %d, %v", replayed, complete, tt.wantReplayed, tt.wantComplete)
+			}
+		})
+	}
+}
+
+func TestOutboxPublishDuringReplay(t *testing.T) {
+	outbox := NewOutbox(0, 0, 0)
+	outbox.Publish(TypeStatus, StatusMessage{Status: "active"})
+
+	sink := newSlowReplayer()
+	attached := make(chan int)
+	go func() {
+		replayed, _ := outbox.Attach(sink, 0)
+		attached <- replayed
+	}()
+
+	// Publishing goes on while the client catches up
+	<-sink.waiting
+	published := make(chan struct{})
+	go func() {
+		outbox.Publish(TypeTranscript, TranscriptMessage{Text: "hello"})
+		close(published)
+	}()
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("Publish waited for the replay")
+	}
+
+	close(sink.release)
+	if replayed := <-attached; replayed != 2 {
+		t.Errorf("replayed %d messages, want the kept one and the one published meanwhile", replayed)
+	}
+	for want := int64(1); want <= 2; want++ {
+		if seq := <-sink.replayed; seq != want {
+			t.Errorf("replayed seq %d, want %d", seq, want)
+		}
+	}
+
+	outbox.Publish(TypeStatus, StatusMessage{Status: "active"})
+	if seq := <-sink.sent; seq != 3 {
+		t.Errorf("sent seq %d after attaching, want 3", seq)
+	}
+}
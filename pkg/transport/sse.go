@@ -0,0 +1,169 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Event stream settings
+const (
+	eventStreamQueueSize = DefaultOutboxMessages + 32 // Messages buffered for a slow client, a full replay with room to spare
+	eventStreamKeepalive = 15 * time.Second           // Comment sent to keep proxies from timing out the stream
+	eventStreamRetry     = 2000                       // Reconnect delay suggested to the browser, in milliseconds
+)
+
+// ErrStreamBehind is returned when an event stream client reads too slowly
+var ErrStreamBehind = errors.New("event stream client fell behind")
+
+// EventStream sends protocol messages to an HTTP client as Server-Sent Events,
+// for clients that cannot open a WebSocket. Each event carries the same JSON
+// envelope as a WebSocket text frame, and replayable messages use their seq as
+// the event ID so the browser resumes with Last-Event-ID after a reconnect.
+// A client that falls behind is disconnected and catches up on reconnect
+type EventStream struct {
+	w         http.ResponseWriter
+	rc        *http.ResponseController
+	messages  chan Message
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewEventStream starts an event stream on w
+func NewEventStream(w http.ResponseWriter) (*EventStream, error) {
+	s := &EventStream{
+		w:        w,
+		rc:       http.NewResponseController(w),
+		messages: make(chan Message, eventStreamQueueSize),
+		closing:  make(chan struct{}),
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx response buffering
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", eventStreamRetry); err != nil {
+		return nil, err
+	}
+	if err := s.rc.Flush(); err != nil {
+		return nil, fmt.Errorf("response cannot be streamed: %w", err)
+	}
+	return s, nil
+}
+
+// LastEventID returns the ID of the last event a reconnecting client saw,
+// from the Last-Event-ID header or the last_event_id query parameter, or 0
+func LastEventID(r *http.Request) (int64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("invalid last event ID %q", value)
+	}
+	return id, nil
+}
+
+// Send queues a message
+func (s *EventStream) Send(messageType string, payload interface{}) error {
+	msg, err := NewMessage(messageType, payload)
+	if err != nil {
+		return err
+	}
+	return s.SendMessage(msg)
+}
+
+// SendMessage queues an already built message without blocking. If the queue
+// is full the stream is closed
+func (s *EventStream) SendMessage(msg Message) error {
+	select {
+	case <-s.closing:
+		return ErrConnClosed
+	default:
+	}
+
+	select {
+	case s.messages <- msg:
+		return nil
+	default:
+		s.Close()
+		return ErrStreamBehind
+	}
+}
+
+// Run writes queued messages until ctx is done, the stream is closed or a
+// write fails. It must be called from the HTTP handler that created the stream
+func (s *EventStream) Run(ctx context.Context) error {
+	keepalive := time.NewTicker(eventStreamKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-s.closing:
+			// Flush what is already queued before returning
+			for {
+				select {
+				case msg := <-s.messages:
+					if err := s.write(msg); err != nil {
+						return err
+					}
+				default:
+					return s.rc.Flush()
+				}
+			}
+
+		case msg := <-s.messages:
+			if err := s.write(msg); err != nil {
+				return err
+			}
+			if err := s.rc.Flush(); err != nil {
+				return err
+			}
+
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(s.w, ": keepalive\n\n"); err != nil {
+				return err
+			}
+			if err := s.rc.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// write writes a message as a single event
+func (s *EventStream) write(msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	if msg.Seq > 0 {
+		if _, err := fmt.Fprintf(s.w, "id: %d\n", msg.Seq); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(s.w, "data: %s\n\n", data)
+	return err
+}
+
+// Close stops the stream once queued messages are written
+func (s *EventStream) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closing)
+	})
+}
@@ -1,10 +1,140 @@
 package transport
 
-// Message represents a transport message
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Protocol versions. Version 0 is the legacy protocol spoken by clients that skip
+// the handshake: they send raw PCM and receive flat JSON objects
+const (
+	LegacyProtocolVersion = 0
+	ProtocolVersion       = 1
+)
+
+// SupportedVersions lists the protocol versions this server speaks, newest first
+var SupportedVersions = []int{ProtocolVersion}
+
+// Control message types
+const (
+	TypeStart            = "start"             // client → server: handshake
+	TypeReady            = "ready"             // server → client: handshake accepted
+	TypeStop             = "stop"              // client → server: end the audio stream
+	TypeMute             = "mute"              // client → server: pause or resume audio processing
+	TypeConfig           = "config"            // client → server: update recognition settings
+	TypePing             = "ping"              // either direction
+	TypePong             = "pong"              // reply to ping
+	TypeHeartbeat        = "heartbeat"         // client → server: liveness and playback position
+	TypeTranscript       = "transcript"        // server → client: speech recognition result
+	TypeStatus           = "status"            // server → client: session status change
+	TypeInterviewerAudio = "interviewer_audio" // server → client: synthesized interviewer speech
+	TypeError            = "error"             // server → client: protocol or processing error
+	TypeLegacyAudio      = "audio_data"        // client → server: base64 audio in JSON (legacy)
+	TypeHint             = "hint"              // server → client: hint from the interviewer or a coach
+	TypeGrade            = "grade"             // server → client: grade of an answer
+	TypeEvent            = "event"             // server → observer: session timeline event
+	TypeState            = "state"             // server → observer: interview state snapshot
+	TypeWhisper          = "whisper"           // coach → server: steer the interview
+	TypeServerDraining   = "server_draining"   // server → client and observer: the instance is shutting down
+)
+
+// Error codes carried in ErrorMessage
+const (
+	ErrorCodeBadMessage         = "bad_message"
+	ErrorCodeUnknownType        = "unknown_type"
+	ErrorCodeUnsupportedVersion = "unsupported_version"
+	ErrorCodeFormatMismatch     = "format_mismatch"
+	ErrorCodeSTT                = "stt_error"
+	ErrorCodeAudio              = "audio_error"
+	ErrorCodeInternal           = "internal_error"
+	ErrorCodeForbidden          = "forbidden"
+	ErrorCodeBudgetExceeded     = "budget_exceeded" // The session used up its budget and is closed
+)
+
+// Message is the JSON envelope of every control message
 type Message struct {
-	Type      string      `json:"type"`
-	Payload   interface{} `json:"payload"`
-	Timestamp int64       `json:"timestamp"`
+	Type      string          `json:"type"`
+	Version   int             `json:"version"`
+	Seq       int64           `json:"seq,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Timestamp int64           `json:"timestamp"`
+}
+
+// NewMessage creates a message of the current protocol version with an encoded payload
+func NewMessage(messageType string, payload interface{}) (Message, error) {
+	msg := Message{
+		Type:      messageType,
+		Version:   ProtocolVersion,
+		Timestamp: time.Now().UnixMilli(),
+	}
+
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return Message{}, fmt.Errorf("failed to marshal %s payload: %w", messageType, err)
+		}
+		msg.Payload = data
+	}
+	return msg, nil
+}
+
+// Decode unmarshals the message payload into v
+func (m Message) Decode(v interface{}) error {
+	if len(m.Payload) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(m.Payload, v); err != nil {
+		return fmt.Errorf("invalid %s payload: %w", m.Type, err)
+	}
+	return nil
+}
+
+// AudioFormat describes raw audio carried in binary frames
+type AudioFormat struct {
+	SampleRate int    `json:"sample_rate"`
+	Encoding   string `json:"encoding"`
+	Channels   int    `json:"channels"`
+}
+
+// AudioHeaderSize is the size of the header that prefixes binary audio frames
+// when sequenced audio is negotiated: a big-endian uint32 sequence number
+// followed by a big-endian uint64 capture timestamp in Unix milliseconds
+const AudioHeaderSize = 12
+
+// StartMessage is the client handshake
+type StartMessage struct {
+	Versions       []int        `json:"versions"`
+	Format         *AudioFormat `json:"format,omitempty"`
+	SequencedAudio bool         `json:"sequenced_audio,omitempty"` // Binary frames carry an AudioHeaderSize header
+	Client         string       `json:"client,omitempty"`
+}
+
+// ReadyMessage accepts the handshake and announces the negotiated settings
+type ReadyMessage struct {
+	SessionID      string      `json:"session_id"`
+	Version        int         `json:"version"`
+	Format         AudioFormat `json:"format"`
+	SequencedAudio bool        `json:"sequenced_audio"`
+}
+
+// MuteMessage pauses or resumes audio processing
+type MuteMessage struct {
+	Muted bool `json:"muted"`
+}
+
+// HeartbeatMessage is sent periodically by the client. Once a client has sent
+// one it must keep sending them or the connection is considered dead
+type HeartbeatMessage struct {
+	PlaybackPosition int64 `json:"playback_position_ms"` // Position in the interviewer audio the client has played
+	Playing          bool  `json:"playing"`              // Whether interviewer audio is currently playing
+}
+
+// ConfigMessage updates speech recognition settings. Nil fields are left unchanged
+type ConfigMessage struct {
+	EndOfTurnConfidenceThreshold     *float64 `json:"end_of_turn_confidence_threshold,omitempty"`
+	MinEndOfTurnSilenceWhenConfident *int     `json:"min_end_of_turn_silence_when_confident,omitempty"`
+	MaxTurnSilence                   *int     `json:"max_turn_silence,omitempty"`
 }
 
 // AudioMessage represents an audio data message
@@ -21,9 +151,54 @@ type TextMessage struct {
 	Speaker   string `json:"speaker"`
 }
 
+// TranscriptMessage carries a speech recognition result
+type TranscriptMessage struct {
+	MessageType string  `json:"message_type"` // "PartialTranscript", "FinalTranscript" or "Turn"
+	Text        string  `json:"text"`
+	Confidence  float64 `json:"confidence"`
+	IsFinal     bool    `json:"is_final"`
+	SessionID   string  `json:"session_id"` // AssemblyAI session ID
+}
+
 // StatusMessage represents a status update message
 type StatusMessage struct {
 	SessionID string `json:"session_id"`
 	Status    string `json:"status"`
 	Details   string `json:"details"`
 }
+
+// ServerDrainingMessage tells a client the instance it is connected to is
+// shutting down. The interview can carry on until Deadline; after that the
+// client is disconnected and should reconnect with its resume token, which
+// another instance will serve
+type ServerDrainingMessage struct {
+	SessionID string    `json:"session_id"`
+	Deadline  time.Time `json:"deadline"`
+	Details   string    `json:"details"`
+}
+
+// HintMessage gives the candidate a hint for the current question
+type HintMessage struct {
+	QuestionIndex int    `json:"question_index"`
+	Text          string `json:"text"`
+	Source        string `json:"source"` // "silence", "request" or "coach"
+}
+
+// Whisper actions
+const (
+	WhisperHint         = "hint"
+	WhisperNextQuestion = "next_question"
+)
+
+// WhisperMessage is sent by a coach to steer the interview. A hint without
+// text uses the next hint of the current question
+type WhisperMessage struct {
+	Action string `json:"action"` // WhisperHint or WhisperNextQuestion
+	Text   string `json:"text,omitempty"`
+}
+
+// ErrorMessage reports a protocol or processing error
+type ErrorMessage struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,470 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+)
+
+// WebRTC peer queue sizes
+const (
+	rtcFrameQueueSize   = 64   // Inbound frames waiting for ReadFrame
+	rtcMessageQueueSize = 128  // Outbound data channel messages
+	rtcAudioQueueSize   = 3000 // Outbound audio packets, a minute of 20ms packets
+)
+
+// ErrAudioBehind is returned by WriteAudio when too much audio is queued
+var ErrAudioBehind = errors.New("outbound audio queue full")
+
+// RTCOptions configures WebRTC peers. Zero values use the defaults
+type RTCOptions struct {
+	ICEServers     []string      // STUN and TURN URLs used to gather candidates
+	JitterDepth    int           // Packets that may wait for a missing one before it is concealed
+	GatherTimeout  time.Duration // Max time to gather ICE candidates for the answer
+	ConnectTimeout time.Duration // Max time for the client to connect after the answer
+}
+
+// DefaultRTCOptions returns the default WebRTC settings
+func DefaultRTCOptions() RTCOptions {
+	return RTCOptions{
+		JitterDepth:    defaultJitterDepth,
+		GatherTimeout:  5 * time.Second,
+		ConnectTimeout: 30 * time.Second,
+	}
+}
+
+// RTCPeer is the server end of a WebRTC connection with a client. The client
+// sends Opus audio on a media track and protocol messages on a data channel;
+// the server answers on the same data channel and plays the interviewer on
+// an Opus track. Negotiation is a single offer and answer with all ICE
+// candidates included, so signaling needs only one HTTP request
+type RTCPeer struct {
+	options  RTCOptions
+	pc       *webrtc.PeerConnection
+	track    *webrtc.TrackLocalStaticSample
+	frames   chan Frame
+	messages chan []byte
+	samples  chan media.Sample
+
+	channel  atomic.Pointer[webrtc.DataChannel]
+	opened   chan struct{}
+	openOnce sync.Once
+
+	jitter   JitterStats
+	jitterMu sync.Mutex
+
+	closing   chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+	failure   atomic.Pointer[error] // Why the peer was closed
+}
+
+// NewRTCPeer answers a client's SDP offer. The answer is available from
+// LocalDescription once NewRTCPeer returns
+func NewRTCPeer(opts RTCOptions, offer webrtc.SessionDescription) (*RTCPeer, error) {
+	defaults := DefaultRTCOptions()
+	if opts.JitterDepth <= 0 {
+		opts.JitterDepth = defaults.JitterDepth
+	}
+	if opts.GatherTimeout <= 0 {
+		opts.GatherTimeout = defaults.GatherTimeout
+	}
+	if opts.ConnectTimeout <= 0 {
+		opts.ConnectTimeout = defaults.ConnectTimeout
+	}
+
+	if offer.Type != webrtc.SDPTypeOffer {
+		return nil, fmt.Errorf("expected an SDP offer, got %q", offer.Type)
+	}
+
+	mediaEngine := &webrtc.MediaEngine{}
+	if err := mediaEngine.RegisterDefaultCodecs(); err != nil {
+		return nil, err
+	}
+	// NACKs and receiver reports let the client recover from packet loss
+	interceptors := &interceptor.Registry{}
+	if err := webrtc.RegisterDefaultInterceptors(mediaEngine, interceptors); err != nil {
+		return nil, err
+	}
+	api := webrtc.NewAPI(webrtc.WithMediaEngine(mediaEngine), webrtc.WithInterceptorRegistry(interceptors))
+
+	config := webrtc.Configuration{}
+	if len(opts.ICEServers) > 0 {
+		config.ICEServers = []webrtc.ICEServer{{URLs: opts.ICEServers}}
+	}
+	pc, err := api.NewPeerConnection(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create peer connection: %w", err)
+	}
+
+	p := &RTCPeer{
+		options:  opts,
+		pc:       pc,
+		frames:   make(chan Frame, rtcFrameQueueSize),
+		messages: make(chan []byte, rtcMessageQueueSize),
+		samples:  make(chan media.Sample, rtcAudioQueueSize),
+		opened:   make(chan struct{}),
+		closing:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	pc.OnTrack(func(track *webrtc.TrackRemote, _ *webrtc.RTPReceiver) {
+		if !strings.EqualFold(track.Codec().MimeType, webrtc.MimeTypeOpus) {
+			p.fail(fmt.Errorf("unsupported client audio codec %s", track.Codec().MimeType))
+			return
+		}
+		go p.readTrack(track)
+	})
+	pc.OnDataChannel(p.attachChannel)
+	pc.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		switch state {
+		case webrtc.PeerConnectionStateFailed:
+			p.fail(errors.New("peer connection failed"))
+		case webrtc.PeerConnectionStateClosed:
+			p.fail(ErrConnClosed)
+		}
+	})
+
+	if err := p.negotiate(offer); err != nil {
+		pc.Close()
+		return nil, err
+	}
+
+	go p.writeMessages()
+	go p.writeAudio()
+	go p.watchConnect()
+	return p, nil
+}
+
+// negotiate applies the offer and creates an answer with gathered candidates
+func (p *RTCPeer) negotiate(offer webrtc.SessionDescription) error {
+	track, err := webrtc.NewTrackLocalStaticSample(
+		webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2},
+		"audio", "interviewer")
+	if err != nil {
+		return err
+	}
+	sender, err := p.pc.AddTrack(track)
+	if err != nil {
+		return fmt.Errorf("failed to add interviewer track: %w", err)
+	}
+	p.track = track
+
+	// RTCP must be read for the interceptors to process it
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			if _, _, err := sender.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	if err := p.pc.SetRemoteDescription(offer); err != nil {
+		return fmt.Errorf("invalid offer: %w", err)
+	}
+	answer, err := p.pc.CreateAnswer(nil)
+	if err != nil {
+		return fmt.Errorf("failed to create answer: %w", err)
+	}
+	gathered := webrtc.GatheringCompletePromise(p.pc)
+	if err := p.pc.SetLocalDescription(answer); err != nil {
+		return fmt.Errorf("failed to set answer: %w", err)
+	}
+
+	select {
+	case <-gathered:
+		return nil
+	case <-time.After(p.options.GatherTimeout):
+		return errors.New("timed out gathering ICE candidates")
+	}
+}
+
+// LocalDescription returns the SDP answer for the client
+func (p *RTCPeer) LocalDescription() webrtc.SessionDescription {
+	return *p.pc.LocalDescription()
+}
+
+// watchConnect closes a peer whose client never connects
+func (p *RTCPeer) watchConnect() {
+	timer := time.NewTimer(p.options.ConnectTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-p.opened:
+	case <-p.closing:
+	case <-timer.C:
+		p.fail(errors.New("client did not connect"))
+	}
+}
+
+// attachChannel takes the client's data channel for protocol messages
+func (p *RTCPeer) attachChannel(channel *webrtc.DataChannel) {
+	if !p.channel.CompareAndSwap(nil, channel) {
+		channel.Close()
+		return
+	}
+
+	channel.OnOpen(func() {
+		p.openOnce.Do(func() { close(p.opened) })
+	})
+	channel.OnClose(func() {
+		p.fail(ErrConnClosed)
+	})
+	channel.OnMessage(func(msg webrtc.DataChannelMessage) {
+		inbound, err := parseInbound(msg.Data)
+		if err != nil {
+			var protoErr *ProtocolError
+			if errors.As(err, &protoErr) {
+				p.SendError(protoErr.Code, protoErr.Message)
+			}
+			return
+		}
+		if inbound.Type == TypeLegacyAudio {
+			p.SendError(ErrorCodeBadMessage, "audio must be sent on the media track")
+			return
+		}
+		p.deliver(Frame{Kind: FrameControl, Message: inbound.Message})
+	})
+}
+
+// readTrack reads client audio, puts it back in order and delivers it
+func (p *RTCPeer) readTrack(track *webrtc.TrackRemote) {
+	jitter := NewJitterBuffer(p.options.JitterDepth)
+
+	for {
+		packet, _, err := track.ReadRTP()
+		if err != nil {
+			p.fail(err)
+			return
+		}
+
+		jitter.Push(packet)
+		for {
+			packet, lost, ok := jitter.Pop()
+			if !ok {
+				break
+			}
+			frame := Frame{Kind: FrameAudioLoss}
+			if !lost {
+				frame = Frame{Kind: FrameAudio, Audio: packet.Payload}
+			}
+			if !p.deliver(frame) {
+				return
+			}
+		}
+
+		p.jitterMu.Lock()
+		p.jitter = jitter.Stats()
+		p.jitterMu.Unlock()
+	}
+}
+
+// deliver queues an inbound frame for ReadFrame. It reports false once the
+// peer is closed
+func (p *RTCPeer) deliver(frame Frame) bool {
+	select {
+	case p.frames <- frame:
+		return true
+	case <-p.closing:
+		return false
+	}
+}
+
+// ReadFrame returns the next inbound frame: an Opus packet, a lost packet to
+// conceal or a control message. It returns an error once the peer is closed
+func (p *RTCPeer) ReadFrame() (Frame, error) {
+	select {
+	case <-p.closing:
+		return Frame{}, p.closeReason()
+	default:
+	}
+
+	select {
+	case frame := <-p.frames:
+		return frame, nil
+	case <-p.closing:
+		return Frame{}, p.closeReason()
+	}
+}
+
+// closeReason returns why the peer was closed
+func (p *RTCPeer) closeReason() error {
+	if failure := p.failure.Load(); failure != nil {
+		return *failure
+	}
+	return ErrConnClosed
+}
+
+// Send queues a message on the data channel
+func (p *RTCPeer) Send(messageType string, payload interface{}) error {
+	msg, err := NewMessage(messageType, payload)
+	if err != nil {
+		return err
+	}
+	return p.SendMessage(msg)
+}
+
+// SendMessage queues an already built message without blocking. Messages sent
+// before the data channel opens are delivered once it does. A client that
+// cannot keep up is disconnected
+func (p *RTCPeer) SendMessage(msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-p.closing:
+		return ErrConnClosed
+	default:
+	}
+
+	select {
+	case p.messages <- data:
+		return nil
+	default:
+		p.fail(errors.New("slow client: message queue full"))
+		return ErrConnClosed
+	}
+}
+
+// ReplayMessage queues a replayed message, waiting for room in the queue. The
+// queue only drains once the data channel is open
+func (p *RTCPeer) ReplayMessage(msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case p.messages <- data:
+		return nil
+	case <-p.closing:
+		return ErrConnClosed
+	}
+}
+
+// SendError queues an error message
+func (p *RTCPeer) SendError(code, message string) error {
+	return p.Send(TypeError, ErrorMessage{Code: code, Message: message})
+}
+
+// WriteAudio queues an Opus packet for the interviewer track. Packets are
+// sent in real time, duration apart
+func (p *RTCPeer) WriteAudio(packet []byte, duration time.Duration) error {
+	select {
+	case <-p.closing:
+		return ErrConnClosed
+	default:
+	}
+
+	select {
+	case p.samples <- media.Sample{Data: packet, Duration: duration}:
+		return nil
+	default:
+		return ErrAudioBehind
+	}
+}
+
+// writeMessages sends queued messages once the data channel is open, and
+// closes the peer connection after flushing them when the peer is closed
+func (p *RTCPeer) writeMessages() {
+	defer close(p.done)
+	defer p.pc.Close()
+
+	select {
+	case <-p.opened:
+	case <-p.closing:
+		return
+	}
+	channel := p.channel.Load()
+
+	for {
+		select {
+		case data := <-p.messages:
+			if err := channel.SendText(string(data)); err != nil {
+				p.fail(err)
+				return
+			}
+		case <-p.closing:
+			for {
+				select {
+				case data := <-p.messages:
+					if err := channel.SendText(string(data)); err != nil {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// writeAudio paces queued audio onto the interviewer track
+func (p *RTCPeer) writeAudio() {
+	var next time.Time
+	for {
+		var sample media.Sample
+		select {
+		case sample = <-p.samples:
+		case <-p.closing:
+			return
+		}
+
+		// Keep a packet's worth of lead so the client's jitter buffer never
+		// runs dry, without sending faster than real time
+		if wait := time.Until(next.Add(-sample.Duration)); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-p.closing:
+				return
+			}
+		}
+		if now := time.Now(); next.Before(now) {
+			next = now
+		}
+		next = next.Add(sample.Duration)
+
+		if err := p.track.WriteSample(sample); err != nil {
+			p.fail(err)
+			return
+		}
+	}
+}
+
+// JitterStats returns the counters of the client audio jitter buffer
+func (p *RTCPeer) JitterStats() JitterStats {
+	p.jitterMu.Lock()
+	defer p.jitterMu.Unlock()
+	return p.jitter
+}
+
+// fail closes the peer, remembering the first reason
+func (p *RTCPeer) fail(err error) {
+	p.failure.CompareAndSwap(nil, &err)
+	p.Close()
+}
+
+// Close stops the peer once queued messages are sent. It does not wait, so it
+// is safe to call while holding the session lock
+func (p *RTCPeer) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.closing)
+	})
+	return nil
+}
+
+// Wait blocks until the peer connection is closed
+func (p *RTCPeer) Wait() {
+	<-p.done
+}
@@ -0,0 +1,225 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+)
+
+const rtcTestTimeout = 10 * time.Second
+
+// rtcTestClient is the client end of a WebRTC connection to an RTCPeer
+type rtcTestClient struct {
+	pc       *webrtc.PeerConnection
+	track    *webrtc.TrackLocalStaticRTP
+	channel  *webrtc.DataChannel
+	opened   chan struct{}
+	messages chan Message
+	audio    chan []byte
+}
+
+// connectRTC connects a client peer to a new RTCPeer in the same process
+func connectRTC(t *testing.T) (*rtcTestClient, *RTCPeer) {
+	t.Helper()
+
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		t.Fatalf("NewPeerConnection: %v", err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	client := &rtcTestClient{
+		pc:       pc,
+		opened:   make(chan struct{}),
+		messages: make(chan Message, 16),
+		audio:    make(chan []byte, 16),
+	}
+	client.track, err = webrtc.NewTrackLocalStaticRTP(
+		webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus, ClockRate: 48000, Channels: 2}, "audio", "candidate")
+	if err != nil {
+		t.Fatalf("NewTrackLocalStaticRTP: %v", err)
+	}
+	if _, err := pc.AddTrack(client.track); err != nil {
+		t.Fatalf("AddTrack: %v", err)
+	}
+	pc.OnTrack(func(track *webrtc.TrackRemote, _ *webrtc.RTPReceiver) {
+		for {
+			packet, _, err := track.ReadRTP()
+			if err != nil {
+				return
+			}
+			client.audio <- packet.Payload
+		}
+	})
+
+	client.channel, err = pc.CreateDataChannel("control", nil)
+	if err != nil {
+		t.Fatalf("CreateDataChannel: %v", err)
+	}
+	client.channel.OnOpen(func() { close(client.opened) })
+	client.channel.OnMessage(func(msg webrtc.DataChannelMessage) {
+		var message Message
+		if err := json.Unmarshal(msg.Data, &message); err == nil {
+			client.messages <- message
+		}
+	})
+
+	offer, err := pc.CreateOffer(nil)
+	if err != nil {
+		t.Fatalf("CreateOffer: %v", err)
+	}
+	gathered := webrtc.GatheringCompletePromise(pc)
+	if err := pc.SetLocalDescription(offer); err != nil {
+		t.Fatalf("SetLocalDescription: %v", err)
+	}
+	<-gathered
+
+	peer, err := NewRTCPeer(RTCOptions{}, *pc.LocalDescription())
+	if err != nil {
+		t.Fatalf("NewRTCPeer: %v", err)
+	}
+	t.Cleanup(func() { peer.Close() })
+	if err := pc.SetRemoteDescription(peer.LocalDescription()); err != nil {
+		t.Fatalf("SetRemoteDescription: %v", err)
+	}
+
+	select {
+	case <-client.opened:
+	case <-time.After(rtcTestTimeout):
+		t.Fatal("data channel did not open")
+	}
+	return client, peer
+}
+
+// readFrame reads the next frame of kind from peer, skipping others
+func readFrame(t *testing.T, peer *RTCPeer, kind FrameKind) Frame {
+	t.Helper()
+
+	frames := make(chan Frame, 1)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			frame, err := peer.ReadFrame()
+			if err != nil {
+				errs <- err
+				return
+			}
+			if frame.Kind == kind {
+				frames <- frame
+				return
+			}
+		}
+	}()
+
+	select {
+	case frame := <-frames:
+		return frame
+	case err := <-errs:
+		t.Fatalf("ReadFrame: %v", err)
+	case <-time.After(rtcTestTimeout):
+		t.Fatal("timed out reading a frame")
+	}
+	return Frame{}
+}
+
+func TestRTCPeerDataChannel(t *testing.T) {
+	client, peer := connectRTC(t)
+
+	ping, err := json.Marshal(Message{Type: TypePing, Version: ProtocolVersion})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.channel.SendText(string(ping)); err != nil {
+		t.Fatalf("SendText: %v", err)
+	}
+	if frame := readFrame(t, peer, FrameControl); frame.Message.Type != TypePing {
+		t.Errorf("control frame type = %q, want %q", frame.Message.Type, TypePing)
+	}
+
+	if err := peer.Send(TypeStatus, StatusMessage{SessionID: "session", Status: "active"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	select {
+	case msg := <-client.messages:
+		var status StatusMessage
+		if msg.Type != TypeStatus || msg.Decode(&status) != nil || status.Status != "active" {
+			t.Errorf("client got %+v, want the active status", msg)
+		}
+	case <-time.After(rtcTestTimeout):
+		t.Fatal("client did not get the status")
+	}
+}
+
+func TestRTCPeerAudio(t *testing.T) {
+	client, peer := connectRTC(t)
+
+	// Packets written before the media path is up are dropped, so the client
+	// streams until the peer hears it
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+		for seq := uint16(1); ; seq++ {
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+			client.track.WriteRTP(&rtp.Packet{
+				Header:  rtp.Header{Version: 2, SequenceNumber: seq, Timestamp: uint32(seq) * 960},
+				Payload: []byte{byte(seq >> 8), byte(seq)},
+			})
+		}
+	}()
+
+	first := readFrame(t, peer, FrameAudio).Audio
+	for i := 1; i <= 5; i++ {
+		audio := readFrame(t, peer, FrameAudio).Audio
+		want := uint16(first[0])<<8 | uint16(first[1]) + uint16(i)
+		if got := uint16(audio[0])<<8 | uint16(audio[1]); got != want {
+			t.Fatalf("audio packet %d = %d, want %d", i, got, want)
+		}
+	}
+	if stats := peer.JitterStats(); stats.Packets < 5 {
+		t.Errorf("jitter stats = %+v, want at least 5 packets", stats)
+	}
+
+	// The interviewer is heard on the server's track
+	if err := peer.WriteAudio([]byte{0xfc, 0xff, 0xfe}, 20*time.Millisecond); err != nil {
+		t.Fatalf("WriteAudio: %v", err)
+	}
+	select {
+	case audio := <-client.audio:
+		if string(audio) != string([]byte{0xfc, 0xff, 0xfe}) {
+			t.Errorf("client heard %x, want fcfffe", audio)
+		}
+	case <-time.After(rtcTestTimeout):
+		t.Fatal("client did not hear the interviewer")
+	}
+}
+
+func TestRTCPeerClose(t *testing.T) {
+	client, peer := connectRTC(t)
+
+	closed := make(chan struct{})
+	client.channel.OnClose(func() { close(closed) })
+
+	peer.Close()
+	peer.Wait()
+	if _, err := peer.ReadFrame(); !errors.Is(err, ErrConnClosed) {
+		t.Errorf("ReadFrame after Close: err = %v, want ErrConnClosed", err)
+	}
+	if err := peer.WriteAudio([]byte{0}, 20*time.Millisecond); !errors.Is(err, ErrConnClosed) {
+		t.Errorf("WriteAudio after Close: err = %v, want ErrConnClosed", err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(rtcTestTimeout):
+		t.Fatal("client data channel was not closed")
+	}
+}
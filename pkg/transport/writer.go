@@ -0,0 +1,299 @@
+package transport
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Priority orders outbound messages. Lower values are written first
+type Priority int
+
+const (
+	PriorityControl    Priority = iota // Handshake, status, errors and pings
+	PriorityTranscript                 // Speech recognition results
+	PriorityAudio                      // Interviewer audio
+	numPriorities
+)
+
+// String returns the metric name of the priority
+func (p Priority) String() string {
+	switch p {
+	case PriorityControl:
+		return "control"
+	case PriorityTranscript:
+		return "transcript"
+	case PriorityAudio:
+		return "audio"
+	default:
+		return "unknown"
+	}
+}
+
+// queueSizes bounds each outbound queue of a connection
+var queueSizes = [numPriorities]int{
+	PriorityControl:    32,
+	PriorityTranscript: 128,
+	PriorityAudio:      64,
+}
+
+// closeDrainTimeout bounds how long Close spends flushing queued messages
+const closeDrainTimeout = time.Second
+
+// ErrConnClosed is returned when sending on a closed connection
+var ErrConnClosed = errors.New("connection closed")
+
+// Outbound queue and keepalive metrics across all connections, published to
+// Prometheus
+var (
+	outboundQueued = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "callservice",
+		Subsystem: "websocket",
+		Name:      "outbound_queued",
+		Help:      "Messages queued for WebSocket clients, by priority.",
+	}, []string{"priority"})
+
+	outboundDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "callservice",
+		Subsystem: "websocket",
+		Name:      "outbound_dropped_total",
+		Help:      "Messages dropped for slow WebSocket clients, by priority.",
+	}, []string{"priority"})
+
+	slowClientDisconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "callservice",
+		Subsystem: "websocket",
+		Name:      "slow_client_disconnects_total",
+		Help:      "WebSocket clients disconnected for not keeping up with control messages.",
+	})
+
+	writeErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "callservice",
+		Subsystem: "websocket",
+		Name:      "write_errors_total",
+		Help:      "Failed WebSocket writes.",
+	})
+
+	keepalives = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "callservice",
+		Subsystem: "websocket",
+		Name:      "keepalive_total",
+		Help:      "WebSocket keepalive outcomes: pings_sent, ping_errors or heartbeat_timeouts.",
+	}, []string{"result"})
+)
+
+// PriorityFor returns the queue a message type is sent on
+func PriorityFor(messageType string) Priority {
+	switch messageType {
+	case TypeTranscript:
+		return PriorityTranscript
+	case TypeInterviewerAudio:
+		return PriorityAudio
+	default:
+		return PriorityControl
+	}
+}
+
+// outbound is a message waiting in a connection's queue
+type outbound struct {
+	frameType int
+	data      []byte
+}
+
+// QueueStats reports a connection's outbound queues
+type QueueStats struct {
+	Depth   map[string]int    `json:"depth"`
+	Dropped map[string]uint64 `json:"dropped"`
+}
+
+// enqueue queues a frame without blocking. When the control queue is full the
+// client is not keeping up at all and is disconnected; transcript and audio
+// queues drop their oldest message to make room, so a slow client never
+// stalls the goroutine producing the messages
+func (c *Conn) enqueue(priority Priority, frameType int, data []byte) error {
+	select {
+	case <-c.closing:
+		return ErrConnClosed
+	case <-c.done:
+		return ErrConnClosed
+	default:
+	}
+
+	queue := c.queues[priority]
+	item := outbound{frameType: frameType, data: data}
+
+	for {
+		select {
+		case queue <- item:
+			outboundQueued.WithLabelValues(priority.String()).Inc()
+			select {
+			case c.wake <- struct{}{}:
+			default:
+			}
+			return nil
+		default:
+		}
+
+		if priority == PriorityControl {
+			slowClientDisconnects.Inc()
+			go c.Close()
+			return errors.New("slow client: control queue full")
+		}
+
+		// Drop the oldest message of this priority and retry
+		select {
+		case <-queue:
+			outboundQueued.WithLabelValues(priority.String()).Dec()
+			outboundDropped.WithLabelValues(priority.String()).Inc()
+			c.dropped[priority].Add(1)
+		default:
+		}
+	}
+}
+
+// enqueueWait queues a frame, waiting for the writer to make room rather than
+// dropping anything. It is used to replay missed messages, which may not fit
+// in the queues at once
+func (c *Conn) enqueueWait(priority Priority, frameType int, data []byte) error {
+	select {
+	case c.queues[priority] <- outbound{frameType: frameType, data: data}:
+		outboundQueued.WithLabelValues(priority.String()).Inc()
+		select {
+		case c.wake <- struct{}{}:
+		default:
+		}
+		return nil
+	case <-c.closing:
+		return ErrConnClosed
+	case <-c.done:
+		return ErrConnClosed
+	}
+}
+
+// next returns the highest priority queued message
+func (c *Conn) next() (outbound, bool) {
+	for priority, queue := range c.queues {
+		select {
+		case item := <-queue:
+			outboundQueued.WithLabelValues(Priority(priority).String()).Dec()
+			return item, true
+		default:
+		}
+	}
+	return outbound{}, false
+}
+
+// writeLoop is the only goroutine that writes to the socket. It also pings the
+// client and enforces the heartbeat timeout
+func (c *Conn) writeLoop() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.options.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			if !c.keepalive(now) {
+				return
+			}
+			continue
+		default:
+		}
+
+		if item, ok := c.next(); ok {
+			c.ws.SetWriteDeadline(time.Now().Add(c.options.WriteTimeout))
+			if err := c.ws.WriteMessage(item.frameType, item.data); err != nil {
+				writeErrors.Inc()
+				c.fail(err)
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-c.wake:
+		case now := <-ticker.C:
+			if !c.keepalive(now) {
+				return
+			}
+		case <-c.closing:
+			c.drain()
+			return
+		}
+	}
+}
+
+// keepalive pings the client and checks its heartbeats. It reports false if
+// the connection was closed
+func (c *Conn) keepalive(now time.Time) bool {
+	if c.heartbeatExpired(now) {
+		keepalives.WithLabelValues("heartbeat_timeouts").Inc()
+		c.fail(ErrHeartbeatTimeout)
+		return false
+	}
+
+	if err := c.ws.WriteControl(websocket.PingMessage, nil, now.Add(c.options.WriteTimeout)); err != nil {
+		keepalives.WithLabelValues("ping_errors").Inc()
+		c.fail(err)
+		return false
+	}
+	keepalives.WithLabelValues("pings_sent").Inc()
+	return true
+}
+
+// fail closes a connection the writer can no longer use. The reader sees err
+// on its next read
+func (c *Conn) fail(err error) {
+	c.failure.Store(err)
+	c.ws.Close()
+	c.discardQueued()
+}
+
+// drain flushes queued messages within closeDrainTimeout, then sends a close
+// frame and closes the socket
+func (c *Conn) drain() {
+	deadline := time.Now().Add(closeDrainTimeout)
+	c.ws.SetWriteDeadline(deadline)
+
+	for {
+		item, ok := c.next()
+		if !ok {
+			break
+		}
+		if err := c.ws.WriteMessage(item.frameType, item.data); err != nil {
+			c.discardQueued()
+			break
+		}
+	}
+
+	c.ws.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), deadline)
+	c.ws.Close()
+}
+
+// discardQueued empties the queues of a connection that can no longer be written
+func (c *Conn) discardQueued() {
+	for {
+		if _, ok := c.next(); !ok {
+			return
+		}
+	}
+}
+
+// QueueStats returns the current depth and drop counts of the outbound queues
+func (c *Conn) QueueStats() QueueStats {
+	stats := QueueStats{
+		Depth:   make(map[string]int, numPriorities),
+		Dropped: make(map[string]uint64, numPriorities),
+	}
+	for priority, queue := range c.queues {
+		name := Priority(priority).String()
+		stats.Depth[name] = len(queue)
+		stats.Dropped[name] = c.dropped[priority].Load()
+	}
+	return stats
+}
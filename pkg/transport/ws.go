@@ -1,26 +1,440 @@
 package transport
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
 )
 
-// WSHandler handles WebSocket connections
+// ErrHeartbeatTimeout is returned by ReadFrame when the client stopped sending
+// heartbeat messages
+var ErrHeartbeatTimeout = errors.New("client missed heartbeats")
+
+// WSOptions configures connection keepalive and which origins may connect.
+// Zero values use the defaults
+type WSOptions struct {
+	PingInterval     time.Duration // How often the server pings the client
+	ReadTimeout      time.Duration // Max time without a frame or pong from the client
+	WriteTimeout     time.Duration // Max time for a single frame write
+	HeartbeatTimeout time.Duration // Max time between heartbeat messages, once the client has sent one
+
+	// CheckOrigin reports whether an upgrade request's origin is allowed. If
+	// nil, only requests from the service's own origin or without one are
+	CheckOrigin func(r *http.Request) bool
+}
+
+// DefaultWSOptions returns the default keepalive settings
+func DefaultWSOptions() WSOptions {
+	return WSOptions{
+		PingInterval:     15 * time.Second,
+		ReadTimeout:      45 * time.Second,
+		WriteTimeout:     10 * time.Second,
+		HeartbeatTimeout: 30 * time.Second,
+	}
+}
+
+// FrameKind classifies an inbound frame
+type FrameKind int
+
+const (
+	FrameAudio     FrameKind = iota // Raw audio from a binary frame or a legacy audio_data message
+	FrameControl                    // JSON control message
+	FrameAudioLoss                  // Audio lost in transit, to be concealed by the decoder (WebRTC only)
+)
+
+// Frame is a single inbound message from a client
+type Frame struct {
+	Kind    FrameKind
+	Audio   []byte
+	Message Message
+}
+
+// ProtocolError is returned by ReadFrame for a message that could not be
+// understood. The connection is still usable and the error should be reported
+// to the client
+type ProtocolError struct {
+	Code    string
+	Message string
+}
+
+func (e *ProtocolError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// IsCloseError reports whether err is a normal client-initiated close
+func IsCloseError(err error) bool {
+	return websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway)
+}
+
+// WSHandler upgrades HTTP requests to protocol connections and tracks them
 type WSHandler struct {
-	// TODO: Add WebSocket handler implementation fields
+	options  WSOptions
+	upgrader websocket.Upgrader
+	conns    map[*Conn]struct{}
+	mu       sync.RWMutex
 }
 
 // NewWSHandler creates a new WebSocket handler
-func NewWSHandler() *WSHandler {
-	return &WSHandler{}
+func NewWSHandler(opts WSOptions) *WSHandler {
+	defaults := DefaultWSOptions()
+	if opts.PingInterval <= 0 {
+		opts.PingInterval = defaults.PingInterval
+	}
+	if opts.ReadTimeout <= 0 {
+		opts.ReadTimeout = defaults.ReadTimeout
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = defaults.WriteTimeout
+	}
+	if opts.HeartbeatTimeout <= 0 {
+		opts.HeartbeatTimeout = defaults.HeartbeatTimeout
+	}
+
+	return &WSHandler{
+		options: opts,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: opts.CheckOrigin,
+		},
+		conns: make(map[*Conn]struct{}),
+	}
 }
 
-// HandleConnection handles a WebSocket connection
-func (w *WSHandler) HandleConnection(writer http.ResponseWriter, request *http.Request) {
-	// TODO: Implement WebSocket connection handling
+// Upgrade upgrades an HTTP request to a protocol connection. The caller must
+// run Handshake before reading frames
+func (h *WSHandler) Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	ws, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &Conn{
+		handler: h,
+		options: h.options,
+		ws:      ws,
+		wake:    make(chan struct{}, 1),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for priority := range conn.queues {
+		conn.queues[priority] = make(chan outbound, queueSizes[priority])
+	}
+
+	// Any frame or pong from the client proves the connection is alive
+	conn.extendReadDeadline()
+	ws.SetPongHandler(func(string) error {
+		conn.extendReadDeadline()
+		return nil
+	})
+
+	h.mu.Lock()
+	h.conns[conn] = struct{}{}
+	h.mu.Unlock()
+
+	go conn.writeLoop()
+
+	return conn, nil
 }
 
 // BroadcastMessage broadcasts a message to all connected clients
-func (w *WSHandler) BroadcastMessage(message []byte) error {
-	// TODO: Implement message broadcasting
+func (h *WSHandler) BroadcastMessage(message []byte) error {
+	h.mu.RLock()
+	conns := make([]*Conn, 0, len(h.conns))
+	for conn := range h.conns {
+		conns = append(conns, conn)
+	}
+	h.mu.RUnlock()
+
+	var errs []error
+	for _, conn := range conns {
+		if err := conn.enqueue(PriorityControl, websocket.TextMessage, message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ConnectionCount returns the number of open connections
+func (h *WSHandler) ConnectionCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.conns)
+}
+
+// Conn is a client connection speaking the interview protocol. Binary frames
+// carry audio in the negotiated format, text frames carry JSON control messages.
+// Outbound messages are queued by priority and written by a single writer
+// goroutine, so Send never blocks on the network
+type Conn struct {
+	handler   *WSHandler
+	options   WSOptions
+	ws        *websocket.Conn
+	version   atomic.Int32 // Negotiated protocol version, LegacyProtocolVersion until the handshake
+	sequenced atomic.Bool  // Binary audio frames carry an AudioHeaderSize header
+	pending   *Frame       // First frame of a legacy client, read during the handshake
+	queues    [numPriorities]chan outbound
+	dropped   [numPriorities]atomic.Uint64
+	wake      chan struct{} // Signals the writer that a message was queued
+	closing   chan struct{} // Closed when Close is called
+	done      chan struct{} // Closed when the writer has exited
+	closeOnce sync.Once
+
+	lastHeartbeat atomic.Int64 // Unix nanoseconds of the last heartbeat, 0 until the first
+	failure       atomic.Value // error that made the writer close the connection
+}
+
+// inboundMessage accepts both the versioned envelope and legacy flat messages
+type inboundMessage struct {
+	Message
+	AudioData string `json:"audio_data,omitempty"`
+}
+
+// Handshake negotiates the protocol version and audio format. A client that
+// opens with a start message gets a ready reply; a client that starts sending
+// audio straight away is treated as a legacy version 0 client
+func (c *Conn) Handshake(sessionID string, format AudioFormat, timeout time.Duration) error {
+	if timeout > 0 {
+		c.ws.SetReadDeadline(time.Now().Add(timeout))
+		defer c.extendReadDeadline()
+	}
+
+	frame, err := c.readFrame()
+	if err != nil {
+		var protocolErr *ProtocolError
+		if errors.As(err, &protocolErr) {
+			c.sendHandshakeError(protocolErr.Code, protocolErr.Message)
+		}
+		return err
+	}
+
+	if frame.Kind != FrameControl || frame.Message.Type != TypeStart {
+		// Legacy clients skip the handshake, keep the frame for the first read
+		c.pending = &frame
+		return nil
+	}
+
+	var start StartMessage
+	if err := frame.Message.Decode(&start); err != nil {
+		c.sendHandshakeError(ErrorCodeBadMessage, err.Error())
+		return err
+	}
+
+	offered := start.Versions
+	if len(offered) == 0 && frame.Message.Version > 0 {
+		offered = []int{frame.Message.Version}
+	}
+	version := negotiateVersion(offered)
+	if version == 0 {
+		err := fmt.Errorf("no common protocol version in %v (supported: %v)", offered, SupportedVersions)
+		c.sendHandshakeError(ErrorCodeUnsupportedVersion, err.Error())
+		return err
+	}
+
+	if start.Format != nil && *start.Format != format {
+		err := fmt.Errorf("client audio format %+v does not match session format %+v", *start.Format, format)
+		c.sendHandshakeError(ErrorCodeFormatMismatch, err.Error())
+		return err
+	}
+
+	c.version.Store(int32(version))
+	c.sequenced.Store(start.SequencedAudio)
+	return c.Send(TypeReady, ReadyMessage{
+		SessionID:      sessionID,
+		Version:        version,
+		Format:         format,
+		SequencedAudio: start.SequencedAudio,
+	})
+}
+
+// sendHandshakeError reports a failed handshake using the current envelope,
+// since no version was agreed
+func (c *Conn) sendHandshakeError(code, message string) {
+	c.version.Store(ProtocolVersion)
+	c.SendError(code, message)
+}
+
+// negotiateVersion returns the highest version offered by the client that the
+// server supports, or 0 if there is none
+func negotiateVersion(offered []int) int {
+	for _, supported := range SupportedVersions {
+		for _, version := range offered {
+			if version == supported {
+				return supported
+			}
+		}
+	}
+	return 0
+}
+
+// Version returns the negotiated protocol version
+func (c *Conn) Version() int {
+	return int(c.version.Load())
+}
+
+// SequencedAudio reports whether binary audio frames carry a sequence header
+func (c *Conn) SequencedAudio() bool {
+	return c.sequenced.Load()
+}
+
+// ReadFrame reads the next audio or control frame. A *ProtocolError means the
+// frame was rejected but the connection is still open
+func (c *Conn) ReadFrame() (Frame, error) {
+	if c.pending != nil {
+		frame := *c.pending
+		c.pending = nil
+		return frame, nil
+	}
+	return c.readFrame()
+}
+
+// readFrame reads and classifies one frame from the socket
+func (c *Conn) readFrame() (Frame, error) {
+	messageType, data, err := c.ws.ReadMessage()
+	if err != nil {
+		if failure, ok := c.failure.Load().(error); ok {
+			return Frame{}, failure
+		}
+		return Frame{}, err
+	}
+	c.extendReadDeadline()
+
+	if messageType == websocket.BinaryMessage {
+		return Frame{Kind: FrameAudio, Audio: data}, nil
+	}
+
+	msg, err := parseInbound(data)
+	if err != nil {
+		return Frame{}, err
+	}
+
+	if msg.Type == TypeLegacyAudio {
+		audio, err := base64.StdEncoding.DecodeString(msg.AudioData)
+		if err != nil {
+			return Frame{}, &ProtocolError{Code: ErrorCodeBadMessage, Message: "audio_data is not valid base64"}
+		}
+		return Frame{Kind: FrameAudio, Audio: audio}, nil
+	}
+
+	if msg.Type == TypeHeartbeat {
+		c.lastHeartbeat.Store(time.Now().UnixNano())
+	}
+
+	return Frame{Kind: FrameControl, Message: msg.Message}, nil
+}
+
+// parseInbound decodes and checks a JSON control message
+func parseInbound(data []byte) (inboundMessage, error) {
+	var msg inboundMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return msg, &ProtocolError{Code: ErrorCodeBadMessage, Message: "invalid JSON message"}
+	}
+	if msg.Type == "" {
+		return msg, &ProtocolError{Code: ErrorCodeBadMessage, Message: "message type is required"}
+	}
+	if msg.Version > ProtocolVersion {
+		return msg, &ProtocolError{
+			Code:    ErrorCodeUnsupportedVersion,
+			Message: fmt.Sprintf("message version %d is newer than %d", msg.Version, ProtocolVersion),
+		}
+	}
+	return msg, nil
+}
+
+// extendReadDeadline gives the client another ReadTimeout to send something
+func (c *Conn) extendReadDeadline() {
+	c.ws.SetReadDeadline(time.Now().Add(c.options.ReadTimeout))
+}
+
+// heartbeatExpired reports whether a client that sends heartbeats stopped
+func (c *Conn) heartbeatExpired(now time.Time) bool {
+	last := c.lastHeartbeat.Load()
+	return last != 0 && now.Sub(time.Unix(0, last)) > c.options.HeartbeatTimeout
+}
+
+// Send queues a message in the connection's protocol version. It never blocks;
+// see enqueue for what happens when the client cannot keep up
+func (c *Conn) Send(messageType string, payload interface{}) error {
+	msg, err := NewMessage(messageType, payload)
+	if err != nil {
+		return err
+	}
+	return c.SendMessage(msg)
+}
+
+// SendMessage queues an already built message, keeping its sequence number
+func (c *Conn) SendMessage(msg Message) error {
+	data, err := c.encode(msg)
+	if err != nil {
+		return err
+	}
+	return c.enqueue(PriorityFor(msg.Type), websocket.TextMessage, data)
+}
+
+// ReplayMessage queues a replayed message, waiting for room in its queue
+func (c *Conn) ReplayMessage(msg Message) error {
+	data, err := c.encode(msg)
+	if err != nil {
+		return err
+	}
+	return c.enqueueWait(PriorityFor(msg.Type), websocket.TextMessage, data)
+}
+
+// encode serializes a message for the negotiated protocol version
+func (c *Conn) encode(msg Message) ([]byte, error) {
+	if c.Version() == LegacyProtocolVersion {
+		return legacyMessage(msg)
+	}
+	return json.Marshal(msg)
+}
+
+// SendAudio queues a binary audio frame on the audio queue
+func (c *Conn) SendAudio(data []byte) error {
+	return c.enqueue(PriorityAudio, websocket.BinaryMessage, data)
+}
+
+// SendError queues an error message
+func (c *Conn) SendError(code, message string) error {
+	return c.Send(TypeError, ErrorMessage{Code: code, Message: message})
+}
+
+// legacyMessage encodes a message for version 0 clients, which expect the
+// payload fields at the top level next to the type
+func legacyMessage(msg Message) ([]byte, error) {
+	fields := make(map[string]interface{})
+	if len(msg.Payload) > 0 {
+		if err := json.Unmarshal(msg.Payload, &fields); err != nil {
+			return nil, fmt.Errorf("%s payload is not an object: %w", msg.Type, err)
+		}
+	}
+
+	fields["type"] = msg.Type
+	fields["timestamp"] = msg.Timestamp / 1000
+	if msg.Seq > 0 {
+		fields["seq"] = msg.Seq
+	}
+	if code, ok := fields["code"].(string); ok && msg.Type == TypeError {
+		fields["error_type"] = strings.ToUpper(code)
+	}
+	return json.Marshal(fields)
+}
+
+// Close flushes queued messages, sends a normal close frame and closes the
+// connection
+func (c *Conn) Close() error {
+	c.closeOnce.Do(func() {
+		c.handler.mu.Lock()
+		delete(c.handler.conns, c)
+		c.handler.mu.Unlock()
+
+		close(c.closing)
+	})
+	<-c.done
 	return nil
 }
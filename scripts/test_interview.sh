@@ -6,6 +6,12 @@ echo "======================================"
 
 BASE_URL="http://localhost:8080"
 
+# Bearer token for servers with authentication enabled
+AUTH_HEADER=()
+if [ -n "$ACCESS_TOKEN" ]; then
+    AUTH_HEADER=(-H "Authorization: Bearer $ACCESS_TOKEN")
+fi
+
 # Test health endpoint
 echo "1. Testing health endpoint..."
 curl -s "$BASE_URL/health" | jq . || echo "❌ Health check failed"
@@ -14,6 +20,7 @@ echo ""
 # Test session initialization
 echo "2. Creating new interview session..."
 SESSION_RESPONSE=$(curl -s -X POST "$BASE_URL/api/interview/init" \
+  "${AUTH_HEADER[@]}" \
   -H "Content-Type: application/json" \
   -d '{"sample_rate": 16000, "encoding": "pcm_s16le"}')
 
@@ -21,17 +28,21 @@ echo "Response: $SESSION_RESPONSE"
 
 # Extract session ID
 SESSION_ID=$(echo "$SESSION_RESPONSE" | jq -r '.session_id')
+TICKET=$(echo "$SESSION_RESPONSE" | jq -r '.ticket // empty')
 echo "Session ID: $SESSION_ID"
 echo ""
 
 # Test session status
 echo "3. Checking session status..."
-curl -s "$BASE_URL/api/interview/status?session_id=$SESSION_ID" | jq . || echo "❌ Status check failed"
+curl -s "${AUTH_HEADER[@]}" "$BASE_URL/api/interview/status?session_id=$SESSION_ID" | jq . || echo "❌ Status check failed"
 echo ""
 
 # Test WebSocket connection
 echo "4. Testing WebSocket connection..."
 WS_URL="ws://localhost:8080/ws/interview/$SESSION_ID"
+if [ -n "$TICKET" ]; then
+    WS_URL="$WS_URL?ticket=$TICKET"
+fi
 echo "Connecting to: $WS_URL"
 
 # Use websocat if available, otherwise provide instructions
@@ -62,6 +73,7 @@ else
     echo "1. Install websocat: brew install websocat"
     echo "2. Connect using: websocat $WS_URL"
     echo "3. Send audio data as JSON: {\"type\":\"audio_data\",\"audio_data\":\"<base64-audio>\"}"
+    echo "   (legacy protocol; see docs/frontend.md for the versioned start handshake)"
 fi
 
 # Wait a moment
@@ -0,0 +1,28 @@
+#!/bin/bash
+
+# Replays a recorded telephony media stream (8kHz μ-law phone audio) into the
+# telephony adapter, the way a provider would bridge a phone call
+echo "📞 Testing Call Service telephony adapter"
+echo "========================================="
+
+BASE_URL="${BASE_URL:-ws://localhost:8080}"
+FIXTURE="${1:-$(dirname "$0")/fixtures/telephony_stream.jsonl}"
+WS_URL="$BASE_URL/ws/telephony"
+
+if ! command -v websocat &> /dev/null; then
+    echo "⚠️  websocat not installed. Install it with: brew install websocat"
+    exit 1
+fi
+
+echo "Replaying $FIXTURE to $WS_URL"
+echo "(connected, start, 3 seconds of 20ms media events, a mark and stop)"
+
+# Events are sent 20ms apart, like a live call; interviewer audio and marks
+# sent back by the server are printed
+while IFS= read -r event; do
+    echo "$event"
+    sleep 0.02
+done < "$FIXTURE" | websocat "$WS_URL"
+
+echo ""
+echo "✅ Stream finished, check the server log for the session transcript"